@@ -5,11 +5,31 @@
 // outputDir
 //   |-> TEST_NAME.log
 //   |-> summary.log
+//   |-> summary.json
+//   |-> slowest.log
 //   |-> report.xml
+//   |-> report.html
 // where:
 // - `TEST_NAME.log` is a log for each test run that only includes the relevant logs for that test.
 // - `summary.log` is a summary of all the tests in the suite, including PASS/FAIL information.
+// - `summary.json` is the same summary in JSON format, including durations and failure excerpts.
+// - `slowest.log` lists the slowest tests in the suite.
 // - `report.xml` is the test summary in junit XML format to be consumed by a CI engine.
+// - `report.html` is a self-contained HTML report with a sortable test table, failure excerpts and per-test logs.
+//
+// The input can either be the plain text output of `go test -v` (the default), or the event stream of `go test -json`
+// when run with `--format=json`. The JSON format is more robust, as each event identifies its package and test, so the
+// parsing heuristics described below are not needed.
+//
+// To track flaky tests, keep the output directories of previous runs (e.g. as CI artifacts) in a history directory, and
+// pass it with `--history-dir`. The command then also writes `flakiness.md` and `flakiness.json` to the output
+// directory, with the pass rate, duration trend and flakiness of every test across the previous runs and the current
+// one. A test is flaky if it both passed and failed on the same commit, so pass the commit under test with `--commit`;
+// it is recorded in a `commit` file in the output directory so that future runs can use it.
+//
+// The log of each failed test is scanned for known causes of infrastructure test failures, such as cloud API
+// throttling or a held state lock, and the cause is recorded in `report.xml`, `summary.json` and `report.html`. Pass a
+// YAML or JSON file of additional rules with `--failure-rules`; these are checked before the built in rules.
 //
 // Certain tradeoffs were made in the decision to implement this functionality as a separate parsing command, as opposed
 // to being built into the logger module as part of `Logf`. Specifically, this implementation avoids the difficulties of
@@ -31,6 +51,9 @@
 //   at certain points in code, the information of which is lost in the final log and have to parse out.
 // - Have to store all the logs twice (the full interleaved version, and the broken out version) because the parsing
 //   depends on logs being available. (NOTE: this is avoidable with a pipe).
+//
+// If you control the test code, logger.PerTestFileSink avoids these cons by writing each test's logs to its own file
+// as they happen. This command remains useful for producing the summary and junit report.
 
 package main
 
@@ -49,15 +72,23 @@ import (
 
 var logger = logging.GetLogger("terratest_log_parser")
 
-const CUSTOM_USAGE_TEXT = `Usage: terratest_log_parser [--help] [--log-level=info] [--testlog=LOG_INPUT] [--outputdir=OUTPUT_DIR]
+const CUSTOM_USAGE_TEXT = `Usage: terratest_log_parser [--help] [--log-level=info] [--format=text] [--testlog=LOG_INPUT] [--outputdir=OUTPUT_DIR] [--history-dir=HISTORY_DIR] [--commit=COMMIT]
 
 A tool for parsing parallel terratest output to produce a test summary and to break out the interleaved logs by test for better debuggability.
 
 Options:
    --log-level LEVEL  Set the log level to LEVEL. Must be one of: [panic fatal error warning info debug]
                       (default: "info")
+   --format FORMAT    Format of the test log. Must be one of: [text json]. Use json for the output of 'go test -json'.
+                      (default: "text")
    --testlog value    Path to file containing test log. If unset will use stdin.
    --outputdir value  Path to directory to output test output to. If unset will use the current directory.
+   --history-dir DIR  Path to a directory containing the output directories of previous runs. If set, a flakiness
+                      report across those runs and the current one is written to the output directory.
+   --commit COMMIT    The commit under test, recorded in the output directory and used to detect flaky tests.
+   --failure-rules FILE
+                      Path to a YAML or JSON file with a list of rules (cause, pattern, description) to classify the
+                      causes of test failures, in addition to the built in rules.
    --help, -h         show help
 `
 
@@ -65,12 +96,24 @@ func run(cliContext *cli.Context) error {
 	filename := cliContext.String("testlog")
 	outputDir := cliContext.String("outputdir")
 	logLevel := cliContext.String("log-level")
+	format := cliContext.String("format")
+	historyDir := cliContext.String("history-dir")
+	commit := cliContext.String("commit")
+	failureRulesFile := cliContext.String("failure-rules")
+	if format != "text" && format != "json" {
+		return errors.WithStackTrace(fmt.Errorf("unknown format %q: must be one of [text json]", format))
+	}
 	level, err := logrus.ParseLevel(logLevel)
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
 	logger.SetLevel(level)
 
+	classifier, err := newFailureClassifier(failureRulesFile)
+	if err != nil {
+		return err
+	}
+
 	var file *os.File
 	if filename != "" {
 		logger.Infof("reading from file")
@@ -89,7 +132,61 @@ func run(cliContext *cli.Context) error {
 		logger.Fatalf("Error extracting absolute path of output directory: %s", err)
 	}
 
-	parser.SpawnParsers(logger, file, outputDir)
+	if format == "json" {
+		parser.ParseJSONOutputWithClassifier(logger, file, outputDir, classifier)
+	} else {
+		parser.SpawnParsersWithClassifier(logger, file, outputDir, classifier)
+	}
+
+	if commit != "" {
+		parser.StoreCommit(logger, outputDir, commit)
+	}
+	if historyDir != "" {
+		return storeFlakinessReport(historyDir, outputDir)
+	}
+	return nil
+}
+
+// newFailureClassifier returns a classifier for the rules in the given file, if any, followed by the built in rules.
+func newFailureClassifier(failureRulesFile string) (*parser.FailureClassifier, error) {
+	rules := []parser.FailureRule{}
+	if failureRulesFile != "" {
+		userRules, err := parser.LoadFailureRules(failureRulesFile)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, userRules...)
+	}
+	return parser.NewFailureClassifier(append(rules, parser.DefaultFailureRules()...))
+}
+
+// storeFlakinessReport analyzes the runs in the history directory along with the run in the output directory, and
+// stores the flakiness report in the output directory.
+func storeFlakinessReport(historyDir string, outputDir string) error {
+	historyDir, err := filepath.Abs(historyDir)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	history, err := parser.LoadRunHistory(historyDir)
+	if err != nil {
+		return err
+	}
+
+	runs := []parser.Run{}
+	for _, run := range history {
+		// The output directory may itself be in the history directory, in which case it is added last below
+		if filepath.Join(historyDir, run.Name) != outputDir {
+			runs = append(runs, run)
+		}
+	}
+	currentRun, err := parser.LoadRun(outputDir)
+	if err != nil {
+		return err
+	}
+	runs = append(runs, currentRun)
+
+	logger.Infof("analyzing flakiness across %d runs", len(runs))
+	parser.StoreFlakinessReport(logger, outputDir, parser.AnalyzeFlakiness(runs))
 	return nil
 }
 
@@ -124,10 +221,31 @@ func main() {
 		Value: logrus.InfoLevel.String(),
 		Usage: fmt.Sprintf("Set the log level to `LEVEL`. Must be one of: %v", logrus.AllLevels),
 	}
+	formatFlag := cli.StringFlag{
+		Name:  "format",
+		Value: "text",
+		Usage: "Format of the test log. Must be one of: [text json]. Use json for the output of 'go test -json'.",
+	}
+	historyDirFlag := cli.StringFlag{
+		Name:  "history-dir",
+		Usage: "Path to a directory containing the output directories of previous runs. If set, a flakiness report across those runs and the current one is written to the output directory.",
+	}
+	commitFlag := cli.StringFlag{
+		Name:  "commit",
+		Usage: "The commit under test, recorded in the output directory and used to detect flaky tests.",
+	}
+	failureRulesFlag := cli.StringFlag{
+		Name:  "failure-rules",
+		Usage: "Path to a YAML or JSON file with a list of rules (cause, pattern, description) to classify the causes of test failures, in addition to the built in rules.",
+	}
 	app.Flags = []cli.Flag{
 		logLevelFlag,
+		formatFlag,
 		logInputFlag,
 		outputDirFlag,
+		historyDirFlag,
+		commitFlag,
+		failureRulesFlag,
 	}
 
 	entrypoint.RunApp(app)
@@ -104,11 +104,24 @@ func WaitForCapacityE(
 	maxRetries int,
 	sleepBetweenRetries time.Duration,
 ) error {
-	msg, err := retry.DoWithRetryE(
+	return WaitForCapacityWithPolicyE(t, asgName, region, retry.FixedPolicy(maxRetries, sleepBetweenRetries))
+}
+
+// WaitForCapacityWithPolicy waits for the currently set desired capacity to be reached on the ASG, retrying according
+// to the given policy, e.g. with exponential backoff.
+func WaitForCapacityWithPolicy(t testing.TestingT, asgName string, region string, policy retry.Policy) {
+	err := WaitForCapacityWithPolicyE(t, asgName, region, policy)
+	require.NoError(t, err)
+}
+
+// WaitForCapacityWithPolicyE waits for the currently set desired capacity to be reached on the ASG, retrying according
+// to the given policy, e.g. with exponential backoff.
+func WaitForCapacityWithPolicyE(t testing.TestingT, asgName string, region string, policy retry.Policy) error {
+	start := time.Now()
+	msg, err := retry.DoWithPolicyE(
 		t,
 		fmt.Sprintf("Waiting for ASG %s to reach desired capacity.", asgName),
-		maxRetries,
-		sleepBetweenRetries,
+		policy,
 		func() (string, error) {
 			capacityInfo, err := GetCapacityInfoForAsgE(t, asgName, region)
 			if err != nil {
@@ -120,7 +133,7 @@ func WaitForCapacityE(
 			return fmt.Sprintf("ASG %s is now at desired capacity %d", asgName, capacityInfo.DesiredCapacity), nil
 		},
 	)
-	logger.Log(t, msg)
+	resourceLogger("autoscalinggroup/"+asgName).WithFields(logger.Fields{logger.FieldDuration: time.Since(start)}).Logf(t, "%s", msg)
 	return err
 }
 
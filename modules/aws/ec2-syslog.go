@@ -29,11 +29,17 @@ func GetSyslogForInstance(t testing.TestingT, instanceID string, awsRegion strin
 // GetSyslogForInstanceE gets the syslog for the Instance with the given ID in the given region. This should be available ~1 minute after an
 // Instance boots and is very useful for debugging boot-time issues, such as an error in User Data.
 func GetSyslogForInstanceE(t testing.TestingT, instanceID string, region string) (string, error) {
+	return GetSyslogForInstanceWithPolicyE(t, instanceID, region, retry.FixedPolicy(120, 5*time.Second))
+}
+
+// (Deprecated) See the FetchContentsOfFileFromInstanceE method for a more powerful solution.
+//
+// GetSyslogForInstanceWithPolicyE gets the syslog for the Instance with the given ID in the given region, waiting for
+// it to be available according to the given retry policy instead of every 5 seconds for 10 minutes.
+func GetSyslogForInstanceWithPolicyE(t testing.TestingT, instanceID string, region string, policy retry.Policy) (string, error) {
 	description := fmt.Sprintf("Fetching syslog for Instance %s in %s", instanceID, region)
-	maxRetries := 120
-	timeBetweenRetries := 5 * time.Second
 
-	logger.Log(t, description)
+	resourceLogger("instance/"+instanceID).Logf(t, "%s", description)
 
 	client, err := NewEc2ClientE(t, region)
 	if err != nil {
@@ -44,7 +50,7 @@ func GetSyslogForInstanceE(t testing.TestingT, instanceID string, region string)
 		InstanceId: aws.String(instanceID),
 	}
 
-	syslogB64, err := retry.DoWithRetryE(t, description, maxRetries, timeBetweenRetries, func() (string, error) {
+	syslogB64, err := retry.DoWithPolicyE(t, description, policy, func() (string, error) {
 		out, err := client.GetConsoleOutput(&input)
 		if err != nil {
 			return "", err
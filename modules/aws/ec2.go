@@ -5,7 +5,6 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/tnn-gruntwork-io/terratest/modules/logger"
 	"github.com/tnn-gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 )
@@ -268,7 +267,7 @@ func DeleteAmi(t testing.TestingT, region string, imageID string) {
 
 // DeleteAmiE deletes the given AMI in the given region.
 func DeleteAmiE(t testing.TestingT, region string, imageID string) error {
-	logger.Logf(t, "Deregistering AMI %s", imageID)
+	resourceLogger("image/"+imageID).Logf(t, "Deregistering AMI %s", imageID)
 
 	client, err := NewEc2ClientE(t, region)
 	if err != nil {
@@ -314,7 +313,7 @@ func TerminateInstance(t testing.TestingT, region string, instanceID string) {
 
 // TerminateInstanceE terminates the EC2 instance with the given ID in the given region.
 func TerminateInstanceE(t testing.TestingT, region string, instanceID string) error {
-	logger.Logf(t, "Terminating Instance %s", instanceID)
+	resourceLogger("instance/"+instanceID).Logf(t, "Terminating Instance %s", instanceID)
 
 	client, err := NewEc2ClientE(t, region)
 	if err != nil {
@@ -0,0 +1,11 @@
+package aws
+
+import (
+	"github.com/tnn-gruntwork-io/terratest/modules/logger"
+)
+
+// resourceLogger returns a logger that attaches the given AWS resource, e.g. "instance/i-0123456789abcdef0", as a
+// structured field to the messages logged about it.
+func resourceLogger(resource string) *logger.Logger {
+	return logger.Default.WithFields(logger.Fields{logger.FieldResource: resource})
+}
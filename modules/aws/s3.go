@@ -136,7 +136,7 @@ func CreateS3Bucket(t testing.TestingT, region string, name string) {
 
 // CreateS3BucketE creates an S3 bucket in the given region with the given name. Note that S3 bucket names must be globally unique.
 func CreateS3BucketE(t testing.TestingT, region string, name string) error {
-	logger.Logf(t, "Creating bucket %s in %s", name, region)
+	resourceLogger("s3://"+name).Logf(t, "Creating bucket %s in %s", name, region)
 
 	s3Client, err := NewS3ClientE(t, region)
 	if err != nil {
@@ -209,7 +209,7 @@ func DeleteS3Bucket(t testing.TestingT, region string, name string) {
 
 // DeleteS3BucketE destroys the S3 bucket in the given region with the given name.
 func DeleteS3BucketE(t testing.TestingT, region string, name string) error {
-	logger.Logf(t, "Deleting bucket %s in %s", region, name)
+	resourceLogger("s3://"+name).Logf(t, "Deleting bucket %s in %s", region, name)
 
 	s3Client, err := NewS3ClientE(t, region)
 	if err != nil {
@@ -231,7 +231,7 @@ func EmptyS3Bucket(t testing.TestingT, region string, name string) {
 
 // EmptyS3BucketE removes the contents of an S3 bucket in the given region with the given name.
 func EmptyS3BucketE(t testing.TestingT, region string, name string) error {
-	logger.Logf(t, "Emptying bucket %s in %s", name, region)
+	resourceLogger("s3://"+name).Logf(t, "Emptying bucket %s in %s", name, region)
 
 	s3Client, err := NewS3ClientE(t, region)
 	if err != nil {
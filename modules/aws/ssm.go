@@ -6,7 +6,6 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ssm"
-	"github.com/tnn-gruntwork-io/terratest/modules/logger"
 	"github.com/tnn-gruntwork-io/terratest/modules/retry"
 	"github.com/tnn-gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
@@ -119,8 +118,12 @@ func WaitForSsmInstanceE(t testing.TestingT, awsRegion, instanceID string, timeo
 
 // WaitForSsmInstanceE waits until the instance get registered to the SSM inventory with the ability to provide the SSM client.
 func WaitForSsmInstanceWithClientE(t testing.TestingT, client *ssm.SSM, instanceID string, timeout time.Duration) error {
-	timeBetweenRetries := 2 * time.Second
-	maxRetries := int(timeout.Seconds() / timeBetweenRetries.Seconds())
+	return WaitForSsmInstanceWithClientAndPolicyE(t, client, instanceID, ssmTimeoutPolicy(timeout))
+}
+
+// WaitForSsmInstanceWithClientAndPolicyE waits until the instance get registered to the SSM inventory with the ability
+// to provide the SSM client, retrying according to the given policy instead of every 2 seconds until a timeout.
+func WaitForSsmInstanceWithClientAndPolicyE(t testing.TestingT, client *ssm.SSM, instanceID string, policy retry.Policy) error {
 	description := fmt.Sprintf("Waiting for %s to appear in the SSM inventory", instanceID)
 
 	input := &ssm.GetInventoryInput{
@@ -132,7 +135,7 @@ func WaitForSsmInstanceWithClientE(t testing.TestingT, client *ssm.SSM, instance
 			},
 		},
 	}
-	_, err := retry.DoWithRetryE(t, description, maxRetries, timeBetweenRetries, func() (string, error) {
+	_, err := retry.DoWithPolicyE(t, description, policy, func() (string, error) {
 		resp, err := client.GetInventory(input)
 
 		if err != nil {
@@ -186,7 +189,7 @@ func CheckSsmCommandWithDocument(t testing.TestingT, awsRegion, instanceID, comm
 
 // CheckSsmCommandWithDocumentE checks that you can run the given command on the given instance through AWS SSM with specified Command Doc type. Returns the result and an error if one occurs.
 func CheckSsmCommandWithDocumentE(t testing.TestingT, awsRegion, instanceID, command string, commandDocName string, timeout time.Duration) (*CommandOutput, error) {
-	logger.Logf(t, "Running command '%s' on EC2 instance with ID '%s'", command, instanceID)
+	resourceLogger("instance/"+instanceID).Logf(t, "Running command '%s' on EC2 instance with ID '%s'", command, instanceID)
 
 	// Now that we know the instance in the SSM inventory, we can send the command
 	client, err := NewSsmClientE(t, awsRegion)
@@ -198,10 +201,11 @@ func CheckSsmCommandWithDocumentE(t testing.TestingT, awsRegion, instanceID, com
 
 // CheckSSMCommandWithClientWithDocumentE checks that you can run the given command on the given instance through AWS SSM with the ability to provide the SSM client with specified Command Doc type. Returns the result and an error if one occurs.
 func CheckSSMCommandWithClientWithDocumentE(t testing.TestingT, client *ssm.SSM, instanceID, command string, commandDocName string, timeout time.Duration) (*CommandOutput, error) {
+	return CheckSSMCommandWithClientWithDocumentAndPolicyE(t, client, instanceID, command, commandDocName, ssmTimeoutPolicy(timeout))
+}
 
-	timeBetweenRetries := 2 * time.Second
-	maxRetries := int(timeout.Seconds() / timeBetweenRetries.Seconds())
-
+// CheckSSMCommandWithClientWithDocumentAndPolicyE checks that you can run the given command on the given instance through AWS SSM with the ability to provide the SSM client with specified Command Doc type, waiting for the result according to the given retry policy instead of every 2 seconds until a timeout. Returns the result and an error if one occurs.
+func CheckSSMCommandWithClientWithDocumentAndPolicyE(t testing.TestingT, client *ssm.SSM, instanceID, command string, commandDocName string, policy retry.Policy) (*CommandOutput, error) {
 	resp, err := client.SendCommand(&ssm.SendCommandInput{
 		Comment:      aws.String("Terratest SSM"),
 		DocumentName: aws.String(commandDocName),
@@ -224,7 +228,7 @@ func CheckSSMCommandWithClientWithDocumentE(t testing.TestingT, client *ssm.SSM,
 	}
 
 	result := &CommandOutput{}
-	_, err = retry.DoWithRetryableErrorsE(t, description, retryableErrors, maxRetries, timeBetweenRetries, func() (string, error) {
+	_, err = retry.DoWithRetryableErrorsAndPolicyE(t, description, retryableErrors, policy, func() (string, error) {
 		resp, err := client.GetCommandInvocation(&ssm.GetCommandInvocationInput{
 			CommandId:  resp.Command.CommandId,
 			InstanceId: &instanceID,
@@ -260,3 +264,9 @@ func CheckSSMCommandWithClientWithDocumentE(t testing.TestingT, client *ssm.SSM,
 
 	return result, nil
 }
+
+// ssmTimeoutPolicy returns the policy of the SSM functions that take a timeout: retry every 2 seconds until the timeout.
+func ssmTimeoutPolicy(timeout time.Duration) retry.Policy {
+	timeBetweenRetries := 2 * time.Second
+	return retry.FixedPolicy(int(timeout.Seconds()/timeBetweenRetries.Seconds()), timeBetweenRetries)
+}
@@ -0,0 +1,189 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CopyMode is how the files in a folder are copied.
+type CopyMode int
+
+const (
+	// CopyModeCopy copies the contents of each file. This is the default.
+	CopyModeCopy CopyMode = iota
+	// CopyModeSymlink creates a symbolic link to each file, instead of copying it. Folders are still created, so that
+	// the .terraform folders, state files, etc. that a test creates are not shared with other tests. Only use this if
+	// the test doesn't modify the copied files.
+	CopyModeSymlink
+	// CopyModeHardlink creates a hard link to each file, instead of copying it, and falls back to copying when that
+	// fails, e.g. because the destination is on another file system. Only use this if the test doesn't modify the
+	// copied files, as modifying a hard link modifies the original file too.
+	CopyModeHardlink
+)
+
+// CopyOptions are the options for CopyTerraformFolderToDestWithOptions.
+type CopyOptions struct {
+	// If not empty, only these Terraform module folders, relative to the folder being copied, and the local modules they
+	// call (e.g. with source = "../../modules/vpc") are copied, instead of the whole folder. See
+	// FindLocalTerraformModuleDependencies.
+	ModuleFolders []string
+
+	// Additional files or folders, relative to the folder being copied, to copy along with ModuleFolders, e.g. files
+	// read with file("${path.module}/../../config.json"), which can't be found by parsing the module blocks.
+	ExtraPaths []string
+
+	// How the files are copied. Defaults to CopyModeCopy.
+	Mode CopyMode
+
+	// If true, the files and folders that match the patterns in the .gitignore and .terraformignore files of the folder
+	// being copied and its subfolders are not copied.
+	RespectIgnoreFiles bool
+}
+
+// CopyTerraformFolderToDestWithOptions is like CopyTerraformFolderToDest, but with options to copy only the modules
+// under test and the local modules they depend on, to link rather than copy files, and to leave out the files listed
+// in .gitignore and .terraformignore files. This makes copying a large repo for every parallel test much faster:
+//
+//	destFolder, err := files.CopyTerraformFolderToDestWithOptions("..", os.TempDir(), "test", files.CopyOptions{
+//		ModuleFolders:      []string{"examples/terraform-aws-example"},
+//		Mode:               files.CopyModeSymlink,
+//		RespectIgnoreFiles: true,
+//	})
+//
+// Like CopyTerraformFolderToDest, it returns the path to the copy of the given folder, in which the copied modules keep
+// their relative paths, so that their local module sources still work.
+func CopyTerraformFolderToDestWithOptions(folderPath string, destRootFolder string, tempFolderPrefix string, options CopyOptions) (string, error) {
+	filter := terraformFolderFilter
+	if options.RespectIgnoreFiles {
+		matcher := newIgnoreMatcher(folderPath)
+		filter = func(path string) bool {
+			if !terraformFolderFilter(path) {
+				return false
+			}
+			ignored, err := matcher.isIgnored(path, IsExistingDir(path))
+			// If the ignore files can't be read, copying too much is better than copying too little
+			return err != nil || !ignored
+		}
+	}
+
+	destFolder, err := createDestFolder(folderPath, destRootFolder, tempFolderPrefix)
+	if err != nil {
+		return "", err
+	}
+
+	if len(options.ModuleFolders) == 0 {
+		if err := copyFolderContents(folderPath, destFolder, filter, options.Mode); err != nil {
+			return "", err
+		}
+		return destFolder, nil
+	}
+
+	pathsToCopy, err := localModulePathsToCopy(folderPath, options)
+	if err != nil {
+		return "", err
+	}
+	for _, pathToCopy := range pathsToCopy {
+		if err := copyPathInFolder(folderPath, destFolder, pathToCopy, filter, options.Mode); err != nil {
+			return "", err
+		}
+	}
+	return destFolder, nil
+}
+
+// CopyTerraformFolderToTempWithOptions calls CopyTerraformFolderToDestWithOptions, passing os.TempDir() as the root
+// destination folder.
+func CopyTerraformFolderToTempWithOptions(folderPath string, tempFolderPrefix string, options CopyOptions) (string, error) {
+	return CopyTerraformFolderToDestWithOptions(folderPath, os.TempDir(), tempFolderPrefix, options)
+}
+
+// localModulePathsToCopy returns the paths, relative to the given folder, of the module folders in the given options,
+// the local modules they depend on and the extra paths, leaving out the paths within another path to copy.
+func localModulePathsToCopy(folderPath string, options CopyOptions) ([]string, error) {
+	paths := []string{}
+	for _, moduleFolder := range options.ModuleFolders {
+		dependencies, err := FindLocalTerraformModuleDependencies(filepath.Join(folderPath, moduleFolder))
+		if err != nil {
+			return nil, err
+		}
+		for _, dependency := range dependencies {
+			relPath, err := filepath.Rel(folderPath, dependency)
+			if err != nil {
+				return nil, err
+			}
+			if relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+				return nil, ModuleOutsideFolderError{Module: dependency, Folder: folderPath}
+			}
+			paths = append(paths, relPath)
+		}
+	}
+	for _, extraPath := range options.ExtraPaths {
+		paths = append(paths, filepath.Clean(extraPath))
+	}
+
+	// Sorted, a path comes after the paths that contain it
+	sort.Strings(paths)
+	pathsToCopy := []string{}
+	for _, path := range paths {
+		if !pathIsWithinAny(path, pathsToCopy) {
+			pathsToCopy = append(pathsToCopy, path)
+		}
+	}
+	return pathsToCopy, nil
+}
+
+// pathIsWithinAny returns true if the given path is the same as, or within, any of the given folders. All must be
+// clean.
+func pathIsWithinAny(path string, folders []string) bool {
+	for _, folder := range folders {
+		if folder == "." || path == folder || strings.HasPrefix(path, folder+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyPathInFolder copies the file or folder at the given path relative to the source folder to the same path relative
+// to the destination folder.
+func copyPathInFolder(source string, destination string, relPath string, filter func(path string) bool, mode CopyMode) error {
+	src := filepath.Join(source, relPath)
+	dest := filepath.Join(destination, relPath)
+
+	fileInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0777); err != nil {
+		return err
+	}
+	if !fileInfo.IsDir() {
+		return copyFileWithMode(src, dest, mode)
+	}
+	if err := os.MkdirAll(dest, fileInfo.Mode()); err != nil {
+		return err
+	}
+	return copyFolderContents(src, dest, filter, mode)
+}
+
+// copyFileWithMode copies the given file to the given destination, or links it, depending on the given mode.
+func copyFileWithMode(source string, destination string, mode CopyMode) error {
+	switch mode {
+	case CopyModeSymlink:
+		absSource, err := filepath.Abs(source)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(absSource, destination)
+	case CopyModeHardlink:
+		if err := os.Link(source, destination); err == nil {
+			return nil
+		}
+		return CopyFile(source, destination)
+	case CopyModeCopy:
+		return CopyFile(source, destination)
+	default:
+		return fmt.Errorf("unknown copy mode %d", mode)
+	}
+}
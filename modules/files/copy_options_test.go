@@ -0,0 +1,120 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyTerraformFolderToDestWithOptionsCopiesOnlyModuleDependencies(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFiles(t, root, monorepoTestFiles)
+
+	destFolder, err := CopyTerraformFolderToDestWithOptions(root, t.TempDir(), t.Name(), CopyOptions{
+		ModuleFolders: []string{filepath.Join("examples", "app")},
+		ExtraPaths:    []string{filepath.Join("config", "settings.json")},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"config/settings.json",
+		"examples/app/README.md",
+		"examples/app/main.tf",
+		"modules/app/main.tf",
+		"modules/app/modules.tf.json",
+		"modules/network/main.tf",
+	}, listTestFiles(t, destFolder))
+}
+
+func TestCopyTerraformFolderToDestWithOptionsFailsOnModuleOutsideFolder(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFiles(t, root, monorepoTestFiles)
+
+	_, err := CopyTerraformFolderToDestWithOptions(filepath.Join(root, "examples"), t.TempDir(), t.Name(), CopyOptions{
+		ModuleFolders: []string{"app"},
+	})
+	assert.IsType(t, ModuleOutsideFolderError{}, err)
+}
+
+func TestCopyTerraformFolderToDestWithOptionsRespectsIgnoreFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		".gitignore":                     "*.zip\n",
+		"main.tf":                        `resource "null_resource" "foo" {}`,
+		"terraform.tfstate":              "{}",
+		"lambda.zip":                     "zip",
+		"modules/app/.terraformignore":   "fixtures/\n",
+		"modules/app/main.tf":            `resource "null_resource" "app" {}`,
+		"modules/app/fixtures/data.json": "{}",
+	})
+
+	destFolder, err := CopyTerraformFolderToDestWithOptions(root, t.TempDir(), t.Name(), CopyOptions{RespectIgnoreFiles: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"main.tf", "modules/app/main.tf"}, listTestFiles(t, destFolder))
+}
+
+func TestCopyTerraformFolderToDestWithOptionsLinksFiles(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		mode          CopyMode
+		expectSymlink bool
+	}{
+		{CopyModeCopy, false},
+		{CopyModeSymlink, true},
+		{CopyModeHardlink, false},
+	}
+
+	for _, testCase := range testCases {
+		root := t.TempDir()
+		writeTestFiles(t, root, map[string]string{"modules/app/main.tf": `resource "null_resource" "app" {}`})
+		source := filepath.Join(root, "modules", "app", "main.tf")
+
+		destFolder, err := CopyTerraformFolderToDestWithOptions(root, t.TempDir(), t.Name(), CopyOptions{Mode: testCase.mode})
+		require.NoError(t, err)
+		dest := filepath.Join(destFolder, "modules", "app", "main.tf")
+
+		destInfo, err := os.Lstat(dest)
+		require.NoError(t, err)
+		assert.Equal(t, testCase.expectSymlink, isSymLink(destInfo))
+		assert.True(t, IsExistingDir(filepath.Join(destFolder, "modules", "app")))
+		assert.False(t, isSymLink(mustLstat(t, filepath.Join(destFolder, "modules"))), "folders should be created, not linked")
+
+		sourceInfo, err := os.Stat(source)
+		require.NoError(t, err)
+		destTargetInfo, err := os.Stat(dest)
+		require.NoError(t, err)
+		assert.Equal(t, testCase.mode != CopyModeCopy, os.SameFile(sourceInfo, destTargetInfo))
+	}
+}
+
+// listTestFiles returns the slash-separated paths of the files in the given folder, relative to it, sorted.
+func listTestFiles(t *testing.T, folder string) []string {
+	paths := []string{}
+	err := filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(folder, path)
+		paths = append(paths, filepath.ToSlash(relPath))
+		return err
+	})
+	require.NoError(t, err)
+	return paths
+}
+
+func mustLstat(t *testing.T, path string) os.FileInfo {
+	info, err := os.Lstat(path)
+	require.NoError(t, err)
+	return info
+}
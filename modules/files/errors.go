@@ -10,3 +10,14 @@ type DirNotFoundError struct {
 func (err DirNotFoundError) Error() string {
 	return fmt.Sprintf("Directory was not found: \"%s\"", err.Directory)
 }
+
+// ModuleOutsideFolderError is an error that occurs when a Terraform module being copied depends on a local module
+// outside the folder being copied.
+type ModuleOutsideFolderError struct {
+	Module string
+	Folder string
+}
+
+func (err ModuleOutsideFolderError) Error() string {
+	return fmt.Sprintf("local module %s is outside the folder being copied, %s", err.Module, err.Folder)
+}
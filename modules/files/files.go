@@ -47,17 +47,7 @@ func IsExistingDir(path string) bool {
 // files, and terraform.tfvars files are not copied to this temp folder, as you typically don't want them interfering with your tests.
 // This method is useful when running through a build tool so the files are copied to a destination that is cleaned on each run of the pipeline.
 func CopyTerraformFolderToDest(folderPath string, destRootFolder string, tempFolderPrefix string) (string, error) {
-	filter := func(path string) bool {
-		if PathIsTerraformVersionFile(path) || PathIsTerraformLockFile(path) {
-			return true
-		}
-		if PathContainsHiddenFileOrFolder(path) || PathContainsTerraformStateOrVars(path) {
-			return false
-		}
-		return true
-	}
-
-	destFolder, err := CopyFolderToDest(folderPath, destRootFolder, tempFolderPrefix, filter)
+	destFolder, err := CopyFolderToDest(folderPath, destRootFolder, tempFolderPrefix, terraformFolderFilter)
 	if err != nil {
 		return "", err
 	}
@@ -65,6 +55,18 @@ func CopyTerraformFolderToDest(folderPath string, destRootFolder string, tempFol
 	return destFolder, nil
 }
 
+// terraformFolderFilter is the filter used by CopyTerraformFolderToDest to leave out hidden files and folders (other than
+// .terraform-version and .terraform.lock.hcl), Terraform state files and terraform.tfvars files.
+func terraformFolderFilter(path string) bool {
+	if PathIsTerraformVersionFile(path) || PathIsTerraformLockFile(path) {
+		return true
+	}
+	if PathContainsHiddenFileOrFolder(path) || PathContainsTerraformStateOrVars(path) {
+		return false
+	}
+	return true
+}
+
 // CopyTerraformFolderToTemp calls CopyTerraformFolderToDest, passing os.TempDir() as the root destination folder.
 func CopyTerraformFolderToTemp(folderPath string, tempFolderPrefix string) (string, error) {
 	return CopyTerraformFolderToDest(folderPath, os.TempDir(), tempFolderPrefix)
@@ -94,6 +96,21 @@ func CopyTerragruntFolderToTemp(folderPath string, tempFolderPrefix string) (str
 // CopyFolderToDest creates a copy of the given folder and all its filtered contents in a temp folder
 // with a unique name and the given prefix.
 func CopyFolderToDest(folderPath string, destRootFolder string, tempFolderPrefix string, filter func(path string) bool) (string, error) {
+	destFolder, err := createDestFolder(folderPath, destRootFolder, tempFolderPrefix)
+	if err != nil {
+		return "", err
+	}
+
+	if err := CopyFolderContentsWithFilter(folderPath, destFolder, filter); err != nil {
+		return "", err
+	}
+
+	return destFolder, nil
+}
+
+// createDestFolder creates a folder with the same name as the given folder, in a temp folder with a unique name and
+// the given prefix in the given root destination folder.
+func createDestFolder(folderPath string, destRootFolder string, tempFolderPrefix string) (string, error) {
 	destRootExists, err := FileExistsE(destRootFolder)
 	if err != nil {
 		return "", err
@@ -127,10 +144,6 @@ func CopyFolderToDest(folderPath string, destRootFolder string, tempFolderPrefix
 		return "", err
 	}
 
-	if err := CopyFolderContentsWithFilter(folderPath, destFolder, filter); err != nil {
-		return "", err
-	}
-
 	return destFolder, nil
 }
 
@@ -149,6 +162,12 @@ func CopyFolderContents(source string, destination string) error {
 // CopyFolderContentsWithFilter copies the files and folders within the given source folder that pass the given filter (return true) to the
 // destination folder.
 func CopyFolderContentsWithFilter(source string, destination string, filter func(path string) bool) error {
+	return copyFolderContents(source, destination, filter, CopyModeCopy)
+}
+
+// copyFolderContents copies, or links, depending on the given mode, the files and folders within the given source
+// folder that pass the given filter to the destination folder.
+func copyFolderContents(source string, destination string, filter func(path string) bool, mode CopyMode) error {
 	files, err := ioutil.ReadDir(source)
 	if err != nil {
 		return err
@@ -165,7 +184,7 @@ func CopyFolderContentsWithFilter(source string, destination string, filter func
 				return err
 			}
 
-			if err := CopyFolderContentsWithFilter(src, dest, filter); err != nil {
+			if err := copyFolderContents(src, dest, filter, mode); err != nil {
 				return err
 			}
 
@@ -174,7 +193,7 @@ func CopyFolderContentsWithFilter(source string, destination string, filter func
 				return err
 			}
 		} else {
-			if err := CopyFile(src, dest); err != nil {
+			if err := copyFileWithMode(src, dest, mode); err != nil {
 				return err
 			}
 		}
@@ -0,0 +1,157 @@
+package files
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileNames are the names of the files with patterns of files and folders to leave out when copying a folder with
+// CopyOptions.RespectIgnoreFiles.
+var IgnoreFileNames = []string{".gitignore", ".terraformignore"}
+
+// ignorePattern is a single pattern of a .gitignore or .terraformignore file. See https://git-scm.com/docs/gitignore.
+type ignorePattern struct {
+	segments []string // The pattern split on "/"
+	negate   bool     // The pattern starts with "!", so it re-includes paths excluded by an earlier pattern
+	dirOnly  bool     // The pattern ends with "/", so it only matches folders
+	anchored bool     // The pattern contains a "/", so it is matched relative to the folder of the ignore file
+}
+
+// ignoreMatcher tells whether a path is excluded by the ignore files in its folder or the folders above it, up to the
+// root folder.
+type ignoreMatcher struct {
+	root     string
+	patterns map[string][]ignorePattern // Patterns of the ignore files in each folder, relative to root, loaded lazily
+}
+
+func newIgnoreMatcher(root string) *ignoreMatcher {
+	return &ignoreMatcher{root: filepath.Clean(root), patterns: map[string][]ignorePattern{}}
+}
+
+// isIgnored returns true if the given path, which must be under the root folder, is excluded by the ignore files.
+func (matcher *ignoreMatcher) isIgnored(filePath string, isDir bool) (bool, error) {
+	relPath, err := filepath.Rel(matcher.root, filePath)
+	if err != nil {
+		return false, err
+	}
+	relPath = filepath.ToSlash(relPath)
+	if relPath == "." || strings.HasPrefix(relPath, "../") {
+		return false, nil
+	}
+
+	// Patterns in deeper folders take precedence, as do later patterns in the same file, so the last match wins
+	ignored := false
+	components := strings.Split(relPath, "/")
+	for i := 0; i < len(components); i++ {
+		dir := strings.Join(components[:i], "/")
+		patterns, err := matcher.loadPatterns(dir)
+		if err != nil {
+			return false, err
+		}
+		pathInDir := components[i:]
+		for _, pattern := range patterns {
+			if pattern.matches(pathInDir, isDir) {
+				ignored = !pattern.negate
+			}
+		}
+	}
+	return ignored, nil
+}
+
+// loadPatterns returns the patterns of the ignore files in the given folder, relative to the root folder.
+func (matcher *ignoreMatcher) loadPatterns(dir string) ([]ignorePattern, error) {
+	if patterns, loaded := matcher.patterns[dir]; loaded {
+		return patterns, nil
+	}
+
+	patterns := []ignorePattern{}
+	for _, fileName := range IgnoreFileNames {
+		filePatterns, err := parseIgnoreFile(filepath.Join(matcher.root, filepath.FromSlash(dir), fileName))
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, filePatterns...)
+	}
+	matcher.patterns[dir] = patterns
+	return patterns, nil
+}
+
+// parseIgnoreFile parses the patterns in the given ignore file, which may not exist.
+func parseIgnoreFile(filePath string) ([]ignorePattern, error) {
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	patterns := []ignorePattern{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if pattern, isPattern := parseIgnorePattern(scanner.Text()); isPattern {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns, scanner.Err()
+}
+
+// parseIgnorePattern parses a line of an ignore file, and returns false if it is blank or a comment.
+func parseIgnorePattern(line string) (ignorePattern, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+
+	pattern := ignorePattern{}
+	if strings.HasPrefix(line, "!") {
+		pattern.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		pattern.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		pattern.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignorePattern{}, false
+	}
+	pattern.segments = strings.Split(line, "/")
+	return pattern, true
+}
+
+// matches returns true if the pattern matches the given path, split on "/", relative to the folder of its ignore file.
+func (pattern ignorePattern) matches(pathSegments []string, isDir bool) bool {
+	if pattern.dirOnly && !isDir {
+		return false
+	}
+	if !pattern.anchored {
+		// A pattern without a slash matches a file or folder with that name at any depth
+		matched, _ := path.Match(pattern.segments[0], pathSegments[len(pathSegments)-1])
+		return matched
+	}
+	return matchSegments(pattern.segments, pathSegments)
+}
+
+// matchSegments matches the segments of a path against those of a pattern, where a "**" segment matches any number of
+// path segments.
+func matchSegments(patternSegments []string, pathSegments []string) bool {
+	if len(patternSegments) == 0 {
+		return len(pathSegments) == 0
+	}
+	if patternSegments[0] == "**" {
+		return matchSegments(patternSegments[1:], pathSegments) || (len(pathSegments) > 0 && matchSegments(patternSegments, pathSegments[1:]))
+	}
+	if len(pathSegments) == 0 {
+		return false
+	}
+	matched, _ := path.Match(patternSegments[0], pathSegments[0])
+	return matched && matchSegments(patternSegments[1:], pathSegments[1:])
+}
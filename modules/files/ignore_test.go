@@ -0,0 +1,83 @@
+package files
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnorePatternMatches(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern  string
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"*.zip", "lambda.zip", false, true},
+		{"*.zip", "build/lambda.zip", false, true},
+		{"*.zip", "lambda.zip.txt", false, false},
+		{"build/", "build", true, true},
+		{"build/", "src/build", true, true},
+		{"build/", "build", false, false},
+		{"/build", "build", true, true},
+		{"/build", "src/build", true, false},
+		{"docs/*.md", "docs/README.md", false, true},
+		{"docs/*.md", "src/docs/README.md", false, false},
+		{"**/fixtures", "test/data/fixtures", true, true},
+		{"**/fixtures", "fixtures", true, true},
+		{"test/**/out", "test/out", true, true},
+		{"test/**/out", "test/a/b/out", true, true},
+		{"test/**/out", "src/test/out", true, false},
+	}
+
+	for _, testCase := range testCases {
+		pattern, isPattern := parseIgnorePattern(testCase.pattern)
+		require.True(t, isPattern)
+		assert.Equal(t, testCase.expected, pattern.matches(strings.Split(testCase.path, "/"), testCase.isDir), "%s should match %s: %v", testCase.pattern, testCase.path, testCase.expected)
+	}
+}
+
+func TestParseIgnorePatternSkipsBlankLinesAndComments(t *testing.T) {
+	t.Parallel()
+
+	for _, line := range []string{"", "   ", "# a comment", "/"} {
+		_, isPattern := parseIgnorePattern(line)
+		assert.False(t, isPattern, line)
+	}
+}
+
+func TestIgnoreMatcherWithNestedIgnoreFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		".gitignore":                   "*.log\nbuild/\n",
+		"modules/app/.terraformignore": "# Keep the logs that document the app\n!docs.log\nlarge-fixture.json\n",
+	})
+
+	testCases := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"main.tf", false, false},
+		{"debug.log", false, true},
+		{"build", true, true},
+		{"modules/app/debug.log", false, true},
+		{"modules/app/docs.log", false, false},
+		{"modules/app/large-fixture.json", false, true},
+		{"modules/network/large-fixture.json", false, false},
+	}
+
+	matcher := newIgnoreMatcher(root)
+	for _, testCase := range testCases {
+		ignored, err := matcher.isIgnored(filepath.Join(root, filepath.FromSlash(testCase.path)), testCase.isDir)
+		require.NoError(t, err)
+		assert.Equal(t, testCase.expected, ignored, testCase.path)
+	}
+}
@@ -0,0 +1,120 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var moduleBlockSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{{Type: "module", LabelNames: []string{"name"}}},
+}
+
+var moduleSourceSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "source"}},
+}
+
+// FindLocalTerraformModuleDependencies returns the given Terraform module folder followed by the folders of all the
+// local modules it calls, directly or indirectly, i.e. the modules with a source such as "../../modules/vpc". Modules
+// from registries, Git or other remote sources are not included, as Terraform downloads them at init time. The folders
+// are cleaned, and relative if the module folder is.
+func FindLocalTerraformModuleDependencies(moduleFolder string) ([]string, error) {
+	moduleFolder = filepath.Clean(moduleFolder)
+	found := map[string]bool{moduleFolder: true}
+	dependencies := []string{moduleFolder}
+
+	for i := 0; i < len(dependencies); i++ {
+		sources, err := findLocalModuleSources(dependencies[i])
+		if err != nil {
+			return nil, err
+		}
+		for _, source := range sources {
+			dependency := filepath.Join(dependencies[i], filepath.FromSlash(source))
+			if !found[dependency] {
+				found[dependency] = true
+				dependencies = append(dependencies, dependency)
+			}
+		}
+	}
+	return dependencies, nil
+}
+
+// findLocalModuleSources returns the sources of the module blocks in the .tf and .tf.json files of the given folder that
+// are local paths, sorted.
+func findLocalModuleSources(moduleFolder string) ([]string, error) {
+	entries, err := os.ReadDir(moduleFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := hclparse.NewParser()
+	sources := []string{}
+	for _, entry := range entries {
+		filePath := filepath.Join(moduleFolder, entry.Name())
+
+		var file *hcl.File
+		var diags hcl.Diagnostics
+		switch {
+		case entry.IsDir():
+			continue
+		case strings.HasSuffix(entry.Name(), ".tf"):
+			file, diags = parser.ParseHCLFile(filePath)
+		case strings.HasSuffix(entry.Name(), ".tf.json"):
+			file, diags = parser.ParseJSONFile(filePath)
+		default:
+			continue
+		}
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		content, _, diags := file.Body.PartialContent(moduleBlockSchema)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		for _, block := range content.Blocks {
+			source, err := moduleSource(block)
+			if err != nil {
+				return nil, err
+			}
+			if isLocalModuleSource(source) {
+				sources = append(sources, source)
+			}
+		}
+	}
+
+	sort.Strings(sources)
+	return sources, nil
+}
+
+// moduleSource returns the source of the given module block, or an empty string if it has none.
+func moduleSource(block *hcl.Block) (string, error) {
+	content, _, diags := block.Body.PartialContent(moduleSourceSchema)
+	if diags.HasErrors() {
+		return "", diags
+	}
+	attr, hasSource := content.Attributes["source"]
+	if !hasSource {
+		return "", nil
+	}
+	// Terraform requires the source to be a literal string, so no evaluation context is needed
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() {
+		return "", diags
+	}
+	if value.IsNull() || !value.IsKnown() || value.Type() != cty.String {
+		return "", nil
+	}
+	return value.AsString(), nil
+}
+
+// isLocalModuleSource returns true if the given module source is a local path. Terraform only treats sources that
+// start with ./ or ../ as local paths. See https://developer.hashicorp.com/terraform/language/modules/sources#local-paths.
+func isLocalModuleSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
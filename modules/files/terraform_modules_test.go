@@ -0,0 +1,81 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestFiles creates the given files, keyed by their slash-separated path relative to the given folder.
+func writeTestFiles(t *testing.T, folder string, files map[string]string) {
+	for path, contents := range files {
+		fullPath := filepath.Join(folder, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0777))
+		require.NoError(t, os.WriteFile(fullPath, []byte(contents), 0644))
+	}
+}
+
+// monorepoTestFiles is a repo with an example that calls a local module, which in turn calls another local module and
+// a remote one, and another example that calls a module that the first one doesn't.
+var monorepoTestFiles = map[string]string{
+	"examples/app/main.tf":        `module "app" { source = "../../modules/app" }`,
+	"examples/app/README.md":      "app example",
+	"modules/app/main.tf":         `module "network" { source = "../network" }`,
+	"modules/app/modules.tf.json": `{"module": {"consul": {"source": "hashicorp/consul/aws"}}}`,
+	"modules/network/main.tf":     `resource "null_resource" "network" {}`,
+	"modules/unused/main.tf":      `resource "null_resource" "unused" {}`,
+	"examples/other/main.tf":      `module "unused" { source = "../../modules/unused" }`,
+	"config/settings.json":        `{}`,
+}
+
+func TestFindLocalTerraformModuleDependencies(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFiles(t, root, monorepoTestFiles)
+
+	dependencies, err := FindLocalTerraformModuleDependencies(filepath.Join(root, "examples", "app"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(root, "examples", "app"),
+		filepath.Join(root, "modules", "app"),
+		filepath.Join(root, "modules", "network"),
+	}, dependencies)
+}
+
+func TestFindLocalTerraformModuleDependenciesWithCycle(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"a/main.tf": `module "b" { source = "../b" }`,
+		"b/main.tf": `module "a" { source = "./../a" }`,
+	})
+
+	dependencies, err := FindLocalTerraformModuleDependencies(filepath.Join(root, "a"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "a"), filepath.Join(root, "b")}, dependencies)
+}
+
+func TestIsLocalModuleSource(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		source   string
+		expected bool
+	}{
+		{"./modules/vpc", true},
+		{"../../modules/vpc", true},
+		{"modules/vpc", false},
+		{"hashicorp/consul/aws", false},
+		{"git::https://example.com/vpc.git?ref=v1.2.0", false},
+		{"", false},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, isLocalModuleSource(testCase.source), testCase.source)
+	}
+}
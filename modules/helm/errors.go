@@ -40,3 +40,12 @@ type ChartNotFoundError struct {
 func (err ChartNotFoundError) Error() string {
 	return fmt.Sprintf("Could not chart path %s", err.Path)
 }
+
+// DuplicateManifestError is returned when a chart renders several objects with the same kind, namespace and name.
+type DuplicateManifestError struct {
+	Key ManifestKey
+}
+
+func (err DuplicateManifestError) Error() string {
+	return fmt.Sprintf("Chart rendered more than one %s named %s in namespace %q", err.Key.Kind, err.Key.Name, err.Key.Namespace)
+}
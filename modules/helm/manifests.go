@@ -0,0 +1,311 @@
+package helm
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/ghodss/yaml"
+	"github.com/tnn-gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// ManifestKey identifies an object rendered by a chart.
+type ManifestKey struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// ManifestContainer is a container of the pod template of an object rendered by a chart, e.g. a Deployment.
+type ManifestContainer struct {
+	Object    ManifestKey
+	Container corev1.Container
+	// True if the container is an init container
+	Init bool
+}
+
+// Manifests are the objects rendered by a chart. The objects of the kinds known to client-go, e.g. Deployments, are
+// decoded into their typed struct, e.g. *appsv1.Deployment, and the others, e.g. custom resources, into
+// *unstructured.Unstructured.
+type Manifests struct {
+	// The objects, in the order they were rendered
+	Objects []runtime.Object
+
+	keys  []ManifestKey
+	index map[ManifestKey]runtime.Object
+}
+
+// RenderTemplateManifests renders the templates of the chart as RenderTemplate does, and decodes all the rendered
+// objects. This will fail the test if there is an error rendering the templates or decoding the objects.
+func RenderTemplateManifests(t testing.TestingT, options *Options, chartDir string, releaseName string, templateFiles []string, extraHelmArgs ...string) *Manifests {
+	manifests, err := RenderTemplateManifestsE(t, options, chartDir, releaseName, templateFiles, extraHelmArgs...)
+	require.NoError(t, err)
+	return manifests
+}
+
+// RenderTemplateManifestsE renders the templates of the chart as RenderTemplateE does, and decodes all the rendered
+// objects. For example, to check the image of a Deployment and that all the containers rendered by the chart have
+// resource limits:
+//
+//	manifests, err := RenderTemplateManifestsE(t, options, chartDir, "my-release", []string{})
+//	require.NoError(t, err)
+//	deployment, found := manifests.Find("Deployment", "my-release-app")
+//	require.True(t, found)
+//	require.Equal(t, "nginx:1.15.8", deployment.(*appsv1.Deployment).Spec.Template.Spec.Containers[0].Image)
+//	containers, err := manifests.ContainersWithoutResourceLimits()
+//	require.NoError(t, err)
+//	require.Empty(t, containers)
+func RenderTemplateManifestsE(t testing.TestingT, options *Options, chartDir string, releaseName string, templateFiles []string, extraHelmArgs ...string) (*Manifests, error) {
+	output, err := RenderTemplateE(t, options, chartDir, releaseName, templateFiles, extraHelmArgs...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseManifestsE(output)
+}
+
+// ParseManifests decodes all the objects of the given multi-document YAML, e.g. the output of RenderTemplate. This
+// will fail the test if there is an error.
+func ParseManifests(t testing.TestingT, yamlData string) *Manifests {
+	manifests, err := ParseManifestsE(yamlData)
+	require.NoError(t, err)
+	return manifests
+}
+
+// ParseManifestsE decodes all the objects of the given multi-document YAML, e.g. the output of RenderTemplate. Empty
+// documents, e.g. templates that rendered nothing, are skipped, and Lists are expanded into their items.
+func ParseManifestsE(yamlData string) (*Manifests, error) {
+	manifests := &Manifests{index: map[ManifestKey]runtime.Object{}}
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewBufferString(yamlData)))
+	for {
+		document, err := reader.Read()
+		if err == io.EOF {
+			return manifests, nil
+		}
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+
+		objects, err := decodeManifestDocumentE(document)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range objects {
+			if err := manifests.add(object); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// Get returns the object of the given kind, e.g. "Deployment", with the given namespace and name, and whether there is
+// such an object. The namespace is empty for the objects rendered without a namespace.
+func (manifests *Manifests) Get(kind string, namespace string, name string) (runtime.Object, bool) {
+	object, found := manifests.index[ManifestKey{Kind: kind, Namespace: namespace, Name: name}]
+	return object, found
+}
+
+// Find returns the first object of the given kind, e.g. "Deployment", with the given name in any namespace, and
+// whether there is such an object.
+func (manifests *Manifests) Find(kind string, name string) (runtime.Object, bool) {
+	for i, key := range manifests.keys {
+		if key.Kind == kind && key.Name == name {
+			return manifests.Objects[i], true
+		}
+	}
+	return nil, false
+}
+
+// FindAll returns all the objects of the given kind, e.g. "Deployment", in the order they were rendered.
+func (manifests *Manifests) FindAll(kind string) []runtime.Object {
+	objects := []runtime.Object{}
+	for i, key := range manifests.keys {
+		if key.Kind == kind {
+			objects = append(objects, manifests.Objects[i])
+		}
+	}
+	return objects
+}
+
+// Keys returns the keys of all the objects, in the order they were rendered.
+func (manifests *Manifests) Keys() []ManifestKey {
+	return append([]ManifestKey{}, manifests.keys...)
+}
+
+// Containers returns the containers and init containers of all the objects that run pods: Pods, Deployments,
+// StatefulSets, DaemonSets, ReplicaSets, Jobs and CronJobs, including those decoded as unstructured objects.
+func (manifests *Manifests) Containers() ([]ManifestContainer, error) {
+	containers := []ManifestContainer{}
+	for i, object := range manifests.Objects {
+		podSpec, err := manifestPodSpecE(object)
+		if err != nil {
+			return nil, err
+		}
+		if podSpec == nil {
+			continue
+		}
+		for _, container := range podSpec.InitContainers {
+			containers = append(containers, ManifestContainer{Object: manifests.keys[i], Container: container, Init: true})
+		}
+		for _, container := range podSpec.Containers {
+			containers = append(containers, ManifestContainer{Object: manifests.keys[i], Container: container})
+		}
+	}
+	return containers, nil
+}
+
+// ContainersWithoutResourceLimits returns the containers of all the objects that run pods that do not have a limit for
+// each of the given resources, which default to CPU and memory.
+func (manifests *Manifests) ContainersWithoutResourceLimits(resources ...corev1.ResourceName) ([]ManifestContainer, error) {
+	if len(resources) == 0 {
+		resources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+	}
+	containers, err := manifests.Containers()
+	if err != nil {
+		return nil, err
+	}
+
+	withoutLimits := []ManifestContainer{}
+	for _, container := range containers {
+		for _, resource := range resources {
+			if _, hasLimit := container.Container.Resources.Limits[resource]; !hasLimit {
+				withoutLimits = append(withoutLimits, container)
+				break
+			}
+		}
+	}
+	return withoutLimits, nil
+}
+
+// add indexes the given object, which must not have been rendered already.
+func (manifests *Manifests) add(object runtime.Object) error {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	key := ManifestKey{
+		Kind:      object.GetObjectKind().GroupVersionKind().Kind,
+		Namespace: accessor.GetNamespace(),
+		Name:      accessor.GetName(),
+	}
+	if _, exists := manifests.index[key]; exists {
+		return errors.WithStackTrace(DuplicateManifestError{key})
+	}
+	manifests.Objects = append(manifests.Objects, object)
+	manifests.keys = append(manifests.keys, key)
+	manifests.index[key] = object
+	return nil
+}
+
+// decodeManifestDocumentE decodes the object of the given YAML document into its client-go struct, or into an
+// unstructured object if client-go does not know its kind. Lists are expanded into their items, and empty documents
+// have no objects.
+func decodeManifestDocumentE(document []byte) ([]runtime.Object, error) {
+	jsonData, err := yaml.YAMLToJSON(document)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	jsonData = bytes.TrimSpace(jsonData)
+	if len(jsonData) == 0 || bytes.Equal(jsonData, []byte("null")) || bytes.Equal(jsonData, []byte("{}")) {
+		return nil, nil
+	}
+
+	object, gvk, err := scheme.Codecs.UniversalDeserializer().Decode(jsonData, nil, nil)
+	if runtime.IsNotRegisteredError(err) {
+		unstructuredObject := &unstructured.Unstructured{}
+		if err := unstructuredObject.UnmarshalJSON(jsonData); err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		object = unstructuredObject
+	} else if err != nil {
+		return nil, errors.WithStackTrace(err)
+	} else {
+		// Make sure the kind is set, as it is the key of the object
+		object.GetObjectKind().SetGroupVersionKind(*gvk)
+	}
+
+	if !meta.IsListType(object) {
+		return []runtime.Object{object}, nil
+	}
+	items, err := meta.ExtractList(object)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	objects := []runtime.Object{}
+	for _, item := range items {
+		// The items of a typed List are raw, so decode them as separate documents
+		if raw, isRaw := item.(*runtime.Unknown); isRaw {
+			decoded, err := decodeManifestDocumentE(raw.Raw)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, decoded...)
+			continue
+		}
+		objects = append(objects, item)
+	}
+	return objects, nil
+}
+
+// manifestPodSpecE returns the spec of the pods run by the given object, or nil if the object does not run pods.
+func manifestPodSpecE(object runtime.Object) (*corev1.PodSpec, error) {
+	switch typed := object.(type) {
+	case *corev1.Pod:
+		return &typed.Spec, nil
+	case *appsv1.Deployment:
+		return &typed.Spec.Template.Spec, nil
+	case *appsv1.StatefulSet:
+		return &typed.Spec.Template.Spec, nil
+	case *appsv1.DaemonSet:
+		return &typed.Spec.Template.Spec, nil
+	case *appsv1.ReplicaSet:
+		return &typed.Spec.Template.Spec, nil
+	case *batchv1.Job:
+		return &typed.Spec.Template.Spec, nil
+	case *batchv1beta1.CronJob:
+		return &typed.Spec.JobTemplate.Spec.Template.Spec, nil
+	case *unstructured.Unstructured:
+		return unstructuredPodSpecE(typed)
+	}
+	return nil, nil
+}
+
+// unstructuredPodSpecE returns the spec of the pods run by the given unstructured object, e.g. a batch/v1 CronJob or a
+// workload of an API version client-go does not know, or nil if the object does not run pods.
+func unstructuredPodSpecE(object *unstructured.Unstructured) (*corev1.PodSpec, error) {
+	var path []string
+	switch object.GetKind() {
+	case "Pod":
+		path = []string{"spec"}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		path = []string{"spec", "template", "spec"}
+	case "CronJob":
+		path = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return nil, nil
+	}
+
+	rawPodSpec, found, err := unstructured.NestedMap(object.Object, path...)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	if !found {
+		return nil, nil
+	}
+	podSpec := &corev1.PodSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawPodSpec, podSpec); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return podSpec, nil
+}
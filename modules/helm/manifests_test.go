@@ -0,0 +1,173 @@
+package helm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const exampleRenderedManifests = `---
+# Source: app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+  namespace: prod
+spec:
+  ports:
+  - port: 80
+---
+# Source: app/templates/disabled.yaml
+---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: prod
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: migrate
+        image: app:1.0
+      containers:
+      - name: app
+        image: app:1.0
+        resources:
+          limits:
+            cpu: 500m
+            memory: 128Mi
+---
+# Source: app/templates/cronjob.yaml
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  schedule: "0 * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: cleanup
+            image: app:1.0
+            resources:
+              limits:
+                memory: 64Mi
+---
+# Source: app/templates/certificate.yaml
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: app
+  namespace: prod
+spec:
+  secretName: app-tls
+`
+
+func TestParseManifests(t *testing.T) {
+	t.Parallel()
+
+	manifests, err := ParseManifestsE(exampleRenderedManifests)
+	require.NoError(t, err)
+	assert.Equal(t, []ManifestKey{
+		{Kind: "Service", Namespace: "prod", Name: "app"},
+		{Kind: "Deployment", Namespace: "prod", Name: "app"},
+		{Kind: "CronJob", Namespace: "", Name: "cleanup"},
+		{Kind: "Certificate", Namespace: "prod", Name: "app"},
+	}, manifests.Keys())
+
+	object, found := manifests.Get("Deployment", "prod", "app")
+	require.True(t, found)
+	deployment, isDeployment := object.(*appsv1.Deployment)
+	require.True(t, isDeployment)
+	assert.Equal(t, "app:1.0", deployment.Spec.Template.Spec.Containers[0].Image)
+
+	_, found = manifests.Get("Deployment", "", "app")
+	assert.False(t, found)
+
+	object, found = manifests.Find("Service", "app")
+	require.True(t, found)
+	assert.IsType(t, &corev1.Service{}, object)
+
+	// batch/v1 CronJobs and custom resources are not known to this version of client-go
+	object, found = manifests.Find("Certificate", "app")
+	require.True(t, found)
+	certificate, isUnstructured := object.(*unstructured.Unstructured)
+	require.True(t, isUnstructured)
+	secretName, _, err := unstructured.NestedString(certificate.Object, "spec", "secretName")
+	require.NoError(t, err)
+	assert.Equal(t, "app-tls", secretName)
+
+	assert.Len(t, manifests.FindAll("Deployment"), 1)
+	assert.Empty(t, manifests.FindAll("Ingress"))
+}
+
+func TestManifestsContainersWithoutResourceLimits(t *testing.T) {
+	t.Parallel()
+
+	manifests, err := ParseManifestsE(exampleRenderedManifests)
+	require.NoError(t, err)
+
+	containers, err := manifests.Containers()
+	require.NoError(t, err)
+	require.Len(t, containers, 3)
+	assert.Equal(t, "migrate", containers[0].Container.Name)
+	assert.True(t, containers[0].Init)
+	assert.Equal(t, ManifestKey{Kind: "CronJob", Name: "cleanup"}, containers[2].Object)
+
+	withoutLimits, err := manifests.ContainersWithoutResourceLimits()
+	require.NoError(t, err)
+	require.Len(t, withoutLimits, 2)
+	assert.Equal(t, "migrate", withoutLimits[0].Container.Name)
+	assert.Equal(t, "cleanup", withoutLimits[1].Container.Name)
+
+	withoutLimits, err = manifests.ContainersWithoutResourceLimits(corev1.ResourceMemory)
+	require.NoError(t, err)
+	require.Len(t, withoutLimits, 1)
+	assert.Equal(t, "migrate", withoutLimits[0].Container.Name)
+}
+
+func TestParseManifestsExpandsLists(t *testing.T) {
+	t.Parallel()
+
+	manifests, err := ParseManifestsE(`apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: a
+- apiVersion: example.com/v1
+  kind: Widget
+  metadata:
+    name: b
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []ManifestKey{{Kind: "ConfigMap", Name: "a"}, {Kind: "Widget", Name: "b"}}, manifests.Keys())
+	object, _ := manifests.Find("ConfigMap", "a")
+	assert.IsType(t, &corev1.ConfigMap{}, object)
+}
+
+func TestParseManifestsReturnsErrorForDuplicates(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseManifestsE(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "more than one ConfigMap named a")
+}
@@ -20,6 +20,8 @@ type HttpGetOptions struct {
 	Url       string
 	TlsConfig *tls.Config
 	Timeout   int
+	// If set, the *WithRetry functions use this policy instead of their retries and sleepBetweenRetries arguments.
+	RetryPolicy *retry.Policy
 }
 
 type HttpDoOptions struct {
@@ -29,6 +31,8 @@ type HttpDoOptions struct {
 	Headers   map[string]string
 	TlsConfig *tls.Config
 	Timeout   int
+	// If set, the *WithRetry functions use this policy instead of their retries and sleepBetweenRetries arguments.
+	RetryPolicy *retry.Policy
 }
 
 // HttpGet performs an HTTP GET, with an optional pointer to a custom TLS configuration, on the given URL and
@@ -174,7 +178,7 @@ func HttpGetWithRetryE(t testing.TestingT, url string, tlsConfig *tls.Config, ex
 // HttpGetWithRetryWithOptionsE repeatedly performs an HTTP GET on the given URL until the given status code and body are returned or until max
 // retries has been exceeded.
 func HttpGetWithRetryWithOptionsE(t testing.TestingT, options HttpGetOptions, expectedStatus int, expectedBody string, retries int, sleepBetweenRetries time.Duration) error {
-	_, err := retry.DoWithRetryE(t, fmt.Sprintf("HTTP GET to URL %s", options.Url), retries, sleepBetweenRetries, func() (string, error) {
+	_, err := retry.DoWithPolicyE(t, fmt.Sprintf("HTTP GET to URL %s", options.Url), retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries), func() (string, error) {
 		return "", HttpGetWithValidationWithOptionsE(t, options, expectedStatus, expectedBody)
 	})
 
@@ -207,7 +211,7 @@ func HttpGetWithRetryWithCustomValidationE(t testing.TestingT, url string, tlsCo
 // HttpGetWithRetryWithCustomValidationWithOptionsE repeatedly performs an HTTP GET on the given URL until the given validation function returns true or max retries
 // has been exceeded.
 func HttpGetWithRetryWithCustomValidationWithOptionsE(t testing.TestingT, options HttpGetOptions, retries int, sleepBetweenRetries time.Duration, validateResponse func(int, string) bool) error {
-	_, err := retry.DoWithRetryE(t, fmt.Sprintf("HTTP GET to URL %s", options.Url), retries, sleepBetweenRetries, func() (string, error) {
+	_, err := retry.DoWithPolicyE(t, fmt.Sprintf("HTTP GET to URL %s", options.Url), retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries), func() (string, error) {
 		return "", HttpGetWithCustomValidationWithOptionsE(t, options, validateResponse)
 	})
 
@@ -356,9 +360,9 @@ func HTTPDoWithRetryWithOptionsE(
 
 	options.Body = nil
 
-	out, err := retry.DoWithRetryE(
-		t, fmt.Sprintf("HTTP %s to URL %s", options.Method, options.Url), retries,
-		sleepBetweenRetries, func() (string, error) {
+	out, err := retry.DoWithPolicyE(
+		t, fmt.Sprintf("HTTP %s to URL %s", options.Method, options.Url),
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries), func() (string, error) {
 			options.Body = bytes.NewReader(data)
 			statusCode, out, err := HTTPDoWithOptionsE(t, options)
 			if err != nil {
@@ -428,8 +432,8 @@ func HTTPDoWithValidationRetryWithOptionsE(
 	t testing.TestingT, options HttpDoOptions, expectedStatus int,
 	expectedBody string, retries int, sleepBetweenRetries time.Duration,
 ) error {
-	_, err := retry.DoWithRetryE(t, fmt.Sprintf("HTTP %s to URL %s", options.Method, options.Url), retries,
-		sleepBetweenRetries, func() (string, error) {
+	_, err := retry.DoWithPolicyE(t, fmt.Sprintf("HTTP %s to URL %s", options.Method, options.Url),
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries), func() (string, error) {
 			return "", HTTPDoWithValidationWithOptionsE(t, options, expectedStatus, expectedBody)
 		})
 
@@ -0,0 +1,203 @@
+// Package cluster provides functions to create throwaway local Kubernetes clusters for tests, using kind or k3d, so
+// that Kubernetes and Helm tests can run on a laptop or a CI runner without a shared cluster.
+package cluster
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	go_test "testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/k8s"
+	"github.com/tnn-gruntwork-io/terratest/modules/logger"
+	"github.com/tnn-gruntwork-io/terratest/modules/random"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// Provider is the tool used to run a local Kubernetes cluster in Docker.
+type Provider string
+
+const (
+	// ProviderKind runs the cluster with kind (https://kind.sigs.k8s.io). Each node is a Docker container.
+	ProviderKind Provider = "kind"
+	// ProviderK3d runs the cluster with k3d (https://k3d.io), a lighter distribution based on k3s.
+	ProviderK3d Provider = "k3d"
+)
+
+const (
+	// The number of times to check whether the nodes of a new cluster are ready, and the time between checks
+	nodesReadyRetries           = 60
+	sleepBetweenNodesReadyRetry = 5 * time.Second
+)
+
+// PortMapping maps a port of the host to a port of the cluster, e.g. to reach an ingress controller listening on the
+// port 80 of the nodes through the port 8080 of localhost.
+type PortMapping struct {
+	HostPort      int
+	ContainerPort int
+	// TCP (the default), UDP or SCTP
+	Protocol string
+}
+
+// RegistryMirror configures the nodes of the cluster to pull the images of a registry from a mirror, e.g. to avoid the
+// rate limits of Docker Hub in CI.
+type RegistryMirror struct {
+	// The registry to mirror, e.g. "docker.io"
+	Registry string
+	// The URL of the mirror, e.g. "http://registry-mirror:5000"
+	Endpoint string
+}
+
+// Options configures the clusters created by Create.
+type Options struct {
+	// The tool to run the cluster with. Defaults to kind.
+	Provider Provider
+	// The name of the cluster. Defaults to a unique name, so that tests can create clusters in parallel.
+	Name string
+	// The number of worker nodes, in addition to the control plane node
+	WorkerNodes int
+	// The version of Kubernetes to run, e.g. "v1.27.3". Defaults to the default version of the provider. Ignored if
+	// NodeImage is set.
+	KubernetesVersion string
+	// The image of the nodes, e.g. "kindest/node:v1.27.3" or "rancher/k3s:v1.27.4-k3s1"
+	NodeImage    string
+	PortMappings []PortMapping
+	// The registry mirrors to configure on the nodes
+	RegistryMirrors []RegistryMirror
+	// The logger for the output of the provider commands. Defaults to the terratest logger.
+	Logger *logger.Logger
+}
+
+// Cluster is a local Kubernetes cluster created by Create.
+type Cluster struct {
+	Name     string
+	Provider Provider
+	// The path of the kubeconfig file to connect to the cluster, which is not merged into the default kubeconfig
+	KubeConfigPath string
+	// The name of the context of the cluster in the kubeconfig file
+	ContextName string
+
+	// The temporary directory holding the kubeconfig and configuration files of the cluster
+	workDir string
+	logger  *logger.Logger
+}
+
+// CreateForTest creates a local Kubernetes cluster as Create does, and deletes it when the given test and all its
+// subtests complete. The cluster is deleted even if creating it fails, as it may have been created partially.
+func CreateForTest(t *go_test.T, options *Options) *Cluster {
+	cluster, err := CreateE(t, options)
+	if cluster != nil {
+		t.Cleanup(func() {
+			cluster.Delete(t)
+		})
+	}
+	require.NoError(t, err)
+	return cluster
+}
+
+// Create creates a local Kubernetes cluster with the given options, and waits until all its nodes are ready. This will
+// fail the test if there is an error. Make sure to Delete the cluster at the end of the test, or use CreateForTest.
+func Create(t testing.TestingT, options *Options) *Cluster {
+	cluster, err := CreateE(t, options)
+	require.NoError(t, err)
+	return cluster
+}
+
+// CreateE creates a local Kubernetes cluster with the given options, and waits until all its nodes are ready. The
+// kind or k3d binary must be on the PATH, and Docker must be running. Make sure to Delete the cluster at the end of the
+// test, even if this returns an error, as the cluster may have been created partially.
+func CreateE(t testing.TestingT, options *Options) (*Cluster, error) {
+	provider := options.Provider
+	if provider == "" {
+		provider = ProviderKind
+	}
+	if provider != ProviderKind && provider != ProviderK3d {
+		return nil, UnknownProvider{provider}
+	}
+	name := options.Name
+	if name == "" {
+		// Cluster names may only contain lower case letters, digits, dots and dashes
+		name = strings.ToLower(fmt.Sprintf("terratest-%s", random.UniqueId()))
+	}
+
+	workDir, err := ioutil.TempDir("", "terratest-cluster-")
+	if err != nil {
+		return nil, err
+	}
+	cluster := &Cluster{
+		Name:           name,
+		Provider:       provider,
+		KubeConfigPath: filepath.Join(workDir, "kubeconfig"),
+		ContextName:    fmt.Sprintf("%s-%s", provider, name),
+		workDir:        workDir,
+		logger:         options.Logger,
+	}
+
+	logger.Logf(t, "Creating %s cluster %s", provider, name)
+	switch provider {
+	case ProviderK3d:
+		err = createK3dClusterE(t, cluster, options)
+	default:
+		err = createKindClusterE(t, cluster, options)
+	}
+	if err != nil {
+		return cluster, err
+	}
+
+	if err := k8s.WaitUntilAllNodesReadyE(t, cluster.KubectlOptions(""), nodesReadyRetries, sleepBetweenNodesReadyRetry); err != nil {
+		return cluster, err
+	}
+	return cluster, nil
+}
+
+// KubectlOptions returns the options to use the k8s module against the cluster, in the given namespace.
+func (cluster *Cluster) KubectlOptions(namespace string) *k8s.KubectlOptions {
+	return k8s.NewKubectlOptions(cluster.ContextName, cluster.KubeConfigPath, namespace)
+}
+
+// LoadDockerImages loads the given images, e.g. images built locally by the test, from the local Docker daemon into
+// the nodes of the cluster, so that pods can run them without pushing them to a registry. Make sure the pods do not
+// use the Always image pull policy, which is the default for images with the latest tag. This will fail the test if
+// there is an error.
+func (cluster *Cluster) LoadDockerImages(t testing.TestingT, images ...string) {
+	require.NoError(t, cluster.LoadDockerImagesE(t, images...))
+}
+
+// LoadDockerImagesE loads the given images, e.g. images built locally by the test, from the local Docker daemon into
+// the nodes of the cluster, so that pods can run them without pushing them to a registry. Make sure the pods do not
+// use the Always image pull policy, which is the default for images with the latest tag.
+func (cluster *Cluster) LoadDockerImagesE(t testing.TestingT, images ...string) error {
+	if len(images) == 0 {
+		return nil
+	}
+	logger.Logf(t, "Loading images %v into %s cluster %s", images, cluster.Provider, cluster.Name)
+	if cluster.Provider == ProviderK3d {
+		return loadK3dImagesE(t, cluster, images)
+	}
+	return loadKindImagesE(t, cluster, images)
+}
+
+// Delete deletes the cluster and its temporary files. This will fail the test if there is an error.
+func (cluster *Cluster) Delete(t testing.TestingT) {
+	require.NoError(t, cluster.DeleteE(t))
+}
+
+// DeleteE deletes the cluster and its temporary files.
+func (cluster *Cluster) DeleteE(t testing.TestingT) error {
+	logger.Logf(t, "Deleting %s cluster %s", cluster.Provider, cluster.Name)
+	var err error
+	if cluster.Provider == ProviderK3d {
+		err = deleteK3dClusterE(t, cluster)
+	} else {
+		err = deleteKindClusterE(t, cluster)
+	}
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(cluster.workDir)
+}
@@ -0,0 +1,36 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/k8s"
+)
+
+func TestCreateKindCluster(t *testing.T) {
+	t.Parallel()
+
+	cluster := CreateForTest(t, &Options{Provider: ProviderKind, WorkerNodes: 1})
+	require.FileExists(t, cluster.KubeConfigPath)
+
+	nodes := k8s.GetReadyNodes(t, cluster.KubectlOptions("default"))
+	require.Len(t, nodes, 2)
+}
+
+func TestCreateEReturnsErrorForUnknownProvider(t *testing.T) {
+	t.Parallel()
+
+	_, err := CreateE(t, &Options{Provider: "minikube"})
+	require.Error(t, err)
+	require.IsType(t, UnknownProvider{}, err)
+}
@@ -0,0 +1,13 @@
+package cluster
+
+import "fmt"
+
+// UnknownProvider is returned when the provider of a cluster is neither kind nor k3d.
+type UnknownProvider struct {
+	Provider Provider
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err UnknownProvider) Error() string {
+	return fmt.Sprintf("Unknown cluster provider %q: expected %q or %q", err.Provider, ProviderKind, ProviderK3d)
+}
@@ -0,0 +1,111 @@
+package cluster
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/shell"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// k3sRegistries is the registries configuration file of k3s. See https://docs.k3s.io/installation/private-registry.
+type k3sRegistries struct {
+	Mirrors map[string]k3sMirror `json:"mirrors"`
+}
+
+type k3sMirror struct {
+	Endpoint []string `json:"endpoint"`
+}
+
+// createK3dClusterE creates the given cluster with k3d, and writes its kubeconfig to the kubeconfig path of the
+// cluster rather than merging it into the default kubeconfig.
+func createK3dClusterE(t testing.TestingT, cluster *Cluster, options *Options) error {
+	registriesPath := ""
+	if len(options.RegistryMirrors) > 0 {
+		registries, err := k3sRegistriesYAMLE(options)
+		if err != nil {
+			return err
+		}
+		registriesPath = filepath.Join(cluster.workDir, "registries.yaml")
+		if err := ioutil.WriteFile(registriesPath, registries, 0644); err != nil {
+			return err
+		}
+	}
+
+	err := shell.RunCommandE(t, shell.Command{
+		Command: "k3d",
+		Args:    k3dCreateArgs(cluster.Name, options, registriesPath),
+		Logger:  cluster.logger,
+	})
+	if err != nil {
+		return err
+	}
+
+	return shell.RunCommandE(t, shell.Command{
+		Command: "k3d",
+		Args:    []string{"kubeconfig", "write", cluster.Name, "--output", cluster.KubeConfigPath},
+		Logger:  cluster.logger,
+	})
+}
+
+// loadK3dImagesE loads the given images from the local Docker daemon into the nodes of the given k3d cluster.
+func loadK3dImagesE(t testing.TestingT, cluster *Cluster, images []string) error {
+	args := append([]string{"image", "import", "--cluster", cluster.Name}, images...)
+	return shell.RunCommandE(t, shell.Command{Command: "k3d", Args: args, Logger: cluster.logger})
+}
+
+// deleteK3dClusterE deletes the given k3d cluster.
+func deleteK3dClusterE(t testing.TestingT, cluster *Cluster) error {
+	return shell.RunCommandE(t, shell.Command{
+		Command: "k3d",
+		Args:    []string{"cluster", "delete", cluster.Name},
+		Logger:  cluster.logger,
+	})
+}
+
+// k3dCreateArgs returns the arguments of `k3d` to create a cluster with the given name and options. The port mappings
+// are set on the load balancer in front of the nodes.
+func k3dCreateArgs(name string, options *Options, registriesPath string) []string {
+	args := []string{
+		"cluster", "create", name,
+		"--agents", strconv.Itoa(options.WorkerNodes),
+		"--wait",
+		"--kubeconfig-update-default=false",
+		"--kubeconfig-switch-context=false",
+	}
+
+	image := options.NodeImage
+	if image == "" && options.KubernetesVersion != "" {
+		image = fmt.Sprintf("rancher/k3s:%s-k3s1", options.KubernetesVersion)
+	}
+	if image != "" {
+		args = append(args, "--image", image)
+	}
+	for _, mapping := range options.PortMappings {
+		port := fmt.Sprintf("%d:%d", mapping.HostPort, mapping.ContainerPort)
+		if mapping.Protocol != "" {
+			port = fmt.Sprintf("%s/%s", port, strings.ToLower(mapping.Protocol))
+		}
+		args = append(args, "--port", port+"@loadbalancer")
+	}
+	if registriesPath != "" {
+		args = append(args, "--registry-config", registriesPath)
+	}
+	return args
+}
+
+// k3sRegistriesYAMLE returns the registries configuration file of k3s for the registry mirrors of the given options.
+func k3sRegistriesYAMLE(options *Options) ([]byte, error) {
+	registries := k3sRegistries{Mirrors: map[string]k3sMirror{}}
+	for _, mirror := range options.RegistryMirrors {
+		registryMirror := registries.Mirrors[mirror.Registry]
+		registryMirror.Endpoint = append(registryMirror.Endpoint, mirror.Endpoint)
+		registries.Mirrors[mirror.Registry] = registryMirror
+	}
+	return yaml.Marshal(registries)
+}
@@ -0,0 +1,62 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestK3dCreateArgs(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		WorkerNodes:       1,
+		KubernetesVersion: "v1.27.4",
+		PortMappings: []PortMapping{
+			{HostPort: 8080, ContainerPort: 80},
+			{HostPort: 5353, ContainerPort: 53, Protocol: "UDP"},
+		},
+	}
+	assert.Equal(t, []string{
+		"cluster", "create", "test",
+		"--agents", "1",
+		"--wait",
+		"--kubeconfig-update-default=false",
+		"--kubeconfig-switch-context=false",
+		"--image", "rancher/k3s:v1.27.4-k3s1",
+		"--port", "8080:80@loadbalancer",
+		"--port", "5353:53/udp@loadbalancer",
+		"--registry-config", "/tmp/registries.yaml",
+	}, k3dCreateArgs("test", options, "/tmp/registries.yaml"))
+
+	options = &Options{KubernetesVersion: "v1.27.4", NodeImage: "rancher/k3s:latest"}
+	assert.Equal(t, []string{
+		"cluster", "create", "test",
+		"--agents", "0",
+		"--wait",
+		"--kubeconfig-update-default=false",
+		"--kubeconfig-switch-context=false",
+		"--image", "rancher/k3s:latest",
+	}, k3dCreateArgs("test", options, ""))
+}
+
+func TestK3sRegistriesYAML(t *testing.T) {
+	t.Parallel()
+
+	registries, err := k3sRegistriesYAMLE(&Options{RegistryMirrors: []RegistryMirror{
+		{Registry: "docker.io", Endpoint: "http://registry-mirror:5000"},
+		{Registry: "docker.io", Endpoint: "https://mirror.gcr.io"},
+		{Registry: "quay.io", Endpoint: "http://quay-mirror:5000"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, `mirrors:
+  docker.io:
+    endpoint:
+    - http://registry-mirror:5000
+    - https://mirror.gcr.io
+  quay.io:
+    endpoint:
+    - http://quay-mirror:5000
+`, string(registries))
+}
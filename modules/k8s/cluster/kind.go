@@ -0,0 +1,108 @@
+package cluster
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/shell"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// How long kind waits for the control plane to be ready
+const kindWaitTimeout = "5m"
+
+// kindConfig is the configuration file of a kind cluster. See https://kind.sigs.k8s.io/docs/user/configuration/.
+type kindConfig struct {
+	Kind                    string     `json:"kind"`
+	APIVersion              string     `json:"apiVersion"`
+	Nodes                   []kindNode `json:"nodes"`
+	ContainerdConfigPatches []string   `json:"containerdConfigPatches,omitempty"`
+}
+
+type kindNode struct {
+	Role              string            `json:"role"`
+	Image             string            `json:"image,omitempty"`
+	ExtraPortMappings []kindPortMapping `json:"extraPortMappings,omitempty"`
+}
+
+type kindPortMapping struct {
+	ContainerPort int    `json:"containerPort"`
+	HostPort      int    `json:"hostPort"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+// createKindClusterE creates the given cluster with kind.
+func createKindClusterE(t testing.TestingT, cluster *Cluster, options *Options) error {
+	config, err := kindConfigYAMLE(options)
+	if err != nil {
+		return err
+	}
+	configPath := filepath.Join(cluster.workDir, "kind-config.yaml")
+	if err := ioutil.WriteFile(configPath, config, 0644); err != nil {
+		return err
+	}
+
+	return shell.RunCommandE(t, shell.Command{
+		Command: "kind",
+		Args: []string{
+			"create", "cluster",
+			"--name", cluster.Name,
+			"--config", configPath,
+			"--kubeconfig", cluster.KubeConfigPath,
+			"--wait", kindWaitTimeout,
+		},
+		Logger: cluster.logger,
+	})
+}
+
+// loadKindImagesE loads the given images from the local Docker daemon into the nodes of the given kind cluster.
+func loadKindImagesE(t testing.TestingT, cluster *Cluster, images []string) error {
+	args := append([]string{"load", "docker-image", "--name", cluster.Name}, images...)
+	return shell.RunCommandE(t, shell.Command{Command: "kind", Args: args, Logger: cluster.logger})
+}
+
+// deleteKindClusterE deletes the given kind cluster.
+func deleteKindClusterE(t testing.TestingT, cluster *Cluster) error {
+	return shell.RunCommandE(t, shell.Command{
+		Command: "kind",
+		Args:    []string{"delete", "cluster", "--name", cluster.Name, "--kubeconfig", cluster.KubeConfigPath},
+		Logger:  cluster.logger,
+	})
+}
+
+// kindConfigYAMLE returns the kind configuration file for the given options. The port mappings are set on the control
+// plane node.
+func kindConfigYAMLE(options *Options) ([]byte, error) {
+	image := options.NodeImage
+	if image == "" && options.KubernetesVersion != "" {
+		image = fmt.Sprintf("kindest/node:%s", options.KubernetesVersion)
+	}
+
+	controlPlane := kindNode{Role: "control-plane", Image: image}
+	for _, mapping := range options.PortMappings {
+		controlPlane.ExtraPortMappings = append(controlPlane.ExtraPortMappings, kindPortMapping{
+			ContainerPort: mapping.ContainerPort,
+			HostPort:      mapping.HostPort,
+			Protocol:      strings.ToUpper(mapping.Protocol),
+		})
+	}
+	config := kindConfig{
+		Kind:       "Cluster",
+		APIVersion: "kind.x-k8s.io/v1alpha4",
+		Nodes:      []kindNode{controlPlane},
+	}
+	for i := 0; i < options.WorkerNodes; i++ {
+		config.Nodes = append(config.Nodes, kindNode{Role: "worker", Image: image})
+	}
+	for _, mirror := range options.RegistryMirrors {
+		config.ContainerdConfigPatches = append(config.ContainerdConfigPatches, fmt.Sprintf(
+			"[plugins.\"io.containerd.grpc.v1.cri\".registry.mirrors.%q]\n  endpoint = [%q]",
+			mirror.Registry, mirror.Endpoint,
+		))
+	}
+	return yaml.Marshal(config)
+}
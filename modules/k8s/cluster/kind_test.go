@@ -0,0 +1,50 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKindConfigYAML(t *testing.T) {
+	t.Parallel()
+
+	config, err := kindConfigYAMLE(&Options{
+		WorkerNodes:       2,
+		KubernetesVersion: "v1.27.3",
+		PortMappings:      []PortMapping{{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}},
+		RegistryMirrors:   []RegistryMirror{{Registry: "docker.io", Endpoint: "http://registry-mirror:5000"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: kind.x-k8s.io/v1alpha4
+containerdConfigPatches:
+- |-
+  [plugins."io.containerd.grpc.v1.cri".registry.mirrors."docker.io"]
+    endpoint = ["http://registry-mirror:5000"]
+kind: Cluster
+nodes:
+- extraPortMappings:
+  - containerPort: 80
+    hostPort: 8080
+    protocol: TCP
+  image: kindest/node:v1.27.3
+  role: control-plane
+- image: kindest/node:v1.27.3
+  role: worker
+- image: kindest/node:v1.27.3
+  role: worker
+`, string(config))
+}
+
+func TestKindConfigYAMLDefaults(t *testing.T) {
+	t.Parallel()
+
+	config, err := kindConfigYAMLE(&Options{})
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: kind.x-k8s.io/v1alpha4
+kind: Cluster
+nodes:
+- role: control-plane
+`, string(config))
+}
@@ -8,7 +8,6 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	"github.com/tnn-gruntwork-io/terratest/modules/logger"
 	"github.com/tnn-gruntwork-io/terratest/modules/retry"
 	"github.com/tnn-gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
@@ -35,12 +34,12 @@ func GetConfigMapE(t testing.TestingT, options *KubectlOptions, configMapName st
 // WaitUntilConfigMapAvailable waits until the configmap is present on the cluster in cases where it is not immediately
 // available (for example, when using ClusterIssuer to request a certificate).
 func WaitUntilConfigMapAvailable(t testing.TestingT, options *KubectlOptions, configMapName string, retries int, sleepBetweenRetries time.Duration) {
+	start := time.Now()
 	statusMsg := fmt.Sprintf("Wait for configmap %s to be provisioned.", configMapName)
-	message := retry.DoWithRetry(
+	message := retry.DoWithPolicy(
 		t,
 		statusMsg,
-		retries,
-		sleepBetweenRetries,
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries),
 		func() (string, error) {
 			_, err := GetConfigMapE(t, options, configMapName)
 			if err != nil {
@@ -50,5 +49,5 @@ func WaitUntilConfigMapAvailable(t testing.TestingT, options *KubectlOptions, co
 			return "configmap is now available", nil
 		},
 	)
-	logger.Logf(t, message)
+	waitLogger("configmap/"+configMapName, start).Logf(t, message)
 }
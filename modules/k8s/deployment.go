@@ -0,0 +1,145 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/retry"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// ListDeployments will look for deployments in the given namespace that match the given filters and return them. This
+// will fail the test if there is an error.
+func ListDeployments(t testing.TestingT, options *KubectlOptions, filters metav1.ListOptions) []appsv1.Deployment {
+	deployments, err := ListDeploymentsE(t, options, filters)
+	require.NoError(t, err)
+	return deployments
+}
+
+// ListDeploymentsE will look for deployments in the given namespace that match the given filters and return them.
+func ListDeploymentsE(t testing.TestingT, options *KubectlOptions, filters metav1.ListOptions) ([]appsv1.Deployment, error) {
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := clientset.AppsV1().Deployments(options.Namespace).List(context.Background(), filters)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// GetDeployment returns a Kubernetes deployment resource in the provided namespace with the given name. This will
+// fail the test if there is an error.
+func GetDeployment(t testing.TestingT, options *KubectlOptions, deploymentName string) *appsv1.Deployment {
+	deployment, err := GetDeploymentE(t, options, deploymentName)
+	require.NoError(t, err)
+	return deployment
+}
+
+// GetDeploymentE returns a Kubernetes deployment resource in the provided namespace with the given name.
+func GetDeploymentE(t testing.TestingT, options *KubectlOptions, deploymentName string) (*appsv1.Deployment, error) {
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	return clientset.AppsV1().Deployments(options.Namespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+}
+
+// WaitUntilDeploymentAvailable waits until the rollout of the given deployment is complete, retrying the check for the
+// specified amount of times, sleeping for the provided duration between each try. This will fail the test if there is
+// an error or if the check times out, in which case the error includes the conditions and events of the pods of the
+// deployment that are not ready.
+func WaitUntilDeploymentAvailable(t testing.TestingT, options *KubectlOptions, deploymentName string, retries int, sleepBetweenRetries time.Duration) {
+	require.NoError(t, WaitUntilDeploymentAvailableE(t, options, deploymentName, retries, sleepBetweenRetries))
+}
+
+// WaitUntilDeploymentAvailableE waits until the rollout of the given deployment is complete, retrying the check for the
+// specified amount of times, sleeping for the provided duration between each try. If the check times out, or the
+// deployment exceeds its progress deadline, the error includes the conditions and events of the pods of the deployment
+// that are not ready.
+func WaitUntilDeploymentAvailableE(t testing.TestingT, options *KubectlOptions, deploymentName string, retries int, sleepBetweenRetries time.Duration) error {
+	start := time.Now()
+	statusMsg := fmt.Sprintf("Wait for deployment %s to be provisioned.", deploymentName)
+	var deployment *appsv1.Deployment
+	message, err := retry.DoWithPolicyE(
+		t,
+		statusMsg,
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries),
+		func() (string, error) {
+			var err error
+			deployment, err = GetDeploymentE(t, options, deploymentName)
+			if err != nil {
+				return "", err
+			}
+			if isDeploymentProgressDeadlineExceeded(deployment) {
+				// Kubernetes has given up on the rollout, so there is no point in waiting any longer
+				return "", retry.FatalError{Underlying: NewDeploymentNotAvailableError(deployment)}
+			}
+			if !IsDeploymentAvailable(deployment) {
+				return "", NewDeploymentNotAvailableError(deployment)
+			}
+			return "Deployment is now available", nil
+		},
+	)
+	if err != nil {
+		waitLogger("deployment/"+deploymentName, start).Logf(t, "Timed out waiting for Deployment to be provisioned: %s", err)
+		if deployment == nil {
+			return err
+		}
+		return newRolloutNotCompleteError(t, options, "Deployment", deploymentName, deployment.Spec.Selector, err)
+	}
+	waitLogger("deployment/"+deploymentName, start).Logf(t, message)
+	return nil
+}
+
+// IsDeploymentAvailable returns true if the rollout of the given deployment is complete, i.e. the controller has
+// observed the latest spec, and all the replicas have been updated and are available, with no old replicas left. This
+// is the same check as `kubectl rollout status`.
+func IsDeploymentAvailable(deployment *appsv1.Deployment) bool {
+	_, complete := deploymentRolloutStatus(deployment)
+	return complete
+}
+
+// deploymentRolloutStatus returns whether the rollout of the given deployment is complete, and if not, why.
+func deploymentRolloutStatus(deployment *appsv1.Deployment) (string, bool) {
+	status := deployment.Status
+	if deployment.Generation > status.ObservedGeneration {
+		return "waiting for the deployment spec update to be observed", false
+	}
+	if isDeploymentProgressDeadlineExceeded(deployment) {
+		return "deployment exceeded its progress deadline", false
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	if status.UpdatedReplicas < replicas {
+		return fmt.Sprintf("%d out of %d new replicas have been updated", status.UpdatedReplicas, replicas), false
+	}
+	if status.Replicas > status.UpdatedReplicas {
+		return fmt.Sprintf("%d old replicas are pending termination", status.Replicas-status.UpdatedReplicas), false
+	}
+	if status.AvailableReplicas < status.UpdatedReplicas {
+		return fmt.Sprintf("%d of %d updated replicas are available", status.AvailableReplicas, status.UpdatedReplicas), false
+	}
+	return "", true
+}
+
+// isDeploymentProgressDeadlineExceeded returns true if the given deployment has failed to make progress within its
+// progressDeadlineSeconds.
+func isDeploymentProgressDeadlineExceeded(deployment *appsv1.Deployment) bool {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse && condition.Reason == "ProgressDeadlineExceeded" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/random"
+)
+
+func TestGetDeploymentEReturnsErrorForNonExistantDeployment(t *testing.T) {
+	t.Parallel()
+
+	options := NewKubectlOptions("", "", "default")
+	_, err := GetDeploymentE(t, options, "nginx-deployment")
+	require.Error(t, err)
+}
+
+func TestGetDeploymentEReturnsCorrectDeploymentInCorrectNamespace(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(EXAMPLE_ROLLOUT_DEPLOYMENT_YAML_TEMPLATE, uniqueID, uniqueID, "nginx:1.23")
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+
+	deployment := GetDeployment(t, options, "nginx-deployment")
+	require.Equal(t, deployment.Name, "nginx-deployment")
+	require.Equal(t, deployment.Namespace, uniqueID)
+}
+
+func TestListDeploymentsReturnsDeploymentsInNamespace(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(EXAMPLE_ROLLOUT_DEPLOYMENT_YAML_TEMPLATE, uniqueID, uniqueID, "nginx:1.23")
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+
+	deployments := ListDeployments(t, options, metav1.ListOptions{})
+	require.Equal(t, len(deployments), 1)
+	require.Equal(t, deployments[0].Name, "nginx-deployment")
+	require.Equal(t, deployments[0].Namespace, uniqueID)
+}
+
+func TestWaitUntilDeploymentAvailable(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(EXAMPLE_ROLLOUT_DEPLOYMENT_YAML_TEMPLATE, uniqueID, uniqueID, "nginx:1.23")
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+
+	WaitUntilDeploymentAvailable(t, options, "nginx-deployment", 60, 1*time.Second)
+	deployment := GetDeployment(t, options, "nginx-deployment")
+	require.True(t, IsDeploymentAvailable(deployment))
+}
+
+func TestWaitUntilDeploymentAvailableDescribesFailingPods(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(EXAMPLE_ROLLOUT_DEPLOYMENT_YAML_TEMPLATE, uniqueID, uniqueID, "nginx:does-not-exist")
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+
+	err := WaitUntilDeploymentAvailableE(t, options, "nginx-deployment", 15, 1*time.Second)
+	var rolloutErr RolloutNotComplete
+	require.True(t, errors.As(err, &rolloutErr), "expected a RolloutNotComplete error, got %v", err)
+	require.NotEmpty(t, rolloutErr.FailingPods)
+	assert.Contains(t, rolloutErr.Error(), "waiting (ErrImagePull")
+}
+
+const EXAMPLE_ROLLOUT_DEPLOYMENT_YAML_TEMPLATE = `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: %s
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+  namespace: %s
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: nginx
+  template:
+    metadata:
+      labels:
+        app: nginx
+    spec:
+      containers:
+      - name: nginx
+        image: %s
+        ports:
+        - containerPort: 80
+`
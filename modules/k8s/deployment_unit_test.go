@@ -0,0 +1,103 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsDeploymentAvailable(t *testing.T) {
+	t.Parallel()
+
+	replicas := int32(3)
+	testCases := []struct {
+		title          string
+		generation     int64
+		status         appsv1.DeploymentStatus
+		expectedResult bool
+	}{
+		{
+			"spec update not observed",
+			2,
+			appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3},
+			false,
+		},
+		{
+			"replicas not updated",
+			1,
+			appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 3},
+			false,
+		},
+		{
+			"old replicas pending termination",
+			1,
+			appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 4, UpdatedReplicas: 3, AvailableReplicas: 3},
+			false,
+		},
+		{
+			"updated replicas not available",
+			1,
+			appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 2},
+			false,
+		},
+		{
+			"progress deadline exceeded",
+			1,
+			appsv1.DeploymentStatus{
+				ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3,
+				Conditions: []appsv1.DeploymentCondition{{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded"}},
+			},
+			false,
+		},
+		{
+			"rollout complete",
+			1,
+			appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3},
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: tc.generation},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status:     tc.status,
+			}
+			assert.Equal(t, tc.expectedResult, IsDeploymentAvailable(deployment))
+		})
+	}
+}
+
+func TestDescribeFailingPod(t *testing.T) {
+	t.Parallel()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-1"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+				{Type: corev1.PodReady, Status: corev1.ConditionFalse, Reason: "ContainersNotReady", Message: "containers with unready status: [app]"},
+			},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "sidecar", Ready: true},
+				{Name: "app", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off 5m0s"}}},
+			},
+		},
+	}
+
+	failingPod := describeFailingPod(pod)
+	failingPod.Events = formatPodEvents([]corev1.Event{
+		{Type: "Warning", Reason: "BackOff", Message: "Back-off restarting failed container", Count: 12},
+	})
+	assert.Equal(t, "Pod app-1 (Running)"+
+		"\n\tcondition Ready=False (ContainersNotReady): containers with unready status: [app]"+
+		"\n\tcontainer app: waiting (CrashLoopBackOff): back-off 5m0s"+
+		"\n\tevent Warning BackOff: Back-off restarting failed container (x12)", failingPod.String())
+}
@@ -0,0 +1,270 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/logger"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// The number of most recent lines of logs to collect of each container
+const diagnosticsLogTailLines = 200
+
+// Diagnostics is a snapshot of the state of a namespace, as collected by GetDiagnosticsE, to find out why a test
+// failed. It holds what you would otherwise look up with `kubectl get events`, `kubectl describe pods` and `kubectl
+// logs` after a failed build.
+type Diagnostics struct {
+	Namespace string
+	// The events of the namespace, oldest first
+	Events []string
+	Pods   []PodDiagnostics
+}
+
+// PodDiagnostics is the description and the logs of the containers of a pod.
+type PodDiagnostics struct {
+	Name string
+	// A description of the phase, conditions, container statuses and events of the pod, similar to `kubectl describe`
+	Description string
+	Containers  []ContainerDiagnostics
+}
+
+// ContainerDiagnostics is the status and the recent logs of a container of a pod.
+type ContainerDiagnostics struct {
+	Name   string
+	Status string
+	Logs   string
+	// The logs of the previous instance of the container, if it has restarted
+	PreviousLogs string
+}
+
+// CollectDiagnostics collects the diagnostics of the namespace of the options and writes them to the given directory,
+// or to the test log if the directory is empty. See CollectDiagnosticsE for the layout of the directory. This will fail
+// the test if there is an error.
+func CollectDiagnostics(t testing.TestingT, options *KubectlOptions, outputDir string) {
+	require.NoError(t, CollectDiagnosticsE(t, options, outputDir))
+}
+
+// CollectDiagnosticsE collects the diagnostics of the namespace of the options and writes them to the given directory,
+// or to the test log if the directory is empty. The directory has a folder for the namespace, with:
+//
+//   - events.txt: the events of the namespace.
+//   - <pod>/describe.txt: the description of each pod.
+//   - <pod>/<container>.log: the recent logs of each container of each pod.
+//   - <pod>/<container>.previous.log: the recent logs of the previous instance of each container that restarted.
+func CollectDiagnosticsE(t testing.TestingT, options *KubectlOptions, outputDir string) error {
+	diagnostics, err := GetDiagnosticsE(t, options)
+	if err != nil {
+		return err
+	}
+	if outputDir == "" {
+		logDiagnostics(t, diagnostics)
+		return nil
+	}
+	return writeDiagnostics(t, diagnostics, outputDir)
+}
+
+// CollectDiagnosticsOnFailure collects the diagnostics of the namespace of the options as in CollectDiagnostics, but
+// only if the test has failed. This is meant to be deferred at the start of a test, before the resources under test
+// are deleted:
+//
+//	defer k8s.CollectDiagnosticsOnFailure(t, options, "")
+//
+// Errors are logged rather than failing the test, as the test has failed already.
+func CollectDiagnosticsOnFailure(t testing.TestingT, options *KubectlOptions, outputDir string) {
+	if !testHasFailed(t) {
+		return
+	}
+	if err := CollectDiagnosticsE(t, options, outputDir); err != nil {
+		logger.Logf(t, "Error collecting the diagnostics of namespace %s: %s", options.Namespace, err)
+	}
+}
+
+// GetDiagnosticsE collects the events of the namespace of the options, and the description and recent logs of all the
+// pods in the namespace. Errors getting the logs of a container, e.g. because it has not started yet, are recorded in
+// place of the logs rather than returned.
+func GetDiagnosticsE(t testing.TestingT, options *KubectlOptions) (*Diagnostics, error) {
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := clientset.CoreV1().Events(options.Namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(events.Items, func(i, j int) bool { return eventTime(events.Items[i]).Before(eventTime(events.Items[j])) })
+
+	pods, err := ListPodsE(t, options, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+
+	diagnostics := &Diagnostics{Namespace: options.Namespace}
+	podEvents := map[string][]corev1.Event{}
+	for _, event := range events.Items {
+		diagnostics.Events = append(diagnostics.Events, formatNamespaceEvent(event))
+		if event.InvolvedObject.Kind == "Pod" {
+			podEvents[event.InvolvedObject.Name] = append(podEvents[event.InvolvedObject.Name], event)
+		}
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		podDiagnostics := PodDiagnostics{Name: pod.Name, Description: describePod(pod, podEvents[pod.Name])}
+		containerStatuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range containerStatuses {
+			container := ContainerDiagnostics{
+				Name:   status.Name,
+				Status: formatContainerStatus(status),
+				Logs:   getRecentContainerLogs(clientset, pod, status.Name, false),
+			}
+			if status.RestartCount > 0 {
+				container.PreviousLogs = getRecentContainerLogs(clientset, pod, status.Name, true)
+			}
+			podDiagnostics.Containers = append(podDiagnostics.Containers, container)
+		}
+		diagnostics.Pods = append(diagnostics.Pods, podDiagnostics)
+	}
+	return diagnostics, nil
+}
+
+// getRecentContainerLogs returns the most recent logs of the given container of the given pod, or of its previous
+// instance, or the error getting them.
+func getRecentContainerLogs(clientset *kubernetes.Clientset, pod *corev1.Pod, containerName string, previous bool) string {
+	tailLines := int64(diagnosticsLogTailLines)
+	logs, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container: containerName,
+		Previous:  previous,
+		TailLines: &tailLines,
+	}).DoRaw(context.Background())
+	if err != nil {
+		return fmt.Sprintf("Error getting logs: %s", err)
+	}
+	return string(logs)
+}
+
+// describePod describes the phase, conditions, container statuses and the given recent events of the given pod.
+func describePod(pod *corev1.Pod, events []corev1.Event) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Pod %s (%s)", pod.Name, pod.Status.Phase)
+	if pod.Spec.NodeName != "" {
+		fmt.Fprintf(&sb, " on node %s", pod.Spec.NodeName)
+	}
+	if pod.Status.Reason != "" || pod.Status.Message != "" {
+		fmt.Fprintf(&sb, "\n\t%s", formatReason("status", pod.Status.Reason, pod.Status.Message))
+	}
+	for _, condition := range pod.Status.Conditions {
+		fmt.Fprintf(&sb, "\n\tcondition %s", formatReason(fmt.Sprintf("%s=%s", condition.Type, condition.Status), condition.Reason, condition.Message))
+	}
+	containerStatuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range containerStatuses {
+		fmt.Fprintf(&sb, "\n\tcontainer %s", formatContainerStatus(status))
+	}
+	for _, event := range formatPodEvents(events) {
+		fmt.Fprintf(&sb, "\n\tevent %s", event)
+	}
+	return sb.String()
+}
+
+// formatContainerStatus formats the state, readiness and restarts of a container, e.g. "app: waiting
+// (CrashLoopBackOff): back-off 5m0s, not ready, restarted 3 times, last terminated with exit code 1 (Error)".
+func formatContainerStatus(status corev1.ContainerStatus) string {
+	readiness := "not ready"
+	if status.Ready {
+		readiness = "ready"
+	}
+	formatted := fmt.Sprintf("%s: %s, %s, restarted %d times", status.Name, formatContainerState(status.State), readiness, status.RestartCount)
+	if status.LastTerminationState.Terminated != nil {
+		formatted = fmt.Sprintf("%s, last %s", formatted, formatContainerState(status.LastTerminationState))
+	}
+	return formatted
+}
+
+// formatContainerState formats the state of a container, e.g. "terminated with exit code 1 (Error)".
+func formatContainerState(state corev1.ContainerState) string {
+	switch {
+	case state.Waiting != nil:
+		return formatReason("waiting", state.Waiting.Reason, state.Waiting.Message)
+	case state.Terminated != nil:
+		return formatReason(fmt.Sprintf("terminated with exit code %d", state.Terminated.ExitCode), state.Terminated.Reason, state.Terminated.Message)
+	case state.Running != nil:
+		return fmt.Sprintf("running since %s", state.Running.StartedAt.UTC().Format("2006-01-02T15:04:05Z"))
+	default:
+		return "unknown state"
+	}
+}
+
+// formatNamespaceEvent formats an event of a namespace, e.g. "2021-01-02T03:04:05Z Warning BackOff Pod/app-1: Back-off
+// restarting failed container (x12)".
+func formatNamespaceEvent(event corev1.Event) string {
+	formatted := fmt.Sprintf(
+		"%s %s %s %s/%s: %s",
+		eventTime(event).UTC().Format("2006-01-02T15:04:05Z"), event.Type, event.Reason, event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Message,
+	)
+	if event.Count > 1 {
+		formatted = fmt.Sprintf("%s (x%d)", formatted, event.Count)
+	}
+	return formatted
+}
+
+// logDiagnostics writes the given diagnostics to the test log.
+func logDiagnostics(t testing.TestingT, diagnostics *Diagnostics) {
+	logger.Logf(t, "Events of namespace %s:\n%s", diagnostics.Namespace, strings.Join(diagnostics.Events, "\n"))
+	for _, pod := range diagnostics.Pods {
+		logger.Logf(t, "%s", pod.Description)
+		for _, container := range pod.Containers {
+			logger.Logf(t, "Logs of container %s of pod %s:\n%s", container.Name, pod.Name, container.Logs)
+			if container.PreviousLogs != "" {
+				logger.Logf(t, "Logs of the previous instance of container %s of pod %s:\n%s", container.Name, pod.Name, container.PreviousLogs)
+			}
+		}
+	}
+}
+
+// writeDiagnostics writes the given diagnostics to a folder for the namespace in the given directory.
+func writeDiagnostics(t testing.TestingT, diagnostics *Diagnostics, outputDir string) error {
+	namespaceDir := filepath.Join(outputDir, diagnostics.Namespace)
+	files := map[string]string{
+		filepath.Join(namespaceDir, "events.txt"): strings.Join(diagnostics.Events, "\n") + "\n",
+	}
+	for _, pod := range diagnostics.Pods {
+		podDir := filepath.Join(namespaceDir, pod.Name)
+		files[filepath.Join(podDir, "describe.txt")] = pod.Description + "\n"
+		for _, container := range pod.Containers {
+			files[filepath.Join(podDir, container.Name+".log")] = container.Logs
+			if container.PreviousLogs != "" {
+				files[filepath.Join(podDir, container.Name+".previous.log")] = container.PreviousLogs
+			}
+		}
+	}
+
+	for path, contents := range files {
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			return err
+		}
+	}
+	logger.Logf(t, "Wrote the diagnostics of namespace %s to %s", diagnostics.Namespace, namespaceDir)
+	return nil
+}
+
+// testHasFailed returns true if the given test has failed. The TestingT interface does not expose whether a test has
+// failed, so this relies on the implementation, e.g. *testing.T, having a Failed method.
+func testHasFailed(t testing.TestingT) bool {
+	failer, ok := t.(interface{ Failed() bool })
+	return ok && failer.Failed()
+}
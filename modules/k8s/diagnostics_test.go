@@ -0,0 +1,59 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package k8s
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/random"
+)
+
+func TestCollectDiagnosticsWritesNamespaceEventsPodsAndLogs(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(EXAMPLE_POD_YAML_TEMPLATE, uniqueID, uniqueID)
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+	WaitUntilPodAvailable(t, options, "nginx-pod", 60, 1*time.Second)
+
+	outputDir := t.TempDir()
+	CollectDiagnostics(t, options, outputDir)
+
+	events, err := ioutil.ReadFile(filepath.Join(outputDir, uniqueID, "events.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(events), "Pod/nginx-pod")
+
+	description, err := ioutil.ReadFile(filepath.Join(outputDir, uniqueID, "nginx-pod", "describe.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(description), "Pod nginx-pod (Running)")
+	assert.Contains(t, string(description), "container nginx: running since")
+
+	require.FileExists(t, filepath.Join(outputDir, uniqueID, "nginx-pod", "nginx.log"))
+	require.NoFileExists(t, filepath.Join(outputDir, uniqueID, "nginx-pod", "nginx.previous.log"))
+}
+
+func TestCollectDiagnosticsOnFailureDoesNothingWhenTestPasses(t *testing.T) {
+	t.Parallel()
+
+	options := NewKubectlOptions("", "", "does-not-exist")
+	outputDir := t.TempDir()
+	CollectDiagnosticsOnFailure(t, options, outputDir)
+	require.NoDirExists(t, filepath.Join(outputDir, "does-not-exist"))
+}
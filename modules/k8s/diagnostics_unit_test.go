@@ -0,0 +1,38 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFormatContainerStatus(t *testing.T) {
+	t.Parallel()
+
+	status := corev1.ContainerStatus{
+		Name:         "app",
+		RestartCount: 3,
+		State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off 5m0s"}},
+		LastTerminationState: corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"},
+		},
+	}
+	assert.Equal(t, "app: waiting (CrashLoopBackOff): back-off 5m0s, not ready, restarted 3 times, last terminated with exit code 1 (Error)", formatContainerStatus(status))
+}
+
+func TestFormatNamespaceEvent(t *testing.T) {
+	t.Parallel()
+
+	event := corev1.Event{
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "app-1"},
+		Type:           "Warning",
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container",
+		Count:          12,
+		LastTimestamp:  metav1.NewTime(time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)),
+	}
+	assert.Equal(t, "2021-01-02T03:04:05Z Warning BackOff Pod/app-1: Back-off restarting failed container (x12)", formatNamespaceEvent(event))
+}
@@ -2,12 +2,15 @@ package k8s
 
 import (
 	"fmt"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	networkingv1beta1 "k8s.io/api/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // IngressNotAvailable is returned when a Kubernetes service is not yet available to accept traffic.
@@ -39,6 +42,17 @@ func (err UnknownKubeResourceType) Error() string {
 	return fmt.Sprintf("ResourceType ID %d is unknown", err.ResourceType)
 }
 
+// TunnelNotConnected is returned when a port forwarding tunnel is not connected to a pod, e.g. while it reconnects.
+type TunnelNotConnected struct {
+	ResourceType KubeResourceType
+	ResourceName string
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err TunnelNotConnected) Error() string {
+	return fmt.Sprintf("Tunnel to %s/%s is not connected to a pod", err.ResourceType, err.ResourceName)
+}
+
 // DesiredNumberOfPodsNotCreated is returned when the number of pods matching a filter condition does not match the
 // desired number of Pods.
 type DesiredNumberOfPodsNotCreated struct {
@@ -91,6 +105,173 @@ func NewJobNotSucceeded(job *batchv1.Job) JobNotSucceeded {
 	return JobNotSucceeded{job}
 }
 
+// ResourceConditionNotMet is returned when a Kubernetes resource does not have the expected status condition.
+type ResourceConditionNotMet struct {
+	resource        *unstructured.Unstructured
+	conditionType   string
+	conditionStatus string
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err ResourceConditionNotMet) Error() string {
+	condition, found := GetResourceCondition(err.resource, err.conditionType)
+	if !found {
+		return fmt.Sprintf("%s %s does not have condition %s", err.resource.GetKind(), err.resource.GetName(), err.conditionType)
+	}
+	return fmt.Sprintf(
+		"%s %s has condition %s=%s instead of %s, reason: %s, message: %s",
+		err.resource.GetKind(), err.resource.GetName(), err.conditionType, condition.Status, err.conditionStatus, condition.Reason, condition.Message,
+	)
+}
+
+// NewResourceConditionNotMetError returns a ResourceConditionNotMet struct when a resource does not have the expected
+// status condition
+func NewResourceConditionNotMetError(resource *unstructured.Unstructured, conditionType string, conditionStatus string) ResourceConditionNotMet {
+	return ResourceConditionNotMet{resource, conditionType, conditionStatus}
+}
+
+// DeploymentNotAvailable is returned when the rollout of a Kubernetes deployment is not complete.
+type DeploymentNotAvailable struct {
+	deployment *appsv1.Deployment
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err DeploymentNotAvailable) Error() string {
+	reason, _ := deploymentRolloutStatus(err.deployment)
+	return fmt.Sprintf("Deployment %s is not available, reason: %s", err.deployment.Name, reason)
+}
+
+// NewDeploymentNotAvailableError returns a DeploymentNotAvailable struct when the rollout of a deployment is not complete
+func NewDeploymentNotAvailableError(deployment *appsv1.Deployment) DeploymentNotAvailable {
+	return DeploymentNotAvailable{deployment}
+}
+
+// StatefulSetNotAvailable is returned when the rollout of a Kubernetes statefulset is not complete.
+type StatefulSetNotAvailable struct {
+	statefulSet *appsv1.StatefulSet
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err StatefulSetNotAvailable) Error() string {
+	reason, _ := statefulSetRolloutStatus(err.statefulSet)
+	return fmt.Sprintf("StatefulSet %s is not available, reason: %s", err.statefulSet.Name, reason)
+}
+
+// NewStatefulSetNotAvailableError returns a StatefulSetNotAvailable struct when the rollout of a statefulset is not
+// complete
+func NewStatefulSetNotAvailableError(statefulSet *appsv1.StatefulSet) StatefulSetNotAvailable {
+	return StatefulSetNotAvailable{statefulSet}
+}
+
+// RolloutNotComplete is returned when waiting for the rollout of a Kubernetes deployment or statefulset times out. It
+// describes the pods of the workload that are not ready, to help find out why.
+type RolloutNotComplete struct {
+	Kind        string
+	Name        string
+	Underlying  error
+	FailingPods []FailingPod
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err RolloutNotComplete) Error() string {
+	message := fmt.Sprintf("Rollout of %s %s did not complete: %s", err.Kind, err.Name, err.Underlying)
+	for _, pod := range err.FailingPods {
+		message += "\n" + pod.String()
+	}
+	return message
+}
+
+// Unwrap returns the error of the last check of the rollout.
+func (err RolloutNotComplete) Unwrap() error {
+	return err.Underlying
+}
+
+// LogLineNotFound is returned when no line matching a pattern is logged by the followed pods before a timeout.
+type LogLineNotFound struct {
+	pattern string
+	timeout time.Duration
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err LogLineNotFound) Error() string {
+	return fmt.Sprintf("No log line matching %s was found within %s", err.pattern, err.timeout)
+}
+
+// NewLogLineNotFoundError returns a LogLineNotFound struct when no log line matches a pattern before a timeout
+func NewLogLineNotFoundError(pattern string, timeout time.Duration) LogLineNotFound {
+	return LogLineNotFound{pattern, timeout}
+}
+
+// NamespaceNotDeleted is returned when a Kubernetes namespace still exists after it has been deleted, e.g. because
+// some of its resources have finalizers that have not run.
+type NamespaceNotDeleted struct {
+	namespace *corev1.Namespace
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err NamespaceNotDeleted) Error() string {
+	message := fmt.Sprintf("Namespace %s is not deleted, phase: %s", err.namespace.Name, err.namespace.Status.Phase)
+	for _, condition := range err.namespace.Status.Conditions {
+		if condition.Status == corev1.ConditionTrue {
+			message += ", " + formatReason(string(condition.Type), condition.Reason, condition.Message)
+		}
+	}
+	return message
+}
+
+// NewNamespaceNotDeletedError returns a NamespaceNotDeleted struct when a namespace still exists after it has been
+// deleted
+func NewNamespaceNotDeletedError(namespace *corev1.Namespace) NamespaceNotDeleted {
+	return NamespaceNotDeleted{namespace}
+}
+
+// PermissionsMismatch is returned when the permissions of a subject are not the expected ones.
+type PermissionsMismatch struct {
+	Subject             string
+	UnexpectedlyAllowed []Permission
+	UnexpectedlyDenied  []Permission
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err PermissionsMismatch) Error() string {
+	message := fmt.Sprintf("Permissions of %s do not match the expected permissions:", err.Subject)
+	for _, permission := range err.UnexpectedlyAllowed {
+		message += fmt.Sprintf("\n\t+ %s: allowed, expected denied", permission)
+	}
+	for _, permission := range err.UnexpectedlyDenied {
+		message += fmt.Sprintf("\n\t- %s: denied, expected allowed", permission)
+	}
+	return message
+}
+
+// PermissionsNotInMatrix is returned when the expected permissions of a subject include permissions that are not
+// checked, as they are not in the permission matrix, e.g. because of a typo in a namespace or a resource.
+type PermissionsNotInMatrix struct {
+	Permissions []Permission
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err PermissionsNotInMatrix) Error() string {
+	message := "Expected permissions are not in the permission matrix, so they are not checked:"
+	for _, permission := range err.Permissions {
+		message += fmt.Sprintf("\n\t%s", permission)
+	}
+	return message
+}
+
+// PermissionEvaluationError is returned when a SubjectAccessReview denies a permission because the authorizer failed to
+// evaluate it, e.g. because a webhook authorizer was unavailable.
+type PermissionEvaluationError struct {
+	Subject         string
+	Permission      Permission
+	EvaluationError string
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err PermissionEvaluationError) Error() string {
+	return fmt.Sprintf("Failed to evaluate whether %s may %s: %s", err.Subject, err.Permission, err.EvaluationError)
+}
+
 // ServiceNotAvailable is returned when a Kubernetes service is not yet available to accept traffic.
 type ServiceNotAvailable struct {
 	service *corev1.Service
@@ -0,0 +1,101 @@
+package k8s
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/logger"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// ExecResult is the output of a command run in a container of a pod.
+type ExecResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// ExecPod runs the given command in the given container of the pod with the provided name, and returns its stdout,
+// stderr and exit code. If containerName is empty, the command runs in the only container of the pod. A command that
+// exits with a non-zero exit code does not fail the test: check the ExitCode of the result instead. This will fail the
+// test if the command could not be run.
+func ExecPod(t testing.TestingT, options *KubectlOptions, podName string, containerName string, command ...string) ExecResult {
+	result, err := ExecPodE(t, options, podName, containerName, command...)
+	require.NoError(t, err)
+	return result
+}
+
+// ExecPodE runs the given command in the given container of the pod with the provided name, and returns its stdout,
+// stderr and exit code. If containerName is empty, the command runs in the only container of the pod. A command that
+// exits with a non-zero exit code is not an error: check the ExitCode of the result instead.
+func ExecPodE(t testing.TestingT, options *KubectlOptions, podName string, containerName string, command ...string) (ExecResult, error) {
+	return ExecPodWithStdinE(t, options, podName, containerName, nil, command...)
+}
+
+// ExecPodWithStdin runs the given command in the given container of the pod with the provided name, streaming stdin
+// to the command, and returns its stdout, stderr and exit code. This will fail the test if the command could not be
+// run.
+func ExecPodWithStdin(t testing.TestingT, options *KubectlOptions, podName string, containerName string, stdin io.Reader, command ...string) ExecResult {
+	result, err := ExecPodWithStdinE(t, options, podName, containerName, stdin, command...)
+	require.NoError(t, err)
+	return result
+}
+
+// ExecPodWithStdinE runs the given command in the given container of the pod with the provided name, streaming stdin
+// to the command, and returns its stdout, stderr and exit code. The stdin of the command is closed once stdin is
+// exhausted. A nil stdin runs the command without stdin.
+func ExecPodWithStdinE(t testing.TestingT, options *KubectlOptions, podName string, containerName string, stdin io.Reader, command ...string) (ExecResult, error) {
+	config, err := GetRestConfigFromOptionsE(t, options)
+	if err != nil {
+		return ExecResult{}, err
+	}
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return ExecResult{}, err
+	}
+
+	// Build a url to the exec endpoint
+	// example: https://localhost:6443/api/v1/namespaces/default/pods/nginx/exec?command=ls&container=nginx&stdout=true
+	request := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(options.Namespace).
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: containerName,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(config, "POST", request.URL())
+	if err != nil {
+		return ExecResult{}, err
+	}
+
+	logger.Logf(t, "Running command '%s' in pod %s", strings.Join(command, " "), podName)
+	var stdout, stderr bytes.Buffer
+	err = executor.Stream(remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	result := ExecResult{Stdout: stdout.String(), Stderr: stderr.String()}
+
+	// The exit code of a command that ran but failed is reported as an error of the stream
+	var exitErr exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.Exited() {
+		result.ExitCode = exitErr.ExitStatus()
+		return result, nil
+	}
+	return result, err
+}
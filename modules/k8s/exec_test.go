@@ -0,0 +1,63 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/random"
+)
+
+func TestExecPodReturnsOutputAndExitCode(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(EXAMPLE_POD_YAML_TEMPLATE, uniqueID, uniqueID)
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+	WaitUntilPodAvailable(t, options, "nginx-pod", 60, 1*time.Second)
+
+	result := ExecPod(t, options, "nginx-pod", "nginx", "sh", "-c", "echo out; echo err >&2; exit 3")
+	require.Equal(t, "out\n", result.Stdout)
+	require.Equal(t, "err\n", result.Stderr)
+	require.Equal(t, 3, result.ExitCode)
+
+	result = ExecPod(t, options, "nginx-pod", "", "true")
+	require.Equal(t, 0, result.ExitCode)
+}
+
+func TestExecPodWithStdin(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(EXAMPLE_POD_YAML_TEMPLATE, uniqueID, uniqueID)
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+	WaitUntilPodAvailable(t, options, "nginx-pod", 60, 1*time.Second)
+
+	result := ExecPodWithStdin(t, options, "nginx-pod", "nginx", strings.NewReader("hello\nworld\n"), "wc", "-l")
+	require.Equal(t, "2", strings.TrimSpace(result.Stdout))
+	require.Equal(t, 0, result.ExitCode)
+}
+
+func TestExecPodEReturnsErrorForNonExistantPod(t *testing.T) {
+	t.Parallel()
+
+	options := NewKubectlOptions("", "", "default")
+	_, err := ExecPodE(t, options, "does-not-exist", "", "true")
+	require.Error(t, err)
+}
@@ -10,7 +10,6 @@ import (
 	networkingv1beta1 "k8s.io/api/networking/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	"github.com/tnn-gruntwork-io/terratest/modules/logger"
 	"github.com/tnn-gruntwork-io/terratest/modules/retry"
 	"github.com/tnn-gruntwork-io/terratest/modules/testing"
 )
@@ -63,12 +62,12 @@ func IsIngressAvailable(ingress *networkingv1.Ingress) bool {
 
 // WaitUntilIngressAvailable waits until the Ingress resource has an endpoint provisioned for it.
 func WaitUntilIngressAvailable(t testing.TestingT, options *KubectlOptions, ingressName string, retries int, sleepBetweenRetries time.Duration) {
+	start := time.Now()
 	statusMsg := fmt.Sprintf("Wait for ingress %s to be provisioned.", ingressName)
-	message := retry.DoWithRetry(
+	message := retry.DoWithPolicy(
 		t,
 		statusMsg,
-		retries,
-		sleepBetweenRetries,
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries),
 		func() (string, error) {
 			ingress, err := GetIngressE(t, options, ingressName)
 			if err != nil {
@@ -80,7 +79,7 @@ func WaitUntilIngressAvailable(t testing.TestingT, options *KubectlOptions, ingr
 			return "Ingress is now available", nil
 		},
 	)
-	logger.Logf(t, message)
+	waitLogger("ingress/"+ingressName, start).Logf(t, message)
 }
 
 // ListIngressesV1Beta1 will look for Ingress resources in the given namespace that match the given filters and return
@@ -135,12 +134,12 @@ func IsIngressAvailableV1Beta1(ingress *networkingv1beta1.Ingress) bool {
 // WaitUntilIngressAvailableV1Beta1 waits until the Ingress resource has an endpoint provisioned for it, using
 // networking.k8s.io/v1beta1 API.
 func WaitUntilIngressAvailableV1Beta1(t testing.TestingT, options *KubectlOptions, ingressName string, retries int, sleepBetweenRetries time.Duration) {
+	start := time.Now()
 	statusMsg := fmt.Sprintf("Wait for ingress %s to be provisioned.", ingressName)
-	message := retry.DoWithRetry(
+	message := retry.DoWithPolicy(
 		t,
 		statusMsg,
-		retries,
-		sleepBetweenRetries,
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries),
 		func() (string, error) {
 			ingress, err := GetIngressV1Beta1E(t, options, ingressName)
 			if err != nil {
@@ -152,5 +151,5 @@ func WaitUntilIngressAvailableV1Beta1(t testing.TestingT, options *KubectlOption
 			return "Ingress is now available", nil
 		},
 	)
-	logger.Logf(t, message)
+	waitLogger("ingress/"+ingressName, start).Logf(t, message)
 }
@@ -10,7 +10,6 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	"github.com/tnn-gruntwork-io/terratest/modules/logger"
 	"github.com/tnn-gruntwork-io/terratest/modules/retry"
 	"github.com/tnn-gruntwork-io/terratest/modules/testing"
 )
@@ -63,12 +62,12 @@ func WaitUntilJobSucceed(t testing.TestingT, options *KubectlOptions, jobName st
 // WaitUntilJobSucceedE waits until requested job is succeeded, retrying the check for the specified amount of times, sleeping
 // for the provided duration between each try.
 func WaitUntilJobSucceedE(t testing.TestingT, options *KubectlOptions, jobName string, retries int, sleepBetweenRetries time.Duration) error {
+	start := time.Now()
 	statusMsg := fmt.Sprintf("Wait for job %s to be provisioned.", jobName)
-	message, err := retry.DoWithRetryE(
+	message, err := retry.DoWithPolicyE(
 		t,
 		statusMsg,
-		retries,
-		sleepBetweenRetries,
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries),
 		func() (string, error) {
 			job, err := GetJobE(t, options, jobName)
 			if err != nil {
@@ -81,10 +80,10 @@ func WaitUntilJobSucceedE(t testing.TestingT, options *KubectlOptions, jobName s
 		},
 	)
 	if err != nil {
-		logger.Logf(t, "Timed out waiting for Job to be provisioned: %s", err)
+		waitLogger("job/"+jobName, start).Logf(t, "Timed out waiting for Job to be provisioned: %s", err)
 		return err
 	}
-	logger.Logf(t, message)
+	waitLogger("job/"+jobName, start).Logf(t, message)
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package k8s
 
 import (
+	"github.com/tnn-gruntwork-io/terratest/modules/retry"
 	"github.com/tnn-gruntwork-io/terratest/modules/testing"
 )
 
@@ -11,6 +12,11 @@ type KubectlOptions struct {
 	Namespace     string
 	Env           map[string]string
 	InClusterAuth bool
+
+	// If set, the WaitUntil* functions use this policy instead of their retries and sleepBetweenRetries arguments, e.g.
+	// to back off exponentially with jitter when many parallel tests poll the same cluster. It is not saved by
+	// test_structure.SaveKubectlOptions, as it may have functions.
+	RetryPolicy *retry.Policy `json:"-"`
 }
 
 // NewKubectlOptions will return a pointer to new instance of KubectlOptions with the configured options
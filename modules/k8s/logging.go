@@ -0,0 +1,13 @@
+package k8s
+
+import (
+	"time"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/logger"
+)
+
+// waitLogger returns a logger for the messages logged when a WaitUntil function is done, which attaches the resource
+// being waited for, e.g. "pod/my-pod", and how long the wait took since the given start as structured fields.
+func waitLogger(resource string, start time.Time) *logger.Logger {
+	return logger.Default.WithFields(logger.Fields{logger.FieldResource: resource, logger.FieldDuration: time.Since(start)})
+}
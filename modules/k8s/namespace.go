@@ -2,10 +2,14 @@ package k8s
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"github.com/tnn-gruntwork-io/terratest/modules/retry"
 	"github.com/tnn-gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -79,3 +83,41 @@ func DeleteNamespaceE(t testing.TestingT, options *KubectlOptions, namespaceName
 
 	return clientset.CoreV1().Namespaces().Delete(context.Background(), namespaceName, metav1.DeleteOptions{})
 }
+
+// WaitUntilNamespaceDeleted waits until the requested namespace no longer exists on the Kubernetes cluster targeted by
+// the provided options, i.e. all its resources have been deleted and all its finalizers have run. This will retry the
+// check for the specified amount of times, sleeping for the provided duration between each try. This will fail the test
+// if there is an error or if the check times out.
+func WaitUntilNamespaceDeleted(t testing.TestingT, options *KubectlOptions, namespaceName string, retries int, sleepBetweenRetries time.Duration) {
+	require.NoError(t, WaitUntilNamespaceDeletedE(t, options, namespaceName, retries, sleepBetweenRetries))
+}
+
+// WaitUntilNamespaceDeletedE waits until the requested namespace no longer exists on the Kubernetes cluster targeted by
+// the provided options, i.e. all its resources have been deleted and all its finalizers have run. This will retry the
+// check for the specified amount of times, sleeping for the provided duration between each try. If the check times out,
+// the error describes the conditions of the namespace, e.g. the finalizers or resources that remain.
+func WaitUntilNamespaceDeletedE(t testing.TestingT, options *KubectlOptions, namespaceName string, retries int, sleepBetweenRetries time.Duration) error {
+	start := time.Now()
+	statusMsg := fmt.Sprintf("Wait for namespace %s to be deleted.", namespaceName)
+	message, err := retry.DoWithPolicyE(
+		t,
+		statusMsg,
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries),
+		func() (string, error) {
+			namespace, err := GetNamespaceE(t, options, namespaceName)
+			if errors.IsNotFound(err) {
+				return "Namespace is now deleted", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			return "", NewNamespaceNotDeletedError(namespace)
+		},
+	)
+	if err != nil {
+		waitLogger("namespace/"+namespaceName, start).Logf(t, "Timed out waiting for Namespace to be deleted: %s", err)
+		return err
+	}
+	waitLogger("namespace/"+namespaceName, start).Logf(t, message)
+	return nil
+}
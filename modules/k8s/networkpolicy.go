@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/tnn-gruntwork-io/terratest/modules/logger"
 	"github.com/tnn-gruntwork-io/terratest/modules/retry"
 	"github.com/tnn-gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
@@ -34,12 +33,12 @@ func GetNetworkPolicyE(t testing.TestingT, options *KubectlOptions, networkPolic
 // WaitUntilNetworkPolicyAvailable waits until the networkpolicy is present on the cluster in cases where it is not immediately
 // available (for example, when using ClusterIssuer to request a certificate).
 func WaitUntilNetworkPolicyAvailable(t testing.TestingT, options *KubectlOptions, networkPolicyName string, retries int, sleepBetweenRetries time.Duration) {
+	start := time.Now()
 	statusMsg := fmt.Sprintf("Wait for networkpolicy %s to be provisioned.", networkPolicyName)
-	message := retry.DoWithRetry(
+	message := retry.DoWithPolicy(
 		t,
 		statusMsg,
-		retries,
-		sleepBetweenRetries,
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries),
 		func() (string, error) {
 			_, err := GetNetworkPolicyE(t, options, networkPolicyName)
 			if err != nil {
@@ -49,5 +48,5 @@ func WaitUntilNetworkPolicyAvailable(t testing.TestingT, options *KubectlOptions
 			return "networkpolicy is now available", nil
 		},
 	)
-	logger.Logf(t, message)
+	waitLogger("networkpolicy/"+networkPolicyName, start).Logf(t, message)
 }
@@ -89,11 +89,11 @@ func WaitUntilAllNodesReady(t testing.TestingT, options *KubectlOptions, retries
 // WaitUntilAllNodesReadyE continuously polls the Kubernetes cluster until all nodes in the cluster reach the ready
 // state, or runs out of retries.
 func WaitUntilAllNodesReadyE(t testing.TestingT, options *KubectlOptions, retries int, sleepBetweenRetries time.Duration) error {
-	message, err := retry.DoWithRetryE(
+	start := time.Now()
+	message, err := retry.DoWithPolicyE(
 		t,
 		"Wait for all Kube Nodes to be ready",
-		retries,
-		sleepBetweenRetries,
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries),
 		func() (string, error) {
 			_, err := AreAllNodesReadyE(t, options)
 			if err != nil {
@@ -102,7 +102,7 @@ func WaitUntilAllNodesReadyE(t testing.TestingT, options *KubectlOptions, retrie
 			return "All nodes ready", nil
 		},
 	)
-	logger.Logf(t, message)
+	waitLogger("nodes", start).Logf(t, message)
 	return err
 }
 
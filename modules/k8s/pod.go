@@ -78,11 +78,10 @@ func WaitUntilNumPodsCreatedE(
 	sleepBetweenRetries time.Duration,
 ) error {
 	statusMsg := fmt.Sprintf("Wait for num pods created to match desired count %d.", desiredCount)
-	message, err := retry.DoWithRetryE(
+	message, err := retry.DoWithPolicyE(
 		t,
 		statusMsg,
-		retries,
-		sleepBetweenRetries,
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries),
 		func() (string, error) {
 			pods, err := ListPodsE(t, options, filters)
 			if err != nil {
@@ -111,12 +110,12 @@ func WaitUntilPodAvailable(t testing.TestingT, options *KubectlOptions, podName
 // WaitUntilPodAvailableE waits until all of the containers within the pod are ready and started, retrying the check for the specified amount of times, sleeping
 // for the provided duration between each try.
 func WaitUntilPodAvailableE(t testing.TestingT, options *KubectlOptions, podName string, retries int, sleepBetweenRetries time.Duration) error {
+	start := time.Now()
 	statusMsg := fmt.Sprintf("Wait for pod %s to be provisioned.", podName)
-	message, err := retry.DoWithRetryE(
+	message, err := retry.DoWithPolicyE(
 		t,
 		statusMsg,
-		retries,
-		sleepBetweenRetries,
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries),
 		func() (string, error) {
 			pod, err := GetPodE(t, options, podName)
 			if err != nil {
@@ -129,10 +128,10 @@ func WaitUntilPodAvailableE(t testing.TestingT, options *KubectlOptions, podName
 		},
 	)
 	if err != nil {
-		logger.Logf(t, "Timedout waiting for Pod to be provisioned: %s", err)
+		waitLogger("pod/"+podName, start).Logf(t, "Timedout waiting for Pod to be provisioned: %s", err)
 		return err
 	}
-	logger.Logf(t, message)
+	waitLogger("pod/"+podName, start).Logf(t, message)
 	return nil
 }
 
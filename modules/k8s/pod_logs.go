@@ -0,0 +1,339 @@
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/logger"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// How often a PodLogFollower looks for new pods and restarted containers to follow
+const podLogsPollInterval = 1 * time.Second
+
+// LogLine is a line logged by a container of a pod.
+type LogLine struct {
+	Pod       string
+	Container string
+	Text      string
+}
+
+func (line LogLine) String() string {
+	return fmt.Sprintf("[%s/%s] %s", line.Pod, line.Container, line.Text)
+}
+
+// PodLogFollower follows the logs of all the containers of the pods matching a filter, including the pods that are
+// created and the containers that restart after it started following. Create one with FollowPodLogs, and Close it when
+// done.
+type PodLogFollower struct {
+	options *KubectlOptions
+	filters metav1.ListOptions
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	mutex sync.Mutex
+	lines []LogLine
+	// Closed and replaced each time a line is logged, to wake up the functions waiting for lines
+	linesChanged chan struct{}
+	// The container instances being or having been followed, by ID. A restarted container has a new ID.
+	followedContainers map[string]*followedContainer
+	closed             bool
+}
+
+// followedContainer is the state of the stream of logs of a container instance.
+type followedContainer struct {
+	// False once the stream has failed, e.g. because the API was briefly unavailable, so that it is reopened
+	following bool
+	// Where to resume the stream when it is reopened
+	position containerLogPosition
+}
+
+// containerLogPosition is the timestamp of the last line received from a container, and the number of lines received
+// with that timestamp.
+type containerLogPosition struct {
+	since        time.Time
+	linesAtSince int
+}
+
+// FollowPodLogs starts following the logs of all the containers of the pods matching the given filters in the
+// namespace of the options. This will fail the test if there is an error.
+func FollowPodLogs(t testing.TestingT, options *KubectlOptions, filters metav1.ListOptions) *PodLogFollower {
+	follower, err := FollowPodLogsE(t, options, filters)
+	require.NoError(t, err)
+	return follower
+}
+
+// FollowPodLogsE starts following the logs of all the containers of the pods matching the given filters in the
+// namespace of the options. The follower looks for new pods and restarted containers every second, and follows the logs
+// of each container instance from its start, so no line is missed across restarts. Make sure to Close the follower:
+//
+//	follower := k8s.FollowPodLogs(t, options, metav1.ListOptions{LabelSelector: "app=nginx"})
+//	defer follower.Close()
+//	follower.WaitUntilLogLineMatches(t, "ready", 1*time.Minute)
+func FollowPodLogsE(t testing.TestingT, options *KubectlOptions, filters metav1.ListOptions) (*PodLogFollower, error) {
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	follower := newPodLogFollower(options, filters)
+	if err := follower.followNewContainersE(t, clientset); err != nil {
+		follower.Close()
+		return nil, err
+	}
+
+	follower.wg.Add(1)
+	go func() {
+		defer follower.wg.Done()
+		ticker := time.NewTicker(podLogsPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-follower.ctx.Done():
+				return
+			case <-ticker.C:
+				// The API may be briefly unavailable, e.g. during an upgrade, so keep trying
+				if err := follower.followNewContainersE(t, clientset); err != nil {
+					logger.Logf(t, "Error looking for pods to follow the logs of: %s", err)
+				}
+			}
+		}
+	}()
+	return follower, nil
+}
+
+func newPodLogFollower(options *KubectlOptions, filters metav1.ListOptions) *PodLogFollower {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &PodLogFollower{
+		options:            options,
+		filters:            filters,
+		ctx:                ctx,
+		cancel:             cancel,
+		linesChanged:       make(chan struct{}),
+		followedContainers: map[string]*followedContainer{},
+	}
+}
+
+// Lines returns the lines logged so far, in the order they were received.
+func (follower *PodLogFollower) Lines() []LogLine {
+	follower.mutex.Lock()
+	defer follower.mutex.Unlock()
+	return append([]LogLine{}, follower.lines...)
+}
+
+// WaitUntilLogLineMatches waits until a line logged by one of the followed containers, since the follower started,
+// matches the given regular expression, and returns it. This will fail the test if the timeout expires first.
+func (follower *PodLogFollower) WaitUntilLogLineMatches(t testing.TestingT, pattern string, timeout time.Duration) LogLine {
+	line, err := follower.WaitUntilLogLineMatchesE(t, pattern, timeout)
+	require.NoError(t, err)
+	return line
+}
+
+// WaitUntilLogLineMatchesE waits until a line logged by one of the followed containers, since the follower started,
+// matches the given regular expression, and returns it. An error is returned if the timeout expires or the follower is
+// closed first.
+func (follower *PodLogFollower) WaitUntilLogLineMatchesE(t testing.TestingT, pattern string, timeout time.Duration) (LogLine, error) {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return LogLine{}, err
+	}
+	logger.Logf(t, "Wait for a log line matching %s.", pattern)
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	next := 0
+	for {
+		follower.mutex.Lock()
+		lines := follower.lines[next:]
+		next = len(follower.lines)
+		linesChanged := follower.linesChanged
+		closed := follower.closed
+		follower.mutex.Unlock()
+
+		for _, line := range lines {
+			if regex.MatchString(line.Text) {
+				logger.Logf(t, "Found log line matching %s: %s", pattern, line)
+				return line, nil
+			}
+		}
+		if closed {
+			return LogLine{}, NewLogLineNotFoundError(pattern, timeout)
+		}
+
+		select {
+		case <-linesChanged:
+		case <-deadline.C:
+			logger.Logf(t, "Timed out waiting for a log line matching %s", pattern)
+			return LogLine{}, NewLogLineNotFoundError(pattern, timeout)
+		}
+	}
+}
+
+// Close stops following the logs, and waits for the streams of logs to be closed.
+func (follower *PodLogFollower) Close() {
+	follower.cancel()
+	follower.wg.Wait()
+
+	follower.mutex.Lock()
+	defer follower.mutex.Unlock()
+	if !follower.closed {
+		follower.closed = true
+		close(follower.linesChanged)
+	}
+}
+
+// followNewContainersE starts following the logs of the containers of the matching pods that have started since the
+// last call, including the new instances of restarted containers.
+func (follower *PodLogFollower) followNewContainersE(t testing.TestingT, clientset *kubernetes.Clientset) error {
+	pods, err := clientset.CoreV1().Pods(follower.options.Namespace).List(follower.ctx, follower.filters)
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range pods.Items {
+		containerStatuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range containerStatuses {
+			// A container that has not started yet has no ID, and no logs
+			if status.ContainerID == "" {
+				continue
+			}
+			resumeFrom, start := follower.startFollowing(status.ContainerID)
+			if !start {
+				continue
+			}
+			logger.Logf(t, "Following the logs of container %s of pod %s", status.Name, pod.Name)
+			follower.wg.Add(1)
+			go func(containerID string, podName string, containerName string) {
+				defer follower.wg.Done()
+				position, err := follower.streamContainerLogsE(clientset, podName, containerName, resumeFrom)
+				if err != nil && follower.ctx.Err() == nil {
+					logger.Logf(t, "Error following the logs of container %s of pod %s, will retry: %s", containerName, podName, err)
+				}
+				follower.stopFollowing(containerID, position, err != nil)
+			}(status.ContainerID, pod.Name, status.Name)
+		}
+	}
+	return nil
+}
+
+// startFollowing records that the container instance with the given ID is followed, and returns where to resume its
+// logs from, or false if it is followed already.
+func (follower *PodLogFollower) startFollowing(containerID string) (containerLogPosition, bool) {
+	follower.mutex.Lock()
+	defer follower.mutex.Unlock()
+	container, exists := follower.followedContainers[containerID]
+	if !exists {
+		container = &followedContainer{}
+		follower.followedContainers[containerID] = container
+	} else if container.following {
+		return containerLogPosition{}, false
+	}
+	container.following = true
+	return container.position, true
+}
+
+// stopFollowing records where the stream of the container instance with the given ID stopped, and if it failed, that
+// it should be followed again.
+func (follower *PodLogFollower) stopFollowing(containerID string, position containerLogPosition, failed bool) {
+	follower.mutex.Lock()
+	defer follower.mutex.Unlock()
+	container := follower.followedContainers[containerID]
+	container.position = position
+	container.following = !failed
+}
+
+// streamContainerLogsE streams the logs of the current instance of the given container until the container terminates
+// or the follower is closed, from its start or, if the stream is reopened, from the given position, skipping the lines
+// that were already received. It returns the position of the last line received.
+func (follower *PodLogFollower) streamContainerLogsE(clientset *kubernetes.Clientset, podName string, containerName string, resumeFrom containerLogPosition) (containerLogPosition, error) {
+	logOptions := &corev1.PodLogOptions{
+		Container:  containerName,
+		Follow:     true,
+		Timestamps: true,
+	}
+	if !resumeFrom.since.IsZero() {
+		// The API only supports a precision of a second, so lines from before the position are skipped below
+		since := metav1.NewTime(resumeFrom.since)
+		logOptions.SinceTime = &since
+	}
+	stream, err := clientset.CoreV1().Pods(follower.options.Namespace).GetLogs(podName, logOptions).Stream(follower.ctx)
+	if err != nil {
+		return resumeFrom, err
+	}
+	defer stream.Close()
+
+	filter := newResumedLogFilter(resumeFrom)
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		timestamp, text, hasTimestamp := splitLogTimestamp(scanner.Text())
+		if hasTimestamp && !filter.isNew(timestamp) {
+			continue
+		}
+		follower.appendLine(LogLine{Pod: podName, Container: containerName, Text: text})
+	}
+	return filter.position, scanner.Err()
+}
+
+// splitLogTimestamp splits a line of logs requested with timestamps into its timestamp and its text.
+func splitLogTimestamp(line string) (time.Time, string, bool) {
+	i := strings.Index(line, " ")
+	if i < 0 {
+		return time.Time{}, line, false
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, line[:i])
+	if err != nil {
+		return time.Time{}, line, false
+	}
+	return timestamp, line[i+1:], true
+}
+
+// resumedLogFilter drops the lines a reopened stream of logs repeats, and tracks the position of the last line.
+type resumedLogFilter struct {
+	resumeFrom containerLogPosition
+	skipped    int
+	position   containerLogPosition
+}
+
+func newResumedLogFilter(resumeFrom containerLogPosition) *resumedLogFilter {
+	return &resumedLogFilter{resumeFrom: resumeFrom, position: resumeFrom}
+}
+
+// isNew returns true if the line with the given timestamp was not received before the stream was reopened.
+func (filter *resumedLogFilter) isNew(timestamp time.Time) bool {
+	if timestamp.Before(filter.resumeFrom.since) {
+		return false
+	}
+	if timestamp.Equal(filter.resumeFrom.since) && filter.skipped < filter.resumeFrom.linesAtSince {
+		filter.skipped++
+		return false
+	}
+	if timestamp.Equal(filter.position.since) {
+		filter.position.linesAtSince++
+	} else {
+		filter.position = containerLogPosition{since: timestamp, linesAtSince: 1}
+	}
+	return true
+}
+
+// appendLine records the given line, and wakes up the functions waiting for lines.
+func (follower *PodLogFollower) appendLine(line LogLine) {
+	follower.mutex.Lock()
+	defer follower.mutex.Unlock()
+	if follower.closed {
+		return
+	}
+	follower.lines = append(follower.lines, line)
+	close(follower.linesChanged)
+	follower.linesChanged = make(chan struct{})
+}
@@ -0,0 +1,82 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/random"
+)
+
+func TestFollowPodLogsWaitsUntilLogLineMatches(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(EXAMPLE_LOGGING_POD_YAML_TEMPLATE, uniqueID, uniqueID, "echo starting; sleep 5; echo ready; sleep 3600")
+	defer KubectlDeleteFromString(t, options, configData)
+
+	// Start following before the pod exists, to check that new pods are picked up
+	follower := FollowPodLogs(t, options, metav1.ListOptions{LabelSelector: "app=logger"})
+	defer follower.Close()
+	KubectlApplyFromString(t, options, configData)
+
+	line := follower.WaitUntilLogLineMatches(t, "^ready$", 2*time.Minute)
+	assert.Equal(t, LogLine{Pod: "logger-pod", Container: "logger", Text: "ready"}, line)
+	assert.Equal(t, "starting", follower.Lines()[0].Text)
+}
+
+func TestFollowPodLogsFollowsRestartedContainers(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	// Count the runs of the container in a volume that outlives its restarts
+	command := "n=$(cat /data/runs 2>/dev/null || echo 0); n=$((n+1)); echo $n > /data/runs; echo run $n; sleep 2; exit 1"
+	configData := fmt.Sprintf(EXAMPLE_LOGGING_POD_YAML_TEMPLATE, uniqueID, uniqueID, command)
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+
+	follower := FollowPodLogs(t, options, metav1.ListOptions{LabelSelector: "app=logger"})
+	defer follower.Close()
+	follower.WaitUntilLogLineMatches(t, "^run 2$", 2*time.Minute)
+}
+
+const EXAMPLE_LOGGING_POD_YAML_TEMPLATE = `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: %s
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: logger-pod
+  namespace: %s
+  labels:
+    app: logger
+spec:
+  containers:
+  - name: logger
+    image: busybox:1.36
+    command: ["sh", "-c", "%s"]
+    volumeMounts:
+    - name: data
+      mountPath: /data
+  volumes:
+  - name: data
+    emptyDir: {}
+`
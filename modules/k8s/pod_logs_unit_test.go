@@ -0,0 +1,73 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSplitLogTimestamp(t *testing.T) {
+	t.Parallel()
+
+	timestamp, text, hasTimestamp := splitLogTimestamp("2023-05-04T10:11:12.123456789Z listening on :8080")
+	assert.True(t, hasTimestamp)
+	assert.Equal(t, time.Date(2023, 5, 4, 10, 11, 12, 123456789, time.UTC), timestamp)
+	assert.Equal(t, "listening on :8080", text)
+
+	_, text, hasTimestamp = splitLogTimestamp("listening on :8080")
+	assert.False(t, hasTimestamp)
+	assert.Equal(t, "listening on :8080", text)
+}
+
+func TestResumedLogFilterSkipsRepeatedLines(t *testing.T) {
+	t.Parallel()
+
+	first := time.Date(2023, 5, 4, 10, 11, 12, 0, time.UTC)
+	second := first.Add(time.Millisecond)
+	third := second.Add(time.Millisecond)
+
+	// The first stream receives a line at the first time, and two at the second time, then breaks
+	filter := newResumedLogFilter(containerLogPosition{})
+	assert.True(t, filter.isNew(first))
+	assert.True(t, filter.isNew(second))
+	assert.True(t, filter.isNew(second))
+	assert.Equal(t, containerLogPosition{since: second, linesAtSince: 2}, filter.position)
+
+	// The reopened stream repeats the lines from the start of the second, and has a new line at the second time
+	filter = newResumedLogFilter(filter.position)
+	assert.False(t, filter.isNew(first))
+	assert.False(t, filter.isNew(second))
+	assert.False(t, filter.isNew(second))
+	assert.True(t, filter.isNew(second))
+	assert.True(t, filter.isNew(third))
+	assert.Equal(t, containerLogPosition{since: third, linesAtSince: 1}, filter.position)
+}
+
+func TestWaitUntilLogLineMatchesE(t *testing.T) {
+	t.Parallel()
+
+	follower := newPodLogFollower(NewKubectlOptions("", "", "default"), metav1.ListOptions{})
+	follower.appendLine(LogLine{Pod: "app-1", Container: "app", Text: "starting"})
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		follower.appendLine(LogLine{Pod: "app-2", Container: "app", Text: "listening on :8080"})
+	}()
+
+	line, err := follower.WaitUntilLogLineMatchesE(t, `listening on :\d+`, 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "app-2", line.Pod)
+
+	_, err = follower.WaitUntilLogLineMatchesE(t, "stopping", 100*time.Millisecond)
+	require.Error(t, err)
+	assert.IsType(t, LogLineNotFound{}, err)
+
+	follower.Close()
+	_, err = follower.WaitUntilLogLineMatchesE(t, "stopping", 10*time.Second)
+	require.Error(t, err)
+	line, err = follower.WaitUntilLogLineMatchesE(t, "starting", 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "app-1", line.Pod)
+}
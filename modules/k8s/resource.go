@@ -0,0 +1,199 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/retry"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// CustomResourceDefinitionResource is the resource of CustomResourceDefinitions, to use with the functions of this file.
+var CustomResourceDefinitionResource = schema.GroupVersionResource{
+	Group:    "apiextensions.k8s.io",
+	Version:  "v1",
+	Resource: "customresourcedefinitions",
+}
+
+// ListResources will look for resources of the given group, version and resource (e.g. the "certificates" of
+// "cert-manager.io/v1") that match the given filters and return them. Resources are looked up in the namespace of the
+// options, or at the cluster scope if the namespace is empty. This will fail the test if there is an error.
+func ListResources(t testing.TestingT, options *KubectlOptions, resource schema.GroupVersionResource, filters metav1.ListOptions) []unstructured.Unstructured {
+	resources, err := ListResourcesE(t, options, resource, filters)
+	require.NoError(t, err)
+	return resources
+}
+
+// ListResourcesE will look for resources of the given group, version and resource (e.g. the "certificates" of
+// "cert-manager.io/v1") that match the given filters and return them. Resources are looked up in the namespace of the
+// options, or at the cluster scope if the namespace is empty.
+func ListResourcesE(t testing.TestingT, options *KubectlOptions, resource schema.GroupVersionResource, filters metav1.ListOptions) ([]unstructured.Unstructured, error) {
+	client, err := getResourceClientE(t, options, resource)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.List(context.Background(), filters)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// GetResource returns the resource of the given group, version and resource (e.g. the "certificates" of
+// "cert-manager.io/v1") with the given name. The resource is looked up in the namespace of the options, or at the
+// cluster scope if the namespace is empty. This will fail the test if there is an error.
+func GetResource(t testing.TestingT, options *KubectlOptions, resource schema.GroupVersionResource, name string) *unstructured.Unstructured {
+	obj, err := GetResourceE(t, options, resource, name)
+	require.NoError(t, err)
+	return obj
+}
+
+// GetResourceE returns the resource of the given group, version and resource (e.g. the "certificates" of
+// "cert-manager.io/v1") with the given name. The resource is looked up in the namespace of the options, or at the
+// cluster scope if the namespace is empty.
+func GetResourceE(t testing.TestingT, options *KubectlOptions, resource schema.GroupVersionResource, name string) (*unstructured.Unstructured, error) {
+	client, err := getResourceClientE(t, options, resource)
+	if err != nil {
+		return nil, err
+	}
+	return client.Get(context.Background(), name, metav1.GetOptions{})
+}
+
+// WaitUntilResourceCondition waits until the resource of the given group, version and resource with the given name has
+// a status condition of the given type with the given status (e.g. "Ready" and "True"), retrying the check for the
+// specified amount of times, sleeping for the provided duration between each try. This will fail the test if there is
+// an error or if the check times out.
+func WaitUntilResourceCondition(
+	t testing.TestingT,
+	options *KubectlOptions,
+	resource schema.GroupVersionResource,
+	name string,
+	conditionType string,
+	conditionStatus string,
+	retries int,
+	sleepBetweenRetries time.Duration,
+) *unstructured.Unstructured {
+	obj, err := WaitUntilResourceConditionE(t, options, resource, name, conditionType, conditionStatus, retries, sleepBetweenRetries)
+	require.NoError(t, err)
+	return obj
+}
+
+// WaitUntilResourceConditionE waits until the resource of the given group, version and resource with the given name
+// has a status condition of the given type with the given status (e.g. "Ready" and "True"), retrying the check for the
+// specified amount of times, sleeping for the provided duration between each try.
+func WaitUntilResourceConditionE(
+	t testing.TestingT,
+	options *KubectlOptions,
+	resource schema.GroupVersionResource,
+	name string,
+	conditionType string,
+	conditionStatus string,
+	retries int,
+	sleepBetweenRetries time.Duration,
+) (*unstructured.Unstructured, error) {
+	start := time.Now()
+	statusMsg := fmt.Sprintf("Wait for %s %s to have condition %s=%s.", resource.Resource, name, conditionType, conditionStatus)
+	var obj *unstructured.Unstructured
+	message, err := retry.DoWithPolicyE(
+		t,
+		statusMsg,
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries),
+		func() (string, error) {
+			var err error
+			obj, err = GetResourceE(t, options, resource, name)
+			if err != nil {
+				return "", err
+			}
+			if !HasResourceCondition(obj, conditionType, conditionStatus) {
+				return "", NewResourceConditionNotMetError(obj, conditionType, conditionStatus)
+			}
+			return fmt.Sprintf("%s %s has condition %s=%s", resource.Resource, name, conditionType, conditionStatus), nil
+		},
+	)
+	if err != nil {
+		waitLogger(resource.Resource+"/"+name, start).Logf(t, "Timed out waiting for %s %s to have condition %s=%s: %s", resource.Resource, name, conditionType, conditionStatus, err)
+		return nil, err
+	}
+	waitLogger(resource.Resource+"/"+name, start).Logf(t, message)
+	return obj, nil
+}
+
+// HasResourceCondition returns true if the given resource has a status condition of the given type with the given
+// status, following the conventions of the Kubernetes API for conditions in status.conditions.
+func HasResourceCondition(obj *unstructured.Unstructured, conditionType string, conditionStatus string) bool {
+	condition, found := GetResourceCondition(obj, conditionType)
+	return found && condition.Status == metav1.ConditionStatus(conditionStatus)
+}
+
+// GetResourceCondition returns the status condition of the given type of the given resource, and whether the resource
+// has such a condition. Conditions are read from status.conditions, following the conventions of the Kubernetes API.
+func GetResourceCondition(obj *unstructured.Unstructured, conditionType string) (metav1.Condition, bool) {
+	conditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return metav1.Condition{}, false
+	}
+	for _, rawCondition := range conditions {
+		condition, ok := rawCondition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if typ, _, _ := unstructured.NestedString(condition, "type"); typ != conditionType {
+			continue
+		}
+		status, _, _ := unstructured.NestedString(condition, "status")
+		reason, _, _ := unstructured.NestedString(condition, "reason")
+		message, _, _ := unstructured.NestedString(condition, "message")
+		return metav1.Condition{
+			Type:    conditionType,
+			Status:  metav1.ConditionStatus(status),
+			Reason:  reason,
+			Message: message,
+		}, true
+	}
+	return metav1.Condition{}, false
+}
+
+// WaitUntilCustomResourceDefinitionEstablished waits until the CustomResourceDefinition with the given name (e.g.
+// "certificates.cert-manager.io") is established, i.e. its custom resources can be created, retrying the check for the
+// specified amount of times, sleeping for the provided duration between each try. This will fail the test if there is
+// an error or if the check times out.
+func WaitUntilCustomResourceDefinitionEstablished(t testing.TestingT, options *KubectlOptions, crdName string, retries int, sleepBetweenRetries time.Duration) {
+	require.NoError(t, WaitUntilCustomResourceDefinitionEstablishedE(t, options, crdName, retries, sleepBetweenRetries))
+}
+
+// WaitUntilCustomResourceDefinitionEstablishedE waits until the CustomResourceDefinition with the given name (e.g.
+// "certificates.cert-manager.io") is established, i.e. its custom resources can be created, retrying the check for the
+// specified amount of times, sleeping for the provided duration between each try.
+func WaitUntilCustomResourceDefinitionEstablishedE(t testing.TestingT, options *KubectlOptions, crdName string, retries int, sleepBetweenRetries time.Duration) error {
+	// CustomResourceDefinitions are cluster scoped, whatever the namespace of the options
+	clusterOptions := *options
+	clusterOptions.Namespace = ""
+	_, err := WaitUntilResourceConditionE(t, &clusterOptions, CustomResourceDefinitionResource, crdName, "Established", string(metav1.ConditionTrue), retries, sleepBetweenRetries)
+	return err
+}
+
+// IsCustomResourceDefinitionEstablished returns true if the given CustomResourceDefinition is established, i.e. its
+// custom resources can be created.
+func IsCustomResourceDefinitionEstablished(crd *unstructured.Unstructured) bool {
+	return HasResourceCondition(crd, "Established", string(metav1.ConditionTrue))
+}
+
+// getResourceClientE returns a dynamic client for the given resource, in the namespace of the options, or at the
+// cluster scope if the namespace is empty.
+func getResourceClientE(t testing.TestingT, options *KubectlOptions, resource schema.GroupVersionResource) (dynamic.ResourceInterface, error) {
+	client, err := GetDynamicClientFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	if options.Namespace == "" {
+		return client.Resource(resource), nil
+	}
+	return client.Resource(resource).Namespace(options.Namespace), nil
+}
@@ -0,0 +1,136 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/random"
+)
+
+func TestGetAndListCustomResources(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	group := fmt.Sprintf("%s.terratest.io", uniqueID)
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(EXAMPLE_CRD_YAML_TEMPLATE, uniqueID, group, group)
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+
+	crdName := fmt.Sprintf("widgets.%s", group)
+	WaitUntilCustomResourceDefinitionEstablished(t, options, crdName, 30, 1*time.Second)
+	clusterOptions := NewKubectlOptions("", "", "")
+	require.True(t, IsCustomResourceDefinitionEstablished(GetResource(t, clusterOptions, CustomResourceDefinitionResource, crdName)))
+
+	widgetData := fmt.Sprintf(EXAMPLE_WIDGET_YAML_TEMPLATE, group, uniqueID)
+	KubectlApplyFromString(t, options, widgetData)
+
+	widgets := schema.GroupVersionResource{Group: group, Version: "v1", Resource: "widgets"}
+	widget := GetResource(t, options, widgets, "my-widget")
+	size, _, err := unstructured.NestedString(widget.Object, "spec", "size")
+	require.NoError(t, err)
+	require.Equal(t, "large", size)
+
+	list := ListResources(t, options, widgets, metav1.ListOptions{})
+	require.Equal(t, len(list), 1)
+	require.Equal(t, list[0].GetName(), "my-widget")
+}
+
+func TestWaitUntilResourceCondition(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(EXAMPLE_ROLLOUT_DEPLOYMENT_YAML_TEMPLATE, uniqueID, uniqueID, "nginx:1.23")
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	deployment := WaitUntilResourceCondition(t, options, deployments, "nginx-deployment", "Available", "True", 60, 1*time.Second)
+	require.Equal(t, deployment.GetName(), "nginx-deployment")
+}
+
+func TestGetResourceCondition(t *testing.T) {
+	t.Parallel()
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Issuing", "status": "False"},
+				map[string]interface{}{"type": "Ready", "status": "False", "reason": "Pending", "message": "Issuing certificate"},
+			},
+		},
+	}}
+
+	condition, found := GetResourceCondition(obj, "Ready")
+	require.True(t, found)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "Pending", condition.Reason)
+	assert.Equal(t, "Issuing certificate", condition.Message)
+	assert.False(t, HasResourceCondition(obj, "Ready", "True"))
+	assert.True(t, HasResourceCondition(obj, "Issuing", "False"))
+
+	_, found = GetResourceCondition(obj, "Established")
+	assert.False(t, found)
+	_, found = GetResourceCondition(&unstructured.Unstructured{Object: map[string]interface{}{}}, "Ready")
+	assert.False(t, found)
+}
+
+const EXAMPLE_CRD_YAML_TEMPLATE = `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: %s
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.%s
+spec:
+  group: %s
+  scope: Namespaced
+  names:
+    plural: widgets
+    singular: widget
+    kind: Widget
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              size:
+                type: string
+`
+
+const EXAMPLE_WIDGET_YAML_TEMPLATE = `---
+apiVersion: %s/v1
+kind: Widget
+metadata:
+  name: my-widget
+  namespace: %s
+spec:
+  size: large
+`
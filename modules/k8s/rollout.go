@@ -0,0 +1,166 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/logger"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+const (
+	// The maximum number of pods that are not ready, and of events of each, to describe in a RolloutNotComplete error
+	maxFailingPodsInRolloutError = 5
+	maxEventsPerFailingPod       = 5
+)
+
+// FailingPod describes why a pod of a workload is not ready.
+type FailingPod struct {
+	Name  string
+	Phase corev1.PodPhase
+	// The conditions of the pod that are not true, e.g. "Ready=False (ContainersNotReady): containers with unready
+	// status: [app]"
+	Conditions []string
+	// The containers that are not ready, e.g. "app: waiting (CrashLoopBackOff): back-off 5m0s restarting failed
+	// container"
+	Containers []string
+	// The most recent events of the pod, e.g. "Warning BackOff: Back-off restarting failed container"
+	Events []string
+}
+
+func (pod FailingPod) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Pod %s (%s)", pod.Name, pod.Phase)
+	for _, condition := range pod.Conditions {
+		fmt.Fprintf(&sb, "\n\tcondition %s", condition)
+	}
+	for _, container := range pod.Containers {
+		fmt.Fprintf(&sb, "\n\tcontainer %s", container)
+	}
+	for _, event := range pod.Events {
+		fmt.Fprintf(&sb, "\n\tevent %s", event)
+	}
+	return sb.String()
+}
+
+// newRolloutNotCompleteError returns a RolloutNotComplete error for the given workload, describing the pods matching
+// its selector that are not ready. Errors while describing the pods are logged, not returned, so as not to hide the
+// original error.
+func newRolloutNotCompleteError(t testing.TestingT, options *KubectlOptions, kind string, name string, selector *metav1.LabelSelector, err error) RolloutNotComplete {
+	failingPods, describeErr := getFailingPodsE(t, options, selector)
+	if describeErr != nil {
+		logger.Logf(t, "Error describing the pods of %s %s that are not ready: %s", kind, name, describeErr)
+	}
+	return RolloutNotComplete{Kind: kind, Name: name, Underlying: err, FailingPods: failingPods}
+}
+
+// getFailingPodsE returns a description of the pods matching the given selector that are not available.
+func getFailingPodsE(t testing.TestingT, options *KubectlOptions, selector *metav1.LabelSelector) ([]FailingPod, error) {
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := ListPodsE(t, options, metav1.ListOptions{LabelSelector: labelSelector.String()})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	failingPods := []FailingPod{}
+	for i := range pods {
+		pod := &pods[i]
+		if IsPodAvailable(pod) {
+			continue
+		}
+		if len(failingPods) == maxFailingPodsInRolloutError {
+			break
+		}
+
+		failingPod := describeFailingPod(pod)
+		events, err := clientset.CoreV1().Events(pod.Namespace).List(context.Background(), metav1.ListOptions{
+			FieldSelector: fmt.Sprintf("involvedObject.kind=Pod,involvedObject.name=%s", pod.Name),
+		})
+		if err != nil {
+			return nil, err
+		}
+		failingPod.Events = formatPodEvents(events.Items)
+		failingPods = append(failingPods, failingPod)
+	}
+	return failingPods, nil
+}
+
+// describeFailingPod describes the conditions and containers of the given pod that are not ready.
+func describeFailingPod(pod *corev1.Pod) FailingPod {
+	failingPod := FailingPod{Name: pod.Name, Phase: pod.Status.Phase}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Status == corev1.ConditionTrue {
+			continue
+		}
+		failingPod.Conditions = append(failingPod.Conditions, formatReason(fmt.Sprintf("%s=%s", condition.Type, condition.Status), condition.Reason, condition.Message))
+	}
+
+	containerStatuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range containerStatuses {
+		if status.Ready {
+			continue
+		}
+		if status.State.Waiting != nil || status.State.Terminated != nil {
+			failingPod.Containers = append(failingPod.Containers, fmt.Sprintf("%s: %s", status.Name, formatContainerState(status.State)))
+		} else {
+			failingPod.Containers = append(failingPod.Containers, fmt.Sprintf("%s: running, but not ready (restarted %d times)", status.Name, status.RestartCount))
+		}
+	}
+	return failingPod
+}
+
+// formatPodEvents formats the most recent of the given events, oldest first.
+func formatPodEvents(events []corev1.Event) []string {
+	sort.SliceStable(events, func(i, j int) bool { return eventTime(events[i]).Before(eventTime(events[j])) })
+	if len(events) > maxEventsPerFailingPod {
+		events = events[len(events)-maxEventsPerFailingPod:]
+	}
+
+	formatted := []string{}
+	for _, event := range events {
+		message := fmt.Sprintf("%s %s: %s", event.Type, event.Reason, event.Message)
+		if event.Count > 1 {
+			message = fmt.Sprintf("%s (x%d)", message, event.Count)
+		}
+		formatted = append(formatted, message)
+	}
+	return formatted
+}
+
+// eventTime returns the time the given event last occurred.
+func eventTime(event corev1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// formatReason formats a status with its optional reason and message, e.g. "Ready=False (ContainersNotReady): containers
+// with unready status: [app]".
+func formatReason(status string, reason string, message string) string {
+	if reason != "" {
+		status = fmt.Sprintf("%s (%s)", status, reason)
+	}
+	if message != "" {
+		status = fmt.Sprintf("%s: %s", status, message)
+	}
+	return status
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/tnn-gruntwork-io/terratest/modules/logger"
 	"github.com/tnn-gruntwork-io/terratest/modules/retry"
 	"github.com/tnn-gruntwork-io/terratest/modules/testing"
 	"github.com/stretchr/testify/require"
@@ -34,12 +33,12 @@ func GetSecretE(t testing.TestingT, options *KubectlOptions, secretName string)
 // WaitUntilSecretAvailable waits until the secret is present on the cluster in cases where it is not immediately
 // available (for example, when using ClusterIssuer to request a certificate).
 func WaitUntilSecretAvailable(t testing.TestingT, options *KubectlOptions, secretName string, retries int, sleepBetweenRetries time.Duration) {
+	start := time.Now()
 	statusMsg := fmt.Sprintf("Wait for secret %s to be provisioned.", secretName)
-	message := retry.DoWithRetry(
+	message := retry.DoWithPolicy(
 		t,
 		statusMsg,
-		retries,
-		sleepBetweenRetries,
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries),
 		func() (string, error) {
 			_, err := GetSecretE(t, options, secretName)
 			if err != nil {
@@ -49,5 +48,5 @@ func WaitUntilSecretAvailable(t testing.TestingT, options *KubectlOptions, secre
 			return "Secret is now available", nil
 		},
 	)
-	logger.Logf(t, message)
+	waitLogger("secret/"+secretName, start).Logf(t, message)
 }
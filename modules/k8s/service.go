@@ -12,7 +12,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/tnn-gruntwork-io/terratest/modules/aws"
-	"github.com/tnn-gruntwork-io/terratest/modules/logger"
 	"github.com/tnn-gruntwork-io/terratest/modules/random"
 	"github.com/tnn-gruntwork-io/terratest/modules/retry"
 	"github.com/tnn-gruntwork-io/terratest/modules/testing"
@@ -58,12 +57,12 @@ func GetServiceE(t testing.TestingT, options *KubectlOptions, serviceName string
 
 // WaitUntilServiceAvailable waits until the service endpoint is ready to accept traffic.
 func WaitUntilServiceAvailable(t testing.TestingT, options *KubectlOptions, serviceName string, retries int, sleepBetweenRetries time.Duration) {
+	start := time.Now()
 	statusMsg := fmt.Sprintf("Wait for service %s to be provisioned.", serviceName)
-	message := retry.DoWithRetry(
+	message := retry.DoWithPolicy(
 		t,
 		statusMsg,
-		retries,
-		sleepBetweenRetries,
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries),
 		func() (string, error) {
 			service, err := GetServiceE(t, options, serviceName)
 			if err != nil {
@@ -83,7 +82,7 @@ func WaitUntilServiceAvailable(t testing.TestingT, options *KubectlOptions, serv
 			return "Service is now available", nil
 		},
 	)
-	logger.Logf(t, message)
+	waitLogger("service/"+serviceName, start).Logf(t, message)
 }
 
 // IsServiceAvailable returns true if the service endpoint is ready to accept traffic. Note that for Minikube, this
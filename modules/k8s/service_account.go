@@ -71,11 +71,10 @@ func GetServiceAccountAuthToken(t testing.TestingT, kubectlOptions *KubectlOptio
 // authenticate requests as that ServiceAccount.
 func GetServiceAccountAuthTokenE(t testing.TestingT, kubectlOptions *KubectlOptions, serviceAccountName string) (string, error) {
 	// Wait for the TokenController to provision a ServiceAccount token
-	msg, err := retry.DoWithRetryE(
+	msg, err := retry.DoWithPolicyE(
 		t,
 		"Waiting for ServiceAccount Token to be provisioned",
-		30,
-		10*time.Second,
+		retry.PolicyOrFixed(kubectlOptions.RetryPolicy, 30, 10*time.Second),
 		func() (string, error) {
 			logger.Logf(t, "Checking if service account has secret")
 			serviceAccount := GetServiceAccount(t, kubectlOptions, serviceAccountName)
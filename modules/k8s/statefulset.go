@@ -0,0 +1,155 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/retry"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// ListStatefulSets will look for statefulsets in the given namespace that match the given filters and return them.
+// This will fail the test if there is an error.
+func ListStatefulSets(t testing.TestingT, options *KubectlOptions, filters metav1.ListOptions) []appsv1.StatefulSet {
+	statefulSets, err := ListStatefulSetsE(t, options, filters)
+	require.NoError(t, err)
+	return statefulSets
+}
+
+// ListStatefulSetsE will look for statefulsets in the given namespace that match the given filters and return them.
+func ListStatefulSetsE(t testing.TestingT, options *KubectlOptions, filters metav1.ListOptions) ([]appsv1.StatefulSet, error) {
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := clientset.AppsV1().StatefulSets(options.Namespace).List(context.Background(), filters)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// GetStatefulSet returns a Kubernetes statefulset resource in the provided namespace with the given name. This will
+// fail the test if there is an error.
+func GetStatefulSet(t testing.TestingT, options *KubectlOptions, statefulSetName string) *appsv1.StatefulSet {
+	statefulSet, err := GetStatefulSetE(t, options, statefulSetName)
+	require.NoError(t, err)
+	return statefulSet
+}
+
+// GetStatefulSetE returns a Kubernetes statefulset resource in the provided namespace with the given name.
+func GetStatefulSetE(t testing.TestingT, options *KubectlOptions, statefulSetName string) (*appsv1.StatefulSet, error) {
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	return clientset.AppsV1().StatefulSets(options.Namespace).Get(context.Background(), statefulSetName, metav1.GetOptions{})
+}
+
+// WaitUntilStatefulSetAvailable waits until the rollout of the given statefulset is complete and the pod of each
+// ordinal is ready, retrying the check for the specified amount of times, sleeping for the provided duration between
+// each try. This will fail the test if there is an error or if the check times out, in which case the error includes
+// the conditions and events of the pods of the statefulset that are not ready.
+func WaitUntilStatefulSetAvailable(t testing.TestingT, options *KubectlOptions, statefulSetName string, retries int, sleepBetweenRetries time.Duration) {
+	require.NoError(t, WaitUntilStatefulSetAvailableE(t, options, statefulSetName, retries, sleepBetweenRetries))
+}
+
+// WaitUntilStatefulSetAvailableE waits until the rollout of the given statefulset is complete and the pod of each
+// ordinal is ready, retrying the check for the specified amount of times, sleeping for the provided duration between
+// each try. If the check times out, the error includes the conditions and events of the pods of the statefulset that
+// are not ready.
+func WaitUntilStatefulSetAvailableE(t testing.TestingT, options *KubectlOptions, statefulSetName string, retries int, sleepBetweenRetries time.Duration) error {
+	start := time.Now()
+	statusMsg := fmt.Sprintf("Wait for statefulset %s to be provisioned.", statefulSetName)
+	var statefulSet *appsv1.StatefulSet
+	message, err := retry.DoWithPolicyE(
+		t,
+		statusMsg,
+		retry.PolicyOrFixed(options.RetryPolicy, retries, sleepBetweenRetries),
+		func() (string, error) {
+			var err error
+			statefulSet, err = GetStatefulSetE(t, options, statefulSetName)
+			if err != nil {
+				return "", err
+			}
+			if !IsStatefulSetAvailable(statefulSet) {
+				return "", NewStatefulSetNotAvailableError(statefulSet)
+			}
+			// The status only counts the ready replicas, so also check that it is the pods with the expected ordinals
+			// that are ready, as clients of a statefulset typically address its pods by name
+			for ordinal := 0; ordinal < int(statefulSetReplicas(statefulSet)); ordinal++ {
+				podName := fmt.Sprintf("%s-%d", statefulSet.Name, ordinal)
+				pod, err := GetPodE(t, options, podName)
+				if err != nil {
+					return "", err
+				}
+				if !IsPodAvailable(pod) {
+					return "", NewPodNotAvailableError(pod)
+				}
+			}
+			return "StatefulSet is now available", nil
+		},
+	)
+	if err != nil {
+		waitLogger("statefulset/"+statefulSetName, start).Logf(t, "Timed out waiting for StatefulSet to be provisioned: %s", err)
+		if statefulSet == nil {
+			return err
+		}
+		return newRolloutNotCompleteError(t, options, "StatefulSet", statefulSetName, statefulSet.Spec.Selector, err)
+	}
+	waitLogger("statefulset/"+statefulSetName, start).Logf(t, message)
+	return nil
+}
+
+// IsStatefulSetAvailable returns true if the rollout of the given statefulset is complete, i.e. the controller has
+// observed the latest spec, all the replicas are ready, and all the replicas that should be updated (those with an
+// ordinal of at least the partition of a RollingUpdate) have been updated. This is the same check as `kubectl rollout
+// status`.
+func IsStatefulSetAvailable(statefulSet *appsv1.StatefulSet) bool {
+	_, complete := statefulSetRolloutStatus(statefulSet)
+	return complete
+}
+
+// statefulSetRolloutStatus returns whether the rollout of the given statefulset is complete, and if not, why.
+func statefulSetRolloutStatus(statefulSet *appsv1.StatefulSet) (string, bool) {
+	status := statefulSet.Status
+	if statefulSet.Generation > status.ObservedGeneration {
+		return "waiting for the statefulset spec update to be observed", false
+	}
+
+	replicas := statefulSetReplicas(statefulSet)
+	if status.ReadyReplicas < replicas {
+		return fmt.Sprintf("%d of %d pods are ready", status.ReadyReplicas, replicas), false
+	}
+
+	// Only the RollingUpdate strategy rolls out a new revision by itself
+	strategy := statefulSet.Spec.UpdateStrategy
+	if strategy.Type != "" && strategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+		return "", true
+	}
+	if strategy.RollingUpdate != nil && strategy.RollingUpdate.Partition != nil && *strategy.RollingUpdate.Partition > 0 {
+		// Only the pods with an ordinal of at least the partition are updated
+		expectedUpdated := replicas - *strategy.RollingUpdate.Partition
+		if status.UpdatedReplicas < expectedUpdated {
+			return fmt.Sprintf("%d of %d pods in the partition have been updated", status.UpdatedReplicas, expectedUpdated), false
+		}
+		return "", true
+	}
+	if status.UpdateRevision != status.CurrentRevision {
+		return fmt.Sprintf("%d of %d pods have been updated to revision %s", status.UpdatedReplicas, replicas, status.UpdateRevision), false
+	}
+	return "", true
+}
+
+// statefulSetReplicas returns the desired number of replicas of the given statefulset, which defaults to 1.
+func statefulSetReplicas(statefulSet *appsv1.StatefulSet) int32 {
+	if statefulSet.Spec.Replicas == nil {
+		return 1
+	}
+	return *statefulSet.Spec.Replicas
+}
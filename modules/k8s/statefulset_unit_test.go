@@ -0,0 +1,79 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsStatefulSetAvailable(t *testing.T) {
+	t.Parallel()
+
+	replicas := int32(3)
+	partition := int32(2)
+	testCases := []struct {
+		title          string
+		generation     int64
+		strategy       appsv1.StatefulSetUpdateStrategy
+		status         appsv1.StatefulSetStatus
+		expectedResult bool
+	}{
+		{
+			"spec update not observed",
+			2,
+			appsv1.StatefulSetUpdateStrategy{},
+			appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 3, UpdatedReplicas: 3, CurrentRevision: "v1", UpdateRevision: "v1"},
+			false,
+		},
+		{
+			"replicas not ready",
+			1,
+			appsv1.StatefulSetUpdateStrategy{},
+			appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 2, UpdatedReplicas: 3, CurrentRevision: "v1", UpdateRevision: "v1"},
+			false,
+		},
+		{
+			"rolling update in progress",
+			1,
+			appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType},
+			appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 3, UpdatedReplicas: 1, CurrentRevision: "v1", UpdateRevision: "v2"},
+			false,
+		},
+		{
+			"partitioned rolling update complete",
+			1,
+			appsv1.StatefulSetUpdateStrategy{Type: appsv1.RollingUpdateStatefulSetStrategyType, RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{Partition: &partition}},
+			appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 3, UpdatedReplicas: 1, CurrentRevision: "v1", UpdateRevision: "v2"},
+			true,
+		},
+		{
+			"on delete strategy",
+			1,
+			appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType},
+			appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 3, UpdatedReplicas: 0, CurrentRevision: "v1", UpdateRevision: "v2"},
+			true,
+		},
+		{
+			"rollout complete",
+			1,
+			appsv1.StatefulSetUpdateStrategy{},
+			appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 3, UpdatedReplicas: 3, CurrentRevision: "v2", UpdateRevision: "v2"},
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+			statefulSet := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Generation: tc.generation},
+				Spec:       appsv1.StatefulSetSpec{Replicas: &replicas, UpdateStrategy: tc.strategy},
+				Status:     tc.status,
+			}
+			assert.Equal(t, tc.expectedResult, IsStatefulSetAvailable(statefulSet))
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tnn-gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/require"
+	authv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/logger"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// RBACSubject is the user, with its groups, whose permissions to check with a SubjectAccessReview. Unlike CanIDo, this
+// does not require credentials for the subject: the API server evaluates the RBAC rules for the subject on behalf of
+// the client configured by the kubectl options, which must be allowed to create SubjectAccessReviews.
+type RBACSubject struct {
+	User   string
+	Groups []string
+}
+
+// ServiceAccountSubject returns the subject of the ServiceAccount with the given namespace and name, with the groups
+// Kubernetes puts all service accounts in.
+func ServiceAccountSubject(namespace string, name string) RBACSubject {
+	return RBACSubject{
+		User:   fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name),
+		Groups: []string{"system:serviceaccounts", fmt.Sprintf("system:serviceaccounts:%s", namespace), "system:authenticated"},
+	}
+}
+
+// Permission is an action on a kind of resource, in a namespace.
+type Permission struct {
+	Verb string
+	// The resource, in the notation of kubectl: the plural name of the resource, followed by its API group if it is not
+	// the core group, and by its subresource if any, e.g. "pods", "deployments.apps", "pods/log" or
+	// "deployments.apps/scale"
+	Resource string
+	// The namespace, or an empty string for cluster scoped resources and for the resources of all namespaces
+	Namespace string
+}
+
+func (permission Permission) String() string {
+	if permission.Namespace == "" {
+		return fmt.Sprintf("%s %s at cluster scope", permission.Verb, permission.Resource)
+	}
+	return fmt.Sprintf("%s %s in namespace %s", permission.Verb, permission.Resource, permission.Namespace)
+}
+
+// resourceAttributes returns the attributes of a SubjectAccessReview for the permission.
+func (permission Permission) resourceAttributes() authv1.ResourceAttributes {
+	resource, subresource := permission.Resource, ""
+	if i := strings.Index(resource, "/"); i >= 0 {
+		resource, subresource = resource[:i], resource[i+1:]
+	}
+	group := ""
+	if i := strings.Index(resource, "."); i >= 0 {
+		resource, group = resource[:i], resource[i+1:]
+	}
+	return authv1.ResourceAttributes{
+		Namespace:   permission.Namespace,
+		Verb:        permission.Verb,
+		Group:       group,
+		Resource:    resource,
+		Subresource: subresource,
+	}
+}
+
+// PermissionMatrix is the permissions of every verb on every resource in every namespace. See Permission for the
+// notation of resources and namespaces.
+type PermissionMatrix struct {
+	Verbs      []string
+	Resources  []string
+	Namespaces []string
+}
+
+// Permissions returns all the permissions of the matrix, by resource, then verb, then namespace.
+func (matrix PermissionMatrix) Permissions() []Permission {
+	permissions := []Permission{}
+	for _, resource := range matrix.Resources {
+		for _, verb := range matrix.Verbs {
+			for _, namespace := range matrix.Namespaces {
+				permissions = append(permissions, Permission{Verb: verb, Resource: resource, Namespace: namespace})
+			}
+		}
+	}
+	return permissions
+}
+
+// PermissionTable is whether each permission is allowed.
+type PermissionTable map[Permission]bool
+
+// Format formats the permissions of the given matrix as a table, with a row for each resource and verb, and a column
+// for each namespace, e.g.:
+//
+//	RESOURCE  VERB  app      default
+//	pods      get   allowed  denied
+//	secrets   get   denied   denied
+func (table PermissionTable) Format(matrix PermissionMatrix) string {
+	var sb strings.Builder
+	writer := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprint(writer, "RESOURCE\tVERB")
+	for _, namespace := range matrix.Namespaces {
+		if namespace == "" {
+			namespace = "(cluster)"
+		}
+		fmt.Fprintf(writer, "\t%s", namespace)
+	}
+	fmt.Fprintln(writer)
+	for _, resource := range matrix.Resources {
+		for _, verb := range matrix.Verbs {
+			fmt.Fprintf(writer, "%s\t%s", resource, verb)
+			for _, namespace := range matrix.Namespaces {
+				fmt.Fprintf(writer, "\t%s", formatAllowed(table[Permission{Verb: verb, Resource: resource, Namespace: namespace}]))
+			}
+			fmt.Fprintln(writer)
+		}
+	}
+	writer.Flush()
+	return sb.String()
+}
+
+// GetPermissionTable checks every permission of the given matrix for the given subject with a SubjectAccessReview,
+// and returns whether each is allowed. This will fail the test if there are any errors accessing the kubernetes API
+// (but not if an action is denied).
+func GetPermissionTable(t testing.TestingT, options *KubectlOptions, subject RBACSubject, matrix PermissionMatrix) PermissionTable {
+	table, err := GetPermissionTableE(t, options, subject, matrix)
+	require.NoError(t, err)
+	return table
+}
+
+// GetPermissionTableE checks every permission of the given matrix for the given subject with a SubjectAccessReview,
+// and returns whether each is allowed. This will return an error if there are problems accessing the kubernetes API,
+// or if an action is denied because the authorizer failed to evaluate it (but not if an action is simply denied).
+func GetPermissionTableE(t testing.TestingT, options *KubectlOptions, subject RBACSubject, matrix PermissionMatrix) (PermissionTable, error) {
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	table := PermissionTable{}
+	for _, permission := range matrix.Permissions() {
+		attributes := permission.resourceAttributes()
+		review := authv1.SubjectAccessReview{
+			Spec: authv1.SubjectAccessReviewSpec{
+				ResourceAttributes: &attributes,
+				User:               subject.User,
+				Groups:             subject.Groups,
+			},
+		}
+		resp, err := clientset.AuthorizationV1().SubjectAccessReviews().Create(context.Background(), &review, metav1.CreateOptions{})
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		allowed, err := reviewAllowedE(subject, permission, resp.Status)
+		if err != nil {
+			return nil, err
+		}
+		table[permission] = allowed
+	}
+	logger.Logf(t, "Permissions of %s:\n%s", subject.User, table.Format(matrix))
+	return table, nil
+}
+
+// reviewAllowedE returns whether the given status of a SubjectAccessReview allows the given permission, or a
+// PermissionEvaluationError if it was denied because the authorizer failed, rather than by the RBAC rules.
+func reviewAllowedE(subject RBACSubject, permission Permission, status authv1.SubjectAccessReviewStatus) (bool, error) {
+	if !status.Allowed && status.EvaluationError != "" {
+		return false, PermissionEvaluationError{Subject: subject.User, Permission: permission, EvaluationError: status.EvaluationError}
+	}
+	return status.Allowed, nil
+}
+
+// AssertPermissions checks that the given subject has exactly the given allowed permissions among the permissions of
+// the given matrix, i.e. that these are allowed, and all the others are denied. This will fail the test with the
+// differences if they are not, or if there are any errors accessing the kubernetes API.
+func AssertPermissions(t testing.TestingT, options *KubectlOptions, subject RBACSubject, matrix PermissionMatrix, allowed []Permission) {
+	require.NoError(t, AssertPermissionsE(t, options, subject, matrix, allowed))
+}
+
+// AssertPermissionsE checks that the given subject has exactly the given allowed permissions among the permissions of
+// the given matrix, i.e. that these are allowed, and all the others are denied. If they are not, a PermissionsMismatch
+// error lists the differences. The allowed permissions must all be in the matrix, otherwise a PermissionsNotInMatrix
+// error lists those that are not. This is typically used to check that the roles of a chart grant least privilege:
+//
+//	matrix := k8s.PermissionMatrix{
+//		Verbs:      []string{"get", "list", "create", "delete"},
+//		Resources:  []string{"pods", "secrets", "deployments.apps"},
+//		Namespaces: []string{"app", "default"},
+//	}
+//	err := k8s.AssertPermissionsE(t, options, k8s.ServiceAccountSubject("app", "app"), matrix, []k8s.Permission{
+//		{Verb: "get", Resource: "pods", Namespace: "app"},
+//		{Verb: "list", Resource: "pods", Namespace: "app"},
+//	})
+func AssertPermissionsE(t testing.TestingT, options *KubectlOptions, subject RBACSubject, matrix PermissionMatrix, allowed []Permission) error {
+	table, err := GetPermissionTableE(t, options, subject, matrix)
+	if err != nil {
+		return err
+	}
+	return comparePermissions(subject, matrix, table, allowed)
+}
+
+// comparePermissions returns a PermissionsMismatch error if the given table does not allow exactly the given
+// permissions among the permissions of the given matrix, or a PermissionsNotInMatrix error if some of the given
+// permissions are not in the matrix.
+func comparePermissions(subject RBACSubject, matrix PermissionMatrix, table PermissionTable, allowed []Permission) error {
+	inMatrix := PermissionTable{}
+	for _, permission := range matrix.Permissions() {
+		inMatrix[permission] = true
+	}
+	expected := PermissionTable{}
+	notInMatrix := PermissionsNotInMatrix{}
+	for _, permission := range allowed {
+		expected[permission] = true
+		if !inMatrix[permission] {
+			notInMatrix.Permissions = append(notInMatrix.Permissions, permission)
+		}
+	}
+	if len(notInMatrix.Permissions) > 0 {
+		return notInMatrix
+	}
+
+	mismatch := PermissionsMismatch{Subject: subject.User}
+	for _, permission := range matrix.Permissions() {
+		switch {
+		case table[permission] && !expected[permission]:
+			mismatch.UnexpectedlyAllowed = append(mismatch.UnexpectedlyAllowed, permission)
+		case !table[permission] && expected[permission]:
+			mismatch.UnexpectedlyDenied = append(mismatch.UnexpectedlyDenied, permission)
+		}
+	}
+	if len(mismatch.UnexpectedlyAllowed) == 0 && len(mismatch.UnexpectedlyDenied) == 0 {
+		return nil
+	}
+	return mismatch
+}
+
+func formatAllowed(allowed bool) string {
+	if allowed {
+		return "allowed"
+	}
+	return "denied"
+}
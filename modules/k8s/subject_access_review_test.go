@@ -0,0 +1,81 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package k8s
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertPermissionsChecksServiceAccountRole(t *testing.T) {
+	t.Parallel()
+
+	options := CreateTestNamespace(t, NewKubectlOptions("", "", "default"))
+	configData := fmt.Sprintf(EXAMPLE_POD_READER_ROLE_YAML_TEMPLATE, options.Namespace, options.Namespace, options.Namespace, options.Namespace)
+	KubectlApplyFromString(t, options, configData)
+
+	subject := ServiceAccountSubject(options.Namespace, "pod-reader")
+	matrix := PermissionMatrix{
+		Verbs:      []string{"get", "list", "delete"},
+		Resources:  []string{"pods", "pods/log", "secrets", "deployments.apps"},
+		Namespaces: []string{options.Namespace, "default"},
+	}
+	allowed := []Permission{
+		{Verb: "get", Resource: "pods", Namespace: options.Namespace},
+		{Verb: "list", Resource: "pods", Namespace: options.Namespace},
+		{Verb: "get", Resource: "pods/log", Namespace: options.Namespace},
+	}
+	AssertPermissions(t, options, subject, matrix, allowed)
+
+	err := AssertPermissionsE(t, options, subject, matrix, allowed[:1])
+	require.Error(t, err)
+	mismatch, isMismatch := err.(PermissionsMismatch)
+	require.True(t, isMismatch, "expected a PermissionsMismatch error, got %v", err)
+	assert.Equal(t, allowed[1:], mismatch.UnexpectedlyAllowed)
+	assert.Empty(t, mismatch.UnexpectedlyDenied)
+}
+
+const EXAMPLE_POD_READER_ROLE_YAML_TEMPLATE = `---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: pod-reader
+  namespace: %s
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: pod-reader
+  namespace: %s
+rules:
+- apiGroups: [""]
+  resources: ["pods", "pods/log"]
+  verbs: ["get"]
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["list"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: pod-reader
+  namespace: %s
+subjects:
+- kind: ServiceAccount
+  name: pod-reader
+  namespace: %s
+roleRef:
+  kind: Role
+  name: pod-reader
+  apiGroup: rbac.authorization.k8s.io
+`
@@ -0,0 +1,100 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "k8s.io/api/authorization/v1"
+)
+
+func TestPermissionResourceAttributes(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t,
+		authv1.ResourceAttributes{Namespace: "app", Verb: "get", Resource: "pods"},
+		Permission{Verb: "get", Resource: "pods", Namespace: "app"}.resourceAttributes(),
+	)
+	assert.Equal(t,
+		authv1.ResourceAttributes{Namespace: "app", Verb: "get", Resource: "pods", Subresource: "log"},
+		Permission{Verb: "get", Resource: "pods/log", Namespace: "app"}.resourceAttributes(),
+	)
+	assert.Equal(t,
+		authv1.ResourceAttributes{Verb: "update", Group: "cert-manager.io", Resource: "certificates", Subresource: "status"},
+		Permission{Verb: "update", Resource: "certificates.cert-manager.io/status"}.resourceAttributes(),
+	)
+}
+
+func TestPermissionTableFormat(t *testing.T) {
+	t.Parallel()
+
+	matrix := PermissionMatrix{
+		Verbs:      []string{"get", "delete"},
+		Resources:  []string{"pods"},
+		Namespaces: []string{"app", ""},
+	}
+	table := PermissionTable{
+		{Verb: "get", Resource: "pods", Namespace: "app"}: true,
+		{Verb: "get", Resource: "pods", Namespace: ""}:    false,
+	}
+	expected := "RESOURCE  VERB    app      (cluster)\n" +
+		"pods      get     allowed  denied\n" +
+		"pods      delete  denied   denied\n"
+	assert.Equal(t, expected, table.Format(matrix))
+}
+
+func TestComparePermissionsListsDifferences(t *testing.T) {
+	t.Parallel()
+
+	subject := ServiceAccountSubject("app", "app")
+	matrix := PermissionMatrix{
+		Verbs:      []string{"get", "create"},
+		Resources:  []string{"secrets"},
+		Namespaces: []string{"app"},
+	}
+	get := Permission{Verb: "get", Resource: "secrets", Namespace: "app"}
+	create := Permission{Verb: "create", Resource: "secrets", Namespace: "app"}
+
+	require.NoError(t, comparePermissions(subject, matrix, PermissionTable{get: true}, []Permission{get}))
+
+	err := comparePermissions(subject, matrix, PermissionTable{create: true}, []Permission{get})
+	require.Error(t, err)
+	expected := "Permissions of system:serviceaccount:app:app do not match the expected permissions:\n" +
+		"\t+ create secrets in namespace app: allowed, expected denied\n" +
+		"\t- get secrets in namespace app: denied, expected allowed"
+	assert.Equal(t, expected, err.Error())
+}
+
+func TestComparePermissionsRejectsPermissionsNotInMatrix(t *testing.T) {
+	t.Parallel()
+
+	matrix := PermissionMatrix{
+		Verbs:      []string{"get"},
+		Resources:  []string{"secrets"},
+		Namespaces: []string{"app"},
+	}
+	get := Permission{Verb: "get", Resource: "secrets", Namespace: "app"}
+	typo := Permission{Verb: "get", Resource: "secret", Namespace: "app"}
+
+	err := comparePermissions(ServiceAccountSubject("app", "app"), matrix, PermissionTable{get: true}, []Permission{get, typo})
+	assert.Equal(t, PermissionsNotInMatrix{Permissions: []Permission{typo}}, err)
+	assert.Equal(t, "Expected permissions are not in the permission matrix, so they are not checked:\n\tget secret in namespace app", err.Error())
+}
+
+func TestReviewAllowedEReportsEvaluationErrors(t *testing.T) {
+	t.Parallel()
+
+	subject := ServiceAccountSubject("app", "app")
+	permission := Permission{Verb: "get", Resource: "secrets", Namespace: "app"}
+
+	allowed, err := reviewAllowedE(subject, permission, authv1.SubjectAccessReviewStatus{Allowed: true})
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = reviewAllowedE(subject, permission, authv1.SubjectAccessReviewStatus{})
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	_, err = reviewAllowedE(subject, permission, authv1.SubjectAccessReviewStatus{EvaluationError: "webhook unavailable"})
+	assert.Equal(t, PermissionEvaluationError{Subject: subject.User, Permission: permission, EvaluationError: "webhook unavailable"}, err)
+}
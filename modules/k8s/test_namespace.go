@@ -0,0 +1,179 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	go_test "testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/logger"
+	"github.com/tnn-gruntwork-io/terratest/modules/random"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// TestNamespaceLabel is the label that CreateTestNamespace sets on the namespaces it creates, with the name of the test
+// as value. Use it to find namespaces leaked by tests that were killed before they could clean up, e.g. with
+// DeleteLeakedTestNamespaces.
+const TestNamespaceLabel = "terratest/test"
+
+const (
+	// The default number of times to check whether a test namespace is deleted, and the time between checks
+	defaultTestNamespaceDeleteRetries           = 60
+	defaultTestNamespaceSleepBetweenDeleteRetry = 5 * time.Second
+
+	// The maximum length of the name and labels of a Kubernetes object
+	maxKubernetesNameLength = 63
+)
+
+var (
+	invalidNamespaceNameChars = regexp.MustCompile("[^a-z0-9]+")
+	invalidLabelValueChars    = regexp.MustCompile("[^A-Za-z0-9_.-]+")
+)
+
+// TestNamespaceOptions configures the namespaces created by CreateTestNamespaceWithOptions.
+type TestNamespaceOptions struct {
+	// The prefix of the name of the namespace, which is followed by a unique ID. Defaults to the name of the test.
+	NamePrefix string
+	// Additional labels to set on the namespace
+	Labels map[string]string
+	// If true, the diagnostics of the namespace are collected before it is deleted, if the test failed. See
+	// CollectDiagnostics.
+	CollectDiagnosticsOnFailure bool
+	// The directory to write the diagnostics to. Defaults to the test log.
+	DiagnosticsOutputDir string
+	// The number of times to check whether the namespace is deleted, and the time between checks. Default to 60 checks,
+	// 5 seconds apart.
+	DeleteRetries             int
+	SleepBetweenDeleteRetries time.Duration
+}
+
+// CreateTestNamespace creates a uniquely named namespace for the given test on the cluster targeted by the provided
+// options, and returns a copy of the options targeting that namespace. The namespace is deleted when the test and all
+// its subtests complete, and the test fails if the namespace, including all its resources and their finalizers, is not
+// fully deleted in time.
+func CreateTestNamespace(t *go_test.T, options *KubectlOptions) *KubectlOptions {
+	return CreateTestNamespaceWithOptions(t, options, TestNamespaceOptions{})
+}
+
+// CreateTestNamespaceWithOptions creates a uniquely named namespace for the given test on the cluster targeted by the
+// provided options, and returns a copy of the options targeting that namespace. The namespace is deleted when the test
+// and all its subtests complete, and the test fails if the namespace, including all its resources and their
+// finalizers, is not fully deleted in time. This will fail the test if there is an error creating the namespace.
+func CreateTestNamespaceWithOptions(t *go_test.T, options *KubectlOptions, namespaceOptions TestNamespaceOptions) *KubectlOptions {
+	namespaceKubectlOptions, err := CreateTestNamespaceWithOptionsE(t, options, namespaceOptions)
+	require.NoError(t, err)
+	return namespaceKubectlOptions
+}
+
+// CreateTestNamespaceWithOptionsE creates a uniquely named namespace for the given test on the cluster targeted by the
+// provided options, and returns a copy of the options targeting that namespace. The namespace is deleted when the test
+// and all its subtests complete, and the test fails if the namespace, including all its resources and their
+// finalizers, is not fully deleted in time.
+func CreateTestNamespaceWithOptionsE(t *go_test.T, options *KubectlOptions, namespaceOptions TestNamespaceOptions) (*KubectlOptions, error) {
+	prefix := namespaceOptions.NamePrefix
+	if prefix == "" {
+		prefix = t.Name()
+	}
+	namespaceName := testNamespaceName(prefix, strings.ToLower(random.UniqueId()))
+
+	labels := map[string]string{TestNamespaceLabel: testNamespaceLabelValue(t.Name())}
+	for key, value := range namespaceOptions.Labels {
+		labels[key] = value
+	}
+	if err := CreateNamespaceWithMetadataE(t, options, metav1.ObjectMeta{Name: namespaceName, Labels: labels}); err != nil {
+		return nil, err
+	}
+	logger.Logf(t, "Created namespace %s for test %s", namespaceName, t.Name())
+
+	namespaceKubectlOptions := *options
+	namespaceKubectlOptions.Namespace = namespaceName
+	t.Cleanup(func() {
+		if namespaceOptions.CollectDiagnosticsOnFailure {
+			CollectDiagnosticsOnFailure(t, &namespaceKubectlOptions, namespaceOptions.DiagnosticsOutputDir)
+		}
+		require.NoError(t, deleteTestNamespaceE(t, options, namespaceName, namespaceOptions))
+	})
+	return &namespaceKubectlOptions, nil
+}
+
+// DeleteLeakedTestNamespaces deletes the namespaces created by CreateTestNamespace more than the given duration ago,
+// which were typically leaked by tests that were killed before they could clean up, e.g. on a timeout. This does not
+// wait for the namespaces to be deleted. This will fail the test if there is an error.
+func DeleteLeakedTestNamespaces(t testing.TestingT, options *KubectlOptions, olderThan time.Duration) []string {
+	deleted, err := DeleteLeakedTestNamespacesE(t, options, olderThan)
+	require.NoError(t, err)
+	return deleted
+}
+
+// DeleteLeakedTestNamespacesE deletes the namespaces created by CreateTestNamespace more than the given duration ago,
+// which were typically leaked by tests that were killed before they could clean up, e.g. on a timeout, and returns
+// their names. This does not wait for the namespaces to be deleted.
+func DeleteLeakedTestNamespacesE(t testing.TestingT, options *KubectlOptions, olderThan time.Duration) ([]string, error) {
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := clientset.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{LabelSelector: TestNamespaceLabel})
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := []string{}
+	for _, namespace := range namespaces.Items {
+		if time.Since(namespace.CreationTimestamp.Time) < olderThan || namespace.Status.Phase == corev1.NamespaceTerminating {
+			continue
+		}
+		logger.Logf(t, "Deleting namespace %s leaked by test %s", namespace.Name, namespace.Labels[TestNamespaceLabel])
+		if err := DeleteNamespaceE(t, options, namespace.Name); err != nil && !errors.IsNotFound(err) {
+			return deleted, err
+		}
+		deleted = append(deleted, namespace.Name)
+	}
+	return deleted, nil
+}
+
+// deleteTestNamespaceE deletes the given test namespace and waits until it no longer exists.
+func deleteTestNamespaceE(t testing.TestingT, options *KubectlOptions, namespaceName string, namespaceOptions TestNamespaceOptions) error {
+	retries := namespaceOptions.DeleteRetries
+	if retries == 0 {
+		retries = defaultTestNamespaceDeleteRetries
+	}
+	sleepBetweenRetries := namespaceOptions.SleepBetweenDeleteRetries
+	if sleepBetweenRetries == 0 {
+		sleepBetweenRetries = defaultTestNamespaceSleepBetweenDeleteRetry
+	}
+
+	if err := DeleteNamespaceE(t, options, namespaceName); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return WaitUntilNamespaceDeletedE(t, options, namespaceName, retries, sleepBetweenRetries)
+}
+
+// testNamespaceName returns a valid namespace name made of the given prefix, e.g. the name of a test, and unique ID.
+func testNamespaceName(prefix string, uniqueID string) string {
+	prefix = strings.Trim(invalidNamespaceNameChars.ReplaceAllString(strings.ToLower(prefix), "-"), "-")
+	maxPrefixLength := maxKubernetesNameLength - len(uniqueID) - 1
+	if len(prefix) > maxPrefixLength {
+		prefix = strings.TrimRight(prefix[:maxPrefixLength], "-")
+	}
+	if prefix == "" {
+		return uniqueID
+	}
+	return fmt.Sprintf("%s-%s", prefix, uniqueID)
+}
+
+// testNamespaceLabelValue returns a valid label value for the given test name, e.g. "TestFoo_bar" for "TestFoo/bar".
+func testNamespaceLabelValue(testName string) string {
+	value := invalidLabelValueChars.ReplaceAllString(testName, "_")
+	if len(value) > maxKubernetesNameLength {
+		value = value[:maxKubernetesNameLength]
+	}
+	return strings.Trim(value, "_.-")
+}
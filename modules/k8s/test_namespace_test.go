@@ -0,0 +1,41 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package k8s
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestCreateTestNamespaceIsDeletedOnCleanup(t *testing.T) {
+	t.Parallel()
+
+	options := NewKubectlOptions("", "", "default")
+	var namespaceName string
+	t.Run("CreateNamespace", func(t *testing.T) {
+		namespaceOptions := CreateTestNamespaceWithOptions(t, options, TestNamespaceOptions{
+			Labels:                    map[string]string{"foo": "bar"},
+			SleepBetweenDeleteRetries: 1 * time.Second,
+		})
+		namespaceName = namespaceOptions.Namespace
+		require.True(t, strings.HasPrefix(namespaceName, "testcreatetestnamespaceisdeletedoncleanup-createnamespace-"))
+
+		namespace := GetNamespace(t, options, namespaceName)
+		require.Equal(t, "TestCreateTestNamespaceIsDeletedOnCleanup_CreateNamespace", namespace.Labels[TestNamespaceLabel])
+		require.Equal(t, "bar", namespace.Labels["foo"])
+	})
+
+	_, err := GetNamespaceE(t, options, namespaceName)
+	require.True(t, errors.IsNotFound(err), "expected namespace %s to be deleted, got %v", namespaceName, err)
+}
@@ -0,0 +1,26 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestNamespaceName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "testfoo-bar-baz-abc123", testNamespaceName("TestFoo/bar_baz", "abc123"))
+	assert.Equal(t, "abc123", testNamespaceName("__", "abc123"))
+
+	name := testNamespaceName("Test"+strings.Repeat("VeryLongName", 10), "abc123")
+	assert.Len(t, name, maxKubernetesNameLength)
+	assert.True(t, strings.HasSuffix(name, "-abc123"))
+}
+
+func TestTestNamespaceLabelValue(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "TestFoo_bar_baz", testNamespaceLabelValue("TestFoo/bar baz"))
+	assert.Len(t, testNamespaceLabelValue("Test"+strings.Repeat("VeryLongName", 10)), maxKubernetesNameLength)
+}
@@ -6,6 +6,7 @@ package k8s
 // See: https://github.com/helm/helm/blob/master/pkg/kube/tunnel.go
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -14,9 +15,14 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/portforward"
 	"k8s.io/client-go/transport/spdy"
 
@@ -35,6 +41,10 @@ const (
 	ResourceTypePod KubeResourceType = iota
 	// ResourceTypeService is a k8s service kind identifier
 	ResourceTypeService
+	// ResourceTypeDeployment is a k8s deployment kind identifier
+	ResourceTypeDeployment
+	// ResourceTypeStatefulSet is a k8s statefulset kind identifier
+	ResourceTypeStatefulSet
 )
 
 func (resourceType KubeResourceType) String() string {
@@ -43,6 +53,10 @@ func (resourceType KubeResourceType) String() string {
 		return "pod"
 	case ResourceTypeService:
 		return "svc"
+	case ResourceTypeDeployment:
+		return "deploy"
+	case ResourceTypeStatefulSet:
+		return "sts"
 	default:
 		// This should not happen
 		return "UNKNOWN_RESOURCE_TYPE"
@@ -58,17 +72,53 @@ func makeLabels(labels map[string]string) string {
 	return strings.Join(out, ",")
 }
 
+// The default time between the checks of the pod of a tunnel that reconnects automatically, and between the attempts
+// to reconnect
+const defaultTunnelReconnectInterval = 1 * time.Second
+
+// TunnelPort is a port forwarded by a tunnel, from a local port to a port of the remote pod. A local port of 0 selects
+// an open port on the host system when the tunnel is opened.
+type TunnelPort struct {
+	Local  int
+	Remote int
+}
+
+// TunnelOptions configures the tunnels created by NewTunnelWithOptions.
+type TunnelOptions struct {
+	// The ports to forward
+	Ports []TunnelPort
+	// If true, the tunnel reopens to a new pod of the resource when the connection to its pod is lost, or its pod is
+	// no longer available, e.g. during a rolling update of a Deployment. The local ports are kept, so that clients of
+	// the tunnel only see a brief interruption.
+	AutoReconnect bool
+	// The time between the checks of the pod of a tunnel that reconnects automatically, and between the attempts to
+	// reconnect. Defaults to 1 second.
+	ReconnectInterval time.Duration
+	// The logger to use. Defaults to logger.Terratest.
+	Logger logger.TestLogger
+}
+
 // Tunnel is the main struct that configures and manages port forwading tunnels to Kubernetes resources.
 type Tunnel struct {
-	out            io.Writer
-	localPort      int
-	remotePort     int
-	kubectlOptions *KubectlOptions
-	resourceType   KubeResourceType
-	resourceName   string
-	logger         logger.TestLogger
-	stopChan       chan struct{}
-	readyChan      chan struct{}
+	out               io.Writer
+	ports             []TunnelPort
+	kubectlOptions    *KubectlOptions
+	resourceType      KubeResourceType
+	resourceName      string
+	logger            logger.TestLogger
+	autoReconnect     bool
+	reconnectInterval time.Duration
+	stopChan          chan struct{}
+	closeOnce         sync.Once
+
+	// The state of the connection to the pod, which changes when the tunnel reconnects
+	mutex     sync.Mutex
+	podName   string
+	connected bool
+	// The client the tunnel was opened with, reused to check its pod
+	clientset *kubernetes.Clientset
+	// Closed when the goroutine that monitors the connection returns
+	doneChan chan struct{}
 }
 
 // NewTunnel creates a new tunnel with NewTunnelWithLogger, setting logger.Terratest as the logger.
@@ -87,27 +137,115 @@ func NewTunnelWithLogger(
 	remote int,
 	logger logger.TestLogger,
 ) *Tunnel {
+	return NewTunnelWithOptions(kubectlOptions, resourceType, resourceName, TunnelOptions{
+		Ports:  []TunnelPort{{Local: local, Remote: remote}},
+		Logger: logger,
+	})
+}
+
+// NewTunnelWithOptions will create a new Tunnel struct that forwards all the given ports to a pod of the given
+// resource. Deployments and StatefulSets are forwarded to one of their available pods, as Services are. Note that if
+// you use 0 for a local port, an open port on the host system will be selected automatically, and the Tunnel struct
+// will be updated with the selected port.
+func NewTunnelWithOptions(kubectlOptions *KubectlOptions, resourceType KubeResourceType, resourceName string, options TunnelOptions) *Tunnel {
+	tunnelLogger := options.Logger
+	if tunnelLogger == nil {
+		tunnelLogger = logger.Terratest
+	}
+	reconnectInterval := options.ReconnectInterval
+	if reconnectInterval == 0 {
+		reconnectInterval = defaultTunnelReconnectInterval
+	}
 	return &Tunnel{
-		out:            ioutil.Discard,
-		localPort:      local,
-		remotePort:     remote,
-		kubectlOptions: kubectlOptions,
-		resourceType:   resourceType,
-		resourceName:   resourceName,
-		logger:         logger,
-		stopChan:       make(chan struct{}, 1),
-		readyChan:      make(chan struct{}, 1),
+		out:               ioutil.Discard,
+		ports:             append([]TunnelPort{}, options.Ports...),
+		kubectlOptions:    kubectlOptions,
+		resourceType:      resourceType,
+		resourceName:      resourceName,
+		logger:            tunnelLogger,
+		autoReconnect:     options.AutoReconnect,
+		reconnectInterval: reconnectInterval,
+		stopChan:          make(chan struct{}, 1),
 	}
 }
 
-// Endpoint returns the tunnel endpoint
+// Endpoint returns the tunnel endpoint of the first forwarded port
 func (tunnel *Tunnel) Endpoint() string {
-	return fmt.Sprintf("localhost:%d", tunnel.localPort)
+	if len(tunnel.ports) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("localhost:%d", tunnel.ports[0].Local)
+}
+
+// EndpointForRemotePort returns the tunnel endpoint that forwards to the given port of the remote pod, or an empty
+// string if the tunnel does not forward that port.
+func (tunnel *Tunnel) EndpointForRemotePort(remote int) string {
+	for _, port := range tunnel.ports {
+		if port.Remote == remote {
+			return fmt.Sprintf("localhost:%d", port.Local)
+		}
+	}
+	return ""
+}
+
+// PodName returns the name of the pod the tunnel currently forwards to, which changes when the tunnel reconnects.
+func (tunnel *Tunnel) PodName() string {
+	tunnel.mutex.Lock()
+	defer tunnel.mutex.Unlock()
+	return tunnel.podName
 }
 
-// Close disconnects a tunnel connection by closing the StopChan, thereby stopping the goroutine.
+// Close disconnects a tunnel connection by closing the StopChan, thereby stopping the goroutine, and waits for the
+// goroutine to return, so that it does not log after the test has completed.
 func (tunnel *Tunnel) Close() {
-	close(tunnel.stopChan)
+	tunnel.closeOnce.Do(func() {
+		close(tunnel.stopChan)
+	})
+	tunnel.mutex.Lock()
+	doneChan := tunnel.doneChan
+	tunnel.mutex.Unlock()
+	if doneChan != nil {
+		<-doneChan
+	}
+}
+
+// IsHealthy returns true if the tunnel is connected to an available pod and accepts connections on all its local
+// ports.
+func (tunnel *Tunnel) IsHealthy(t testing.TestingT) bool {
+	return tunnel.CheckHealthE(t) == nil
+}
+
+// CheckHealthE returns an error if the tunnel is not connected, e.g. while it reconnects, if its pod is not
+// available, or if one of its local ports does not accept connections.
+func (tunnel *Tunnel) CheckHealthE(t testing.TestingT) error {
+	tunnel.mutex.Lock()
+	podName, connected, clientset := tunnel.podName, tunnel.connected, tunnel.clientset
+	tunnel.mutex.Unlock()
+	if !connected {
+		return TunnelNotConnected{tunnel.resourceType, tunnel.resourceName}
+	}
+
+	pod, err := tunnel.getPodE(clientset, podName)
+	if err != nil {
+		return err
+	}
+	if !isPodAttachable(pod) {
+		return NewPodNotAvailableError(pod)
+	}
+	for _, port := range tunnel.ports {
+		conn, err := net.DialTimeout("tcp", fmt.Sprintf("localhost:%d", port.Local), tunnel.reconnectInterval)
+		if err != nil {
+			return err
+		}
+		conn.Close()
+	}
+	return nil
+}
+
+// getPodE returns the pod with the given name with the given client, rather than with a new client as GetPodE does, as
+// the pod of a tunnel is checked frequently.
+func (tunnel *Tunnel) getPodE(clientset *kubernetes.Clientset, podName string) (*corev1.Pod, error) {
+	return clientset.CoreV1().Pods(tunnel.kubectlOptions.Namespace).Get(context.Background(), podName, metav1.GetOptions{})
 }
 
 // getAttachablePodForResource will find a pod that can be port forwarded to given the provided resource type and return
@@ -118,6 +256,10 @@ func (tunnel *Tunnel) getAttachablePodForResourceE(t testing.TestingT) (string,
 		return tunnel.resourceName, nil
 	case ResourceTypeService:
 		return tunnel.getAttachablePodForServiceE(t)
+	case ResourceTypeDeployment:
+		return tunnel.getAttachablePodForDeploymentE(t)
+	case ResourceTypeStatefulSet:
+		return tunnel.getAttachablePodForStatefulSetE(t)
 	default:
 		return "", UnknownKubeResourceType{tunnel.resourceType}
 	}
@@ -130,16 +272,75 @@ func (tunnel *Tunnel) getAttachablePodForServiceE(t testing.TestingT) (string, e
 		return "", err
 	}
 	selectorLabelsOfPods := makeLabels(service.Spec.Selector)
-	servicePods, err := ListPodsE(t, tunnel.kubectlOptions, metav1.ListOptions{LabelSelector: selectorLabelsOfPods})
+	podName, err := tunnel.getAttachablePodForSelectorE(t, selectorLabelsOfPods)
+	if err != nil {
+		return "", err
+	}
+	if podName == "" {
+		return "", ServiceNotAvailable{service}
+	}
+	return podName, nil
+}
+
+// getAttachablePodForDeploymentE will find an active pod of the Deployment and return the pod name.
+func (tunnel *Tunnel) getAttachablePodForDeploymentE(t testing.TestingT) (string, error) {
+	deployment, err := GetDeploymentE(t, tunnel.kubectlOptions, tunnel.resourceName)
+	if err != nil {
+		return "", err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return "", err
+	}
+	podName, err := tunnel.getAttachablePodForSelectorE(t, selector.String())
+	if err != nil {
+		return "", err
+	}
+	if podName == "" {
+		return "", NewDeploymentNotAvailableError(deployment)
+	}
+	return podName, nil
+}
+
+// getAttachablePodForStatefulSetE will find an active pod of the StatefulSet and return the pod name.
+func (tunnel *Tunnel) getAttachablePodForStatefulSetE(t testing.TestingT) (string, error) {
+	statefulSet, err := GetStatefulSetE(t, tunnel.kubectlOptions, tunnel.resourceName)
+	if err != nil {
+		return "", err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(statefulSet.Spec.Selector)
+	if err != nil {
+		return "", err
+	}
+	podName, err := tunnel.getAttachablePodForSelectorE(t, selector.String())
+	if err != nil {
+		return "", err
+	}
+	if podName == "" {
+		return "", NewStatefulSetNotAvailableError(statefulSet)
+	}
+	return podName, nil
+}
+
+// getAttachablePodForSelectorE returns the name of an active pod matching the given label selector, or an empty string
+// if there is none.
+func (tunnel *Tunnel) getAttachablePodForSelectorE(t testing.TestingT, labelSelector string) (string, error) {
+	pods, err := ListPodsE(t, tunnel.kubectlOptions, metav1.ListOptions{LabelSelector: labelSelector})
 	if err != nil {
 		return "", err
 	}
-	for _, pod := range servicePods {
-		if IsPodAvailable(&pod) {
-			return pod.Name, nil
+	for i := range pods {
+		if isPodAttachable(&pods[i]) {
+			return pods[i].Name, nil
 		}
 	}
-	return "", ServiceNotAvailable{service}
+	return "", nil
+}
+
+// isPodAttachable returns true if the given pod is available and is not being deleted, e.g. by a rolling update, so
+// that a tunnel to it is not about to be cut.
+func isPodAttachable(pod *corev1.Pod) bool {
+	return pod.DeletionTimestamp == nil && IsPodAvailable(pod)
 }
 
 // ForwardPort opens a tunnel to a kubernetes resource, as specified by the provided tunnel struct. This will fail the
@@ -148,16 +349,19 @@ func (tunnel *Tunnel) ForwardPort(t testing.TestingT) {
 	require.NoError(t, tunnel.ForwardPortE(t))
 }
 
-// ForwardPortE opens a tunnel to a kubernetes resource, as specified by the provided tunnel struct.
+// ForwardPortE opens a tunnel to a kubernetes resource, as specified by the provided tunnel struct. If the tunnel
+// reconnects automatically, it keeps doing so in the background until it is closed.
 func (tunnel *Tunnel) ForwardPortE(t testing.TestingT) error {
-	tunnel.logger.Logf(
-		t,
-		"Creating a port forwarding tunnel for resource %s/%s routing local port %d to remote port %d",
-		tunnel.resourceType.String(),
-		tunnel.resourceName,
-		tunnel.localPort,
-		tunnel.remotePort,
-	)
+	for _, port := range tunnel.ports {
+		tunnel.logger.Logf(
+			t,
+			"Creating a port forwarding tunnel for resource %s/%s routing local port %d to remote port %d",
+			tunnel.resourceType.String(),
+			tunnel.resourceName,
+			port.Local,
+			port.Remote,
+		)
+	}
 
 	// Prepare a kubernetes client for the client-go library
 	clientset, err := GetKubernetesClientFromOptionsE(t, tunnel.kubectlOptions)
@@ -165,22 +369,95 @@ func (tunnel *Tunnel) ForwardPortE(t testing.TestingT) error {
 		tunnel.logger.Logf(t, "Error creating a new Kubernetes client: %s", err)
 		return err
 	}
-	kubeConfigPath, err := tunnel.kubectlOptions.GetConfigPath(t)
+	config, err := GetRestConfigFromOptionsE(t, tunnel.kubectlOptions)
 	if err != nil {
-		tunnel.logger.Logf(t, "Error getting kube config path: %s", err)
+		tunnel.logger.Logf(t, "Error loading Kubernetes config: %s", err)
 		return err
 	}
-	config, err := LoadApiClientConfigE(kubeConfigPath, tunnel.kubectlOptions.ContextName)
+
+	// If a local port is 0, get an available port before continuing. We do this here instead of relying on the
+	// underlying portforwarder library, because the portforwarder library does not expose the selected local port in a
+	// machine readable manner, and so that the tunnel reconnects on the same port.
+	// Synchronize on the global lock to avoid race conditions with concurrently selecting the same available port,
+	// since there is a brief moment between `GetAvailablePort` and `portforwader.ForwardPorts` where the selected port
+	// is available for selection again.
+	lockedPortSelection := false
+	for i := range tunnel.ports {
+		if tunnel.ports[i].Local != 0 {
+			continue
+		}
+		tunnel.logger.Logf(t, "Requested local port is 0. Selecting an open port on host system")
+		if !lockedPortSelection {
+			globalMutex.Lock()
+			defer globalMutex.Unlock()
+			lockedPortSelection = true
+		}
+		tunnel.ports[i].Local, err = tunnel.getAvailableLocalPortE(t)
+		if err != nil {
+			tunnel.logger.Logf(t, "Error getting available port: %s", err)
+			return err
+		}
+		tunnel.logger.Logf(t, "Selected port %d", tunnel.ports[i].Local)
+	}
+
+	tunnel.mutex.Lock()
+	tunnel.clientset = clientset
+	tunnel.mutex.Unlock()
+
+	connection, err := tunnel.connectE(t, clientset, config)
 	if err != nil {
-		tunnel.logger.Logf(t, "Error loading Kubernetes config: %s", err)
 		return err
 	}
+	doneChan := make(chan struct{})
+	tunnel.mutex.Lock()
+	tunnel.doneChan = doneChan
+	tunnel.mutex.Unlock()
+	go func() {
+		defer close(doneChan)
+		tunnel.monitor(t, clientset, config, connection)
+	}()
+	return nil
+}
+
+// getAvailableLocalPortE retrieves an available port on the host machine that is not already a local port of the
+// tunnel, as the ports selected for a tunnel are only bound once they are all selected.
+func (tunnel *Tunnel) getAvailableLocalPortE(t testing.TestingT) (int, error) {
+	for {
+		port, err := GetAvailablePortE(t)
+		if err != nil {
+			return 0, err
+		}
+		if !tunnel.hasLocalPort(port) {
+			return port, nil
+		}
+	}
+}
 
+// hasLocalPort returns true if the given port is a local port of the tunnel.
+func (tunnel *Tunnel) hasLocalPort(local int) bool {
+	for _, port := range tunnel.ports {
+		if port.Local == local {
+			return true
+		}
+	}
+	return false
+}
+
+// tunnelConnection is a port forwarding connection to a pod. A tunnel that reconnects opens a new connection to a new
+// pod.
+type tunnelConnection struct {
+	podName  string
+	stopChan chan struct{}
+	errChan  chan error
+}
+
+// connectE opens a port forwarding connection to a pod of the resource of the tunnel.
+func (tunnel *Tunnel) connectE(t testing.TestingT, clientset *kubernetes.Clientset, config *rest.Config) (*tunnelConnection, error) {
 	// Find the pod to port forward to
 	podName, err := tunnel.getAttachablePodForResourceE(t)
 	if err != nil {
 		tunnel.logger.Logf(t, "Error finding available pod: %s", err)
-		return err
+		return nil, err
 	}
 	tunnel.logger.Logf(t, "Selected pod %s to open port forward to", podName)
 
@@ -201,54 +478,116 @@ func (tunnel *Tunnel) ForwardPortE(t testing.TestingT) error {
 	transport, upgrader, err := spdy.RoundTripperFor(config)
 	if err != nil {
 		tunnel.logger.Logf(t, "Error creating http client: %s", err)
-		return err
+		return nil, err
 	}
 	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", portForwardCreateURL)
 
-	// If the localport is 0, get an available port before continuing. We do this here instead of relying on the
-	// underlying portforwarder library, because the portforwarder library does not expose the selected local port in a
-	// machine readable manner.
-	// Synchronize on the global lock to avoid race conditions with concurrently selecting the same available port,
-	// since there is a brief moment between `GetAvailablePort` and `portforwader.ForwardPorts` where the selected port
-	// is available for selection again.
-	if tunnel.localPort == 0 {
-		tunnel.logger.Logf(t, "Requested local port is 0. Selecting an open port on host system")
-		tunnel.localPort, err = GetAvailablePortE(t)
-		if err != nil {
-			tunnel.logger.Logf(t, "Error getting available port: %s", err)
-			return err
-		}
-		tunnel.logger.Logf(t, "Selected port %d", tunnel.localPort)
-		globalMutex.Lock()
-		defer globalMutex.Unlock()
-	}
-
 	// Construct a new PortForwarder struct that manages the instructed port forward tunnel
-	ports := []string{fmt.Sprintf("%d:%d", tunnel.localPort, tunnel.remotePort)}
-	portforwarder, err := portforward.New(dialer, ports, tunnel.stopChan, tunnel.readyChan, tunnel.out, tunnel.out)
+	ports := []string{}
+	for _, port := range tunnel.ports {
+		ports = append(ports, fmt.Sprintf("%d:%d", port.Local, port.Remote))
+	}
+	connection := &tunnelConnection{podName: podName, stopChan: make(chan struct{}), errChan: make(chan error, 1)}
+	portforwarder, err := portforward.New(dialer, ports, connection.stopChan, make(chan struct{}, 1), tunnel.out, tunnel.out)
 	if err != nil {
 		tunnel.logger.Logf(t, "Error creating port forwarding tunnel: %s", err)
-		return err
+		return nil, err
 	}
 
 	// Open the tunnel in a goroutine so that it is available in the background. Report errors to the main goroutine via
 	// a new channel.
-	errChan := make(chan error)
 	go func() {
-		errChan <- portforwarder.ForwardPorts()
+		connection.errChan <- portforwarder.ForwardPorts()
 	}()
 
 	// Wait for an error or the tunnel to be ready
 	select {
-	case err = <-errChan:
+	case err = <-connection.errChan:
 		tunnel.logger.Logf(t, "Error starting port forwarding tunnel: %s", err)
-		return err
+		return nil, err
 	case <-portforwarder.Ready:
 		tunnel.logger.Logf(t, "Successfully created port forwarding tunnel")
-		return nil
+		tunnel.setConnection(podName, true)
+		return connection, nil
+	}
+}
+
+// monitor waits for the given connection to be lost or for the tunnel to be closed. If the tunnel reconnects
+// automatically, it also checks that the pod of the connection is still available, and reconnects to a new pod when
+// it is not.
+func (tunnel *Tunnel) monitor(t testing.TestingT, clientset *kubernetes.Clientset, config *rest.Config, connection *tunnelConnection) {
+	ticker := time.NewTicker(tunnel.reconnectInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-tunnel.stopChan:
+			close(connection.stopChan)
+			tunnel.setConnection(connection.podName, false)
+			return
+		case err := <-connection.errChan:
+			tunnel.setConnection(connection.podName, false)
+			tunnel.logger.Logf(t, "Lost port forwarding connection to pod %s: %v", connection.podName, err)
+			if !tunnel.autoReconnect {
+				return
+			}
+			connection = tunnel.reconnect(t, clientset, config)
+			if connection == nil {
+				return
+			}
+		case <-ticker.C:
+			if !tunnel.autoReconnect {
+				continue
+			}
+			pod, err := tunnel.getPodE(clientset, connection.podName)
+			if err != nil && !errors.IsNotFound(err) {
+				// A transient error, e.g. from the API server, says nothing about the pod, so keep the connection
+				tunnel.logger.Logf(t, "Failed to check pod %s, keeping the connection: %v", connection.podName, err)
+				continue
+			}
+			if err == nil && isPodAttachable(pod) {
+				continue
+			}
+			tunnel.logger.Logf(t, "Pod %s is no longer available, reconnecting to a new pod", connection.podName)
+			tunnel.setConnection(connection.podName, false)
+			close(connection.stopChan)
+			<-connection.errChan
+			connection = tunnel.reconnect(t, clientset, config)
+			if connection == nil {
+				return
+			}
+		}
 	}
 }
 
+// reconnect opens a new connection to a pod of the resource of the tunnel, retrying until it succeeds or the tunnel is
+// closed, in which case it returns nil.
+func (tunnel *Tunnel) reconnect(t testing.TestingT, clientset *kubernetes.Clientset, config *rest.Config) *tunnelConnection {
+	for {
+		select {
+		case <-tunnel.stopChan:
+			return nil
+		default:
+		}
+		connection, err := tunnel.connectE(t, clientset, config)
+		if err == nil {
+			return connection
+		}
+		select {
+		case <-tunnel.stopChan:
+			return nil
+		case <-time.After(tunnel.reconnectInterval):
+		}
+	}
+}
+
+// setConnection records the pod the tunnel forwards to and whether it is connected.
+func (tunnel *Tunnel) setConnection(podName string, connected bool) {
+	tunnel.mutex.Lock()
+	defer tunnel.mutex.Unlock()
+	tunnel.podName = podName
+	tunnel.connected = connected
+}
+
 // GetAvailablePort retrieves an available port on the host machine. This delegates the port selection to the golang net
 // library by starting a server and then checking the port that the server is using. This will fail the test if it could
 // not find an available port.
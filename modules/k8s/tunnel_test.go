@@ -16,6 +16,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/require"
+
 	http_helper "github.com/tnn-gruntwork-io/terratest/modules/http-helper"
 	"github.com/tnn-gruntwork-io/terratest/modules/random"
 )
@@ -123,3 +125,69 @@ spec:
     targetPort: 80
     port: 80
 `
+
+func TestTunnelForwardsMultiplePortsToDeployment(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(EXAMPLE_ROLLOUT_DEPLOYMENT_YAML_TEMPLATE, uniqueID, uniqueID, "nginx:1.23")
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+	WaitUntilDeploymentAvailable(t, options, "nginx-deployment", 60, 1*time.Second)
+
+	tunnel := NewTunnelWithOptions(options, ResourceTypeDeployment, "nginx-deployment", TunnelOptions{
+		Ports: []TunnelPort{{Local: 0, Remote: 80}, {Local: 0, Remote: 8080}},
+	})
+	defer tunnel.Close()
+	tunnel.ForwardPort(t)
+
+	require.NotEqual(t, tunnel.EndpointForRemotePort(80), tunnel.EndpointForRemotePort(8080))
+	require.Equal(t, tunnel.Endpoint(), tunnel.EndpointForRemotePort(80))
+	require.True(t, tunnel.IsHealthy(t))
+
+	http_helper.HttpGetWithRetryWithCustomValidation(
+		t,
+		fmt.Sprintf("http://%s", tunnel.EndpointForRemotePort(80)),
+		&tls.Config{},
+		60,
+		5*time.Second,
+		verifyNginxWelcomePage,
+	)
+}
+
+func TestTunnelReconnectsToANewPod(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(EXAMPLE_ROLLOUT_DEPLOYMENT_YAML_TEMPLATE, uniqueID, uniqueID, "nginx:1.23")
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+	WaitUntilDeploymentAvailable(t, options, "nginx-deployment", 60, 1*time.Second)
+
+	tunnel := NewTunnelWithOptions(options, ResourceTypeDeployment, "nginx-deployment", TunnelOptions{
+		Ports:         []TunnelPort{{Local: 0, Remote: 80}},
+		AutoReconnect: true,
+	})
+	defer tunnel.Close()
+	tunnel.ForwardPort(t)
+	endpoint := tunnel.Endpoint()
+
+	// Delete the pod of the tunnel, and check that the tunnel moves to another pod on the same local port
+	firstPod := tunnel.PodName()
+	RunKubectl(t, options, "delete", "pod", firstPod, "--wait=false")
+	require.Eventually(t, func() bool {
+		return tunnel.PodName() != firstPod && tunnel.IsHealthy(t)
+	}, 2*time.Minute, 1*time.Second)
+	require.Equal(t, endpoint, tunnel.Endpoint())
+
+	http_helper.HttpGetWithRetryWithCustomValidation(
+		t,
+		fmt.Sprintf("http://%s", endpoint),
+		&tls.Config{},
+		60,
+		5*time.Second,
+		verifyNginxWelcomePage,
+	)
+}
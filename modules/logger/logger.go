@@ -46,15 +46,34 @@ type TestLogger interface {
 }
 
 type Logger struct {
-	l TestLogger
+	l      TestLogger
+	fields Fields
 }
 
 func New(l TestLogger) *Logger {
 	return &Logger{
-		l,
+		l: l,
 	}
 }
 
+// WithFields returns a copy of this logger that attaches the given structured fields (in addition to any fields this
+// logger already has) to every message. Fields are only recorded if the underlying TestLogger is a FieldLogger, such
+// as the one returned by NewStructured; other loggers ignore them. This can be called on a nil Logger, in which case
+// the returned Logger will log via Default.
+func (l *Logger) WithFields(fields Fields) *Logger {
+	newLogger := &Logger{fields: Fields{}}
+	if l != nil {
+		newLogger.l = l.l
+		for key, value := range l.fields {
+			newLogger.fields[key] = value
+		}
+	}
+	for key, value := range fields {
+		newLogger.fields[key] = value
+	}
+	return newLogger
+}
+
 func (l *Logger) Logf(t testing.TestingT, format string, args ...interface{}) {
 	if tt, ok := t.(helper); ok {
 		tt.Helper()
@@ -63,10 +82,19 @@ func (l *Logger) Logf(t testing.TestingT, format string, args ...interface{}) {
 	// methods can be called on (typed) nil pointers. In this case, use the Default function to log. This enables the
 	// caller to do `var l *Logger` and then use the logger already.
 	if l == nil || l.l == nil {
+		if l != nil && len(l.fields) > 0 {
+			Default.WithFields(l.fields).Logf(t, format, args...)
+			return
+		}
 		Default.Logf(t, format, args...)
 		return
 	}
 
+	if fieldLogger, ok := l.l.(FieldLogger); ok {
+		fieldLogger.LogfWithFields(t, l.fields, format, args...)
+		return
+	}
+
 	l.l.Logf(t, format, args...)
 }
 
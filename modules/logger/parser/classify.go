@@ -0,0 +1,236 @@
+// Package logger/parser contains methods to parse and restructure log output from go testing and terratest
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/tnn-gruntwork-io/go-commons/errors"
+	"github.com/tnn-gruntwork-io/terratest/modules/terraform"
+	"github.com/ghodss/yaml"
+	junitparser "github.com/jstemmer/go-junit-report/parser"
+	"github.com/sirupsen/logrus"
+)
+
+// Causes of the built in failure rules
+const (
+	FailureCauseThrottling                 = "throttling"
+	FailureCauseQuotaExceeded              = "quota-exceeded"
+	FailureCauseProviderInconsistentResult = "provider-inconsistent-result"
+	FailureCauseHelmRepoUnreachable        = "helm-repo-unreachable"
+	FailureCauseSSHTimeout                 = "ssh-timeout"
+	FailureCauseStateLockHeld              = "state-lock-held"
+	FailureCauseKubernetesAPIUnreachable   = "kubernetes-api-unreachable"
+	FailureCausePluginDownloadFailed       = "plugin-download-failed"
+	FailureCauseTransientTerraformError    = "transient-terraform-error"
+)
+
+// FailureRule identifies a known cause of test failures by a regular expression that matches a line of the test log.
+type FailureRule struct {
+	Cause       string `json:"cause"`
+	Pattern     string `json:"pattern"`
+	Description string `json:"description,omitempty"`
+}
+
+// FailureCause is the cause a FailureClassifier found for a failed test.
+type FailureCause struct {
+	Cause       string `json:"cause"`
+	Description string `json:"description,omitempty"`
+	MatchedLine string `json:"matchedLine"`
+}
+
+// defaultFailureRules are the built in rules, which are checked before the rules derived from
+// terraform.DefaultRetryableTerraformErrors.
+var defaultFailureRules = []FailureRule{
+	{
+		Cause:       FailureCauseThrottling,
+		Pattern:     `(?i)(Throttling|Rate exceeded|RequestLimitExceeded|TooManyRequests|Too Many Requests|rateLimitExceeded)`,
+		Description: "Cloud API throttled the requests.",
+	},
+	{
+		Cause:       FailureCauseQuotaExceeded,
+		Pattern:     `(?i)(LimitExceeded|QuotaExceeded|quota exceeded|exceeded quota|Quota '.*' exceeded|insufficient quota)`,
+		Description: "Cloud account ran out of quota.",
+	},
+	{
+		Cause:       FailureCauseStateLockHeld,
+		Pattern:     `Error acquiring the state lock|ConditionalCheckFailedException.*lock|state blob is already locked`,
+		Description: "Terraform state is locked by another process.",
+	},
+	{
+		Cause:       FailureCauseHelmRepoUnreachable,
+		Pattern:     `(?i)(looks like ".*" is not a valid chart repository or cannot be reached|failed to fetch .*index\.yaml|failed to download ".*" \(hint: running .helm repo update. may help\))`,
+		Description: "Failed to reach helm charts repository.",
+	},
+	{
+		Cause:       FailureCauseSSHTimeout,
+		Pattern:     `(?i)(ssh: handshake failed.*(timeout|timed out)|dial tcp [^ ]+:22: .*(timeout|timed out)|ssh.*i/o timeout)`,
+		Description: "Timed out connecting over SSH.",
+	},
+}
+
+// Maps the messages in terraform.DefaultRetryableTerraformErrors to failure causes. Messages that are not in this map
+// are classified as FailureCauseTransientTerraformError.
+var terraformErrorCauses = map[string]string{
+	"Failed to reach helm charts repository.":                   FailureCauseHelmRepoUnreachable,
+	"Failed to reach Kubernetes API.":                           FailureCauseKubernetesAPIUnreachable,
+	"Failed to retrieve plugin due to transient network error.": FailureCausePluginDownloadFailed,
+	"Provider eventual consistency error.":                      FailureCauseProviderInconsistentResult,
+}
+
+// DefaultFailureRules returns the built in failure rules: the rules for common causes of infrastructure test failures,
+// followed by a rule for each of the errors in terraform.DefaultRetryableTerraformErrors.
+func DefaultFailureRules() []FailureRule {
+	rules := append([]FailureRule{}, defaultFailureRules...)
+
+	// Sort the patterns so that the rules are checked in a deterministic order
+	patterns := make([]string, 0, len(terraform.DefaultRetryableTerraformErrors))
+	for pattern := range terraform.DefaultRetryableTerraformErrors {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		message := terraform.DefaultRetryableTerraformErrors[pattern]
+		cause, hasCause := terraformErrorCauses[message]
+		if !hasCause {
+			cause = FailureCauseTransientTerraformError
+		}
+		rules = append(rules, FailureRule{Cause: cause, Pattern: pattern, Description: message})
+	}
+	return rules
+}
+
+// LoadFailureRules loads a list of failure rules from the given YAML or JSON file. For example:
+//
+//	# rules.yml
+//	- cause: dns-propagation
+//	  pattern: "no such host"
+//	  description: DNS record has not propagated yet.
+func LoadFailureRules(path string) ([]FailureRule, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	rules := []FailureRule{}
+	if err := yaml.Unmarshal(contents, &rules); err != nil {
+		return nil, errors.WithStackTrace(fmt.Errorf("error parsing failure rules in %s: %w", path, err))
+	}
+	return rules, nil
+}
+
+type compiledFailureRule struct {
+	FailureRule
+	regex *regexp.Regexp
+}
+
+// FailureClassifier finds the cause of failed tests by scanning their logs for known signatures.
+type FailureClassifier struct {
+	rules []compiledFailureRule
+}
+
+// NewFailureClassifier returns a FailureClassifier for the given rules. Rules are checked in order, so the first rule
+// that matches any line of a test's log determines the cause.
+func NewFailureClassifier(rules []FailureRule) (*FailureClassifier, error) {
+	classifier := &FailureClassifier{}
+	for _, rule := range rules {
+		if rule.Cause == "" {
+			return nil, errors.WithStackTrace(fmt.Errorf("failure rule with pattern %q has no cause", rule.Pattern))
+		}
+		regex, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, errors.WithStackTrace(fmt.Errorf("invalid pattern for failure cause %s: %w", rule.Cause, err))
+		}
+		classifier.rules = append(classifier.rules, compiledFailureRule{FailureRule: rule, regex: regex})
+	}
+	return classifier, nil
+}
+
+// DefaultFailureClassifier returns a FailureClassifier for DefaultFailureRules.
+func DefaultFailureClassifier() *FailureClassifier {
+	classifier, err := NewFailureClassifier(DefaultFailureRules())
+	if err != nil {
+		// The default rules are static, so this can only be a programming error
+		panic(err)
+	}
+	return classifier
+}
+
+// Classify returns the cause of the failure in the given log lines, or nil if no rule matches.
+func (classifier *FailureClassifier) Classify(lines []string) *FailureCause {
+	for _, rule := range classifier.rules {
+		for _, line := range lines {
+			if rule.regex.MatchString(line) {
+				return &FailureCause{Cause: rule.Cause, Description: rule.Description, MatchedLine: line}
+			}
+		}
+	}
+	return nil
+}
+
+// ClassifyFile returns the cause of the failure in the log file at the given path, or nil if no rule matches.
+func (classifier *FailureClassifier) ClassifyFile(path string) (*FailureCause, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	defer f.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return classifier.Classify(lines), nil
+}
+
+// classifyFailures finds the cause of each failed test in the summary from its log file, and records it in both the
+// summary and the junit report, where it is added as the first line of the test output. The tests in the summary must
+// be in the same order as in the report, as produced by summarizeReport.
+func classifyFailures(logger *logrus.Logger, outputDir string, classifier *FailureClassifier, report *junitparser.Report, summary *ReportSummary) {
+	if classifier == nil {
+		return
+	}
+
+	i := 0
+	for _, pkg := range report.Packages {
+		for _, test := range pkg.Tests {
+			testSummary := &summary.Tests[i]
+			i++
+			if test.Result != junitparser.FAIL {
+				continue
+			}
+
+			cause, err := classifier.ClassifyFile(filepath.Join(outputDir, testSummary.LogFile))
+			if err != nil {
+				logger.Warnf("Could not read log for test %s to classify failure: %s", test.Name, err)
+				continue
+			}
+			if cause == nil {
+				continue
+			}
+
+			testSummary.FailureCause = cause
+			if summary.FailureCauses == nil {
+				summary.FailureCauses = map[string]int{}
+			}
+			summary.FailureCauses[cause.Cause]++
+			test.Output = append([]string{formatFailureCause(cause)}, test.Output...)
+		}
+	}
+}
+
+func formatFailureCause(cause *FailureCause) string {
+	if cause.Description == "" {
+		return fmt.Sprintf("Failure cause: %s", cause.Cause)
+	}
+	return fmt.Sprintf("Failure cause: %s (%s)", cause.Cause, cause.Description)
+}
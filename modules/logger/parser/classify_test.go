@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	junitparser "github.com/jstemmer/go-junit-report/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultFailureClassifier(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		line          string
+		expectedCause string
+	}{
+		{"Error: error creating EC2 instance: RequestLimitExceeded: Request limit exceeded.", FailureCauseThrottling},
+		{"ThrottlingException: Rate exceeded", FailureCauseThrottling},
+		{"Error: VcpuLimitExceeded: You have requested more vCPU capacity than your current vCPU limit", FailureCauseQuotaExceeded},
+		{"Error: Quota 'CPUS' exceeded.  Limit: 24.0 in region us-central1.", FailureCauseQuotaExceeded},
+		{"Error: Provider produced inconsistent result after apply", FailureCauseProviderInconsistentResult},
+		{`Error: looks like "https://charts.example.com" is not a valid chart repository or cannot be reached`, FailureCauseHelmRepoUnreachable},
+		{"read tcp 10.0.0.1:443: read: connection reset by peer", FailureCauseHelmRepoUnreachable},
+		{"dial tcp 1.2.3.4:22: connect: connection timed out", FailureCauseSSHTimeout},
+		{"Error: Error acquiring the state lock", FailureCauseStateLockHeld},
+		{"Error: Failed to query available provider packages", FailureCausePluginDownloadFailed},
+		{"rpc error: code = Unavailable desc = transport is closing", FailureCauseKubernetesAPIUnreachable},
+	}
+
+	classifier := DefaultFailureClassifier()
+	for _, testCase := range testCases {
+		cause := classifier.Classify([]string{"some unrelated line", testCase.line})
+		if assert.NotNil(t, cause, testCase.line) {
+			assert.Equal(t, testCase.expectedCause, cause.Cause, testCase.line)
+			assert.Equal(t, testCase.line, cause.MatchedLine)
+		}
+	}
+
+	assert.Nil(t, classifier.Classify([]string{"Error: expected 1 to equal 2"}))
+}
+
+func TestDefaultFailureRulesIncludeRetryableTerraformErrors(t *testing.T) {
+	t.Parallel()
+
+	patterns := map[string]bool{}
+	for _, rule := range DefaultFailureRules() {
+		patterns[rule.Pattern] = true
+	}
+	assert.True(t, patterns[".*Provider produced inconsistent result after apply.*"])
+	assert.True(t, patterns[".*read: connection reset by peer.*"])
+}
+
+func TestNewFailureClassifierInvalidRules(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewFailureClassifier([]FailureRule{{Cause: "broken", Pattern: "("}})
+	assert.Error(t, err)
+
+	_, err = NewFailureClassifier([]FailureRule{{Pattern: "foo"}})
+	assert.Error(t, err)
+}
+
+func TestLoadFailureRules(t *testing.T) {
+	t.Parallel()
+
+	rulesFile := filepath.Join(t.TempDir(), "rules.yml")
+	require.NoError(t, os.WriteFile(rulesFile, []byte(`
+- cause: dns-propagation
+  pattern: "no such host"
+  description: DNS record has not propagated yet.
+`), 0644))
+
+	rules, err := LoadFailureRules(rulesFile)
+	require.NoError(t, err)
+	assert.Equal(t, []FailureRule{{Cause: "dns-propagation", Pattern: "no such host", Description: "DNS record has not propagated yet."}}, rules)
+
+	// User rules take precedence over the built in rules when listed first
+	classifier, err := NewFailureClassifier(append(rules, DefaultFailureRules()...))
+	require.NoError(t, err)
+	cause := classifier.Classify([]string{"Throttling: dial tcp: lookup example.com: no such host"})
+	require.NotNil(t, cause)
+	assert.Equal(t, "dns-propagation", cause.Cause)
+}
+
+func TestClassifyFailures(t *testing.T) {
+	t.Parallel()
+
+	outputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "TestThrottled.log"), []byte("TestThrottled: Error: Rate exceeded\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "TestUnknown.log"), []byte("TestUnknown: Error: boom\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "TestPassed.log"), []byte("TestPassed: Rate exceeded, retrying\n"), 0644))
+
+	report := &junitparser.Report{
+		Packages: []junitparser.Package{
+			{
+				Name: "example",
+				Tests: []*junitparser.Test{
+					{Name: "TestThrottled", Result: junitparser.FAIL, Output: []string{"Error: Rate exceeded"}},
+					{Name: "TestUnknown", Result: junitparser.FAIL, Output: []string{"Error: boom"}},
+					{Name: "TestPassed", Result: junitparser.PASS},
+				},
+			},
+		},
+	}
+	summary := summarizeReport(report)
+	classifyFailures(NewTestLogger(t), outputDir, DefaultFailureClassifier(), report, &summary)
+
+	require.NotNil(t, summary.Tests[0].FailureCause)
+	assert.Equal(t, FailureCauseThrottling, summary.Tests[0].FailureCause.Cause)
+	assert.Nil(t, summary.Tests[1].FailureCause)
+	assert.Nil(t, summary.Tests[2].FailureCause)
+	assert.Equal(t, map[string]int{FailureCauseThrottling: 1}, summary.FailureCauses)
+
+	assert.Equal(t, []string{"Failure cause: throttling (Cloud API throttled the requests.)", "Error: Rate exceeded"}, report.Packages[0].Tests[0].Output)
+	assert.Equal(t, []string{"Error: boom"}, report.Packages[0].Tests[1].Output)
+}
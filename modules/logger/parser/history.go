@@ -0,0 +1,328 @@
+// Package logger/parser contains methods to parse and restructure log output from go testing and terratest
+package parser
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tnn-gruntwork-io/go-commons/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// CommitFileName is the name of the file, next to a report.xml, that contains the commit the tests ran against.
+	CommitFileName = "commit"
+	// Relative change in average duration between the older and newer half of the runs below which we consider a test's
+	// duration stable.
+	durationTrendThreshold = 0.1
+)
+
+// RunResult is the outcome of a single test in a single run.
+type RunResult struct {
+	Result          string
+	DurationSeconds float64
+}
+
+// Run contains the test results of a single test run, as loaded from a report.xml file.
+type Run struct {
+	Name   string // The path of the folder containing the report, relative to the history folder
+	Commit string // The commit the tests ran against, or empty if unknown
+	// The results of each test, keyed by "<package>.<test name>". A test may appear several times in a run, e.g. when
+	// run with -count or retried.
+	Results map[string][]RunResult
+}
+
+// TestFlakiness summarizes the history of a single test across runs.
+type TestFlakiness struct {
+	Test string `json:"test"`
+	// The number of runs the test appeared in. Each run may have several results for the test, e.g. with -count.
+	Runs          int      `json:"runs"`
+	Passed        int      `json:"passed"`
+	Failed        int      `json:"failed"`
+	Skipped       int      `json:"skipped"`
+	PassRate      float64  `json:"passRate"`
+	Flaky         bool     `json:"flaky"`
+	FlakyCommits  []string `json:"flakyCommits,omitempty"`
+	DurationTrend string   `json:"durationTrend"`
+	// Relative change of the average duration between the older and the newer half of the runs, e.g. 0.5 means the
+	// test got 50% slower.
+	DurationChange float64 `json:"durationChange"`
+}
+
+// FlakinessReport summarizes the history of all tests across runs.
+type FlakinessReport struct {
+	Runs  int             `json:"runs"`
+	Tests []TestFlakiness `json:"tests"`
+}
+
+// junit XML structures, as written by storeJunitReport.
+type junitXMLTestSuites struct {
+	Suites []junitXMLTestSuite `xml:"testsuite"`
+}
+
+type junitXMLTestSuite struct {
+	Name      string             `xml:"name,attr"`
+	TestCases []junitXMLTestCase `xml:"testcase"`
+}
+
+type junitXMLTestCase struct {
+	Name    string    `xml:"name,attr"`
+	Time    string    `xml:"time,attr"`
+	Failure *struct{} `xml:"failure"`
+	Skipped *struct{} `xml:"skipped"`
+}
+
+// LoadRunHistory finds all the report.xml files under the given folder, such as the output folders of previous runs
+// of terratest_log_parser, and loads them as Runs. If a file named `commit` exists next to a report.xml, its contents
+// are used as the commit of that run. Runs are returned ordered by their path, so name the run folders such that they
+// sort chronologically (e.g. with a build number or timestamp).
+func LoadRunHistory(historyDir string) ([]Run, error) {
+	runs := []Run{}
+	err := filepath.Walk(historyDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Name() != "report.xml" {
+			return nil
+		}
+
+		runDir := filepath.Dir(path)
+		run, err := LoadRun(runDir)
+		if err != nil {
+			return err
+		}
+		relativeDir, err := filepath.Rel(historyDir, runDir)
+		if err == nil {
+			run.Name = relativeDir
+		}
+		runs = append(runs, run)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Name < runs[j].Name })
+	return runs, nil
+}
+
+// LoadRun loads the report.xml (and commit file, if any) in the given folder as a Run.
+func LoadRun(runDir string) (Run, error) {
+	run := Run{Name: runDir, Results: map[string][]RunResult{}}
+
+	contents, err := os.ReadFile(filepath.Join(runDir, "report.xml"))
+	if err != nil {
+		return run, errors.WithStackTrace(err)
+	}
+	var suites junitXMLTestSuites
+	if err := xml.Unmarshal(contents, &suites); err != nil {
+		return run, errors.WithStackTrace(fmt.Errorf("error parsing %s: %w", filepath.Join(runDir, "report.xml"), err))
+	}
+
+	for _, suite := range suites.Suites {
+		for _, testCase := range suite.TestCases {
+			result := "PASS"
+			switch {
+			case testCase.Failure != nil:
+				result = "FAIL"
+			case testCase.Skipped != nil:
+				result = "SKIP"
+			}
+			duration, _ := strconv.ParseFloat(testCase.Time, 64)
+			key := suite.Name + "." + testCase.Name
+			run.Results[key] = append(run.Results[key], RunResult{Result: result, DurationSeconds: duration})
+		}
+	}
+
+	commit, err := os.ReadFile(filepath.Join(runDir, CommitFileName))
+	if err == nil {
+		run.Commit = strings.TrimSpace(string(commit))
+	}
+
+	return run, nil
+}
+
+// AnalyzeFlakiness computes the pass rate, duration trend and flakiness of every test across the given runs, which
+// should be ordered from oldest to newest. A test is flaky if it both passed and failed on the same commit (including
+// within a single run, e.g. when retried). Tests are ordered with flaky tests first, then by ascending pass rate.
+func AnalyzeFlakiness(runs []Run) FlakinessReport {
+	type history struct {
+		runs            int
+		results         []RunResult
+		resultsByCommit map[string]map[string]bool
+	}
+	histories := map[string]*history{}
+
+	for i, run := range runs {
+		// Results without a known commit can only be compared within the same run
+		commitKey := "commit:" + run.Commit
+		if run.Commit == "" {
+			commitKey = fmt.Sprintf("run:%d", i)
+		}
+
+		for test, results := range run.Results {
+			h, hasKey := histories[test]
+			if !hasKey {
+				h = &history{resultsByCommit: map[string]map[string]bool{}}
+				histories[test] = h
+			}
+			h.runs++
+			if h.resultsByCommit[commitKey] == nil {
+				h.resultsByCommit[commitKey] = map[string]bool{}
+			}
+			for _, result := range results {
+				h.results = append(h.results, result)
+				h.resultsByCommit[commitKey][result.Result] = true
+			}
+		}
+	}
+
+	report := FlakinessReport{Runs: len(runs), Tests: []TestFlakiness{}}
+	for test, h := range histories {
+		flakiness := TestFlakiness{Test: test, Runs: h.runs}
+		durations := []float64{}
+		for _, result := range h.results {
+			switch result.Result {
+			case "PASS":
+				flakiness.Passed++
+			case "FAIL":
+				flakiness.Failed++
+			case "SKIP":
+				flakiness.Skipped++
+				continue
+			}
+			durations = append(durations, result.DurationSeconds)
+		}
+		if executed := flakiness.Passed + flakiness.Failed; executed > 0 {
+			flakiness.PassRate = float64(flakiness.Passed) / float64(executed)
+		}
+
+		for commitKey, results := range h.resultsByCommit {
+			if results["PASS"] && results["FAIL"] {
+				flakiness.Flaky = true
+				if strings.HasPrefix(commitKey, "commit:") {
+					flakiness.FlakyCommits = append(flakiness.FlakyCommits, strings.TrimPrefix(commitKey, "commit:"))
+				}
+			}
+		}
+		sort.Strings(flakiness.FlakyCommits)
+
+		flakiness.DurationChange, flakiness.DurationTrend = durationTrend(durations)
+		report.Tests = append(report.Tests, flakiness)
+	}
+
+	sort.Slice(report.Tests, func(i, j int) bool {
+		a, b := report.Tests[i], report.Tests[j]
+		if a.Flaky != b.Flaky {
+			return a.Flaky
+		}
+		if a.PassRate != b.PassRate {
+			return a.PassRate < b.PassRate
+		}
+		return a.Test < b.Test
+	})
+	return report
+}
+
+// durationTrend compares the average duration of the older half of the given durations with the newer half, and
+// returns the relative change along with a label: "slower", "faster", "stable", or "unknown" if there are not enough
+// data points.
+func durationTrend(durations []float64) (float64, string) {
+	if len(durations) < 2 {
+		return 0, "unknown"
+	}
+
+	half := len(durations) / 2
+	older := average(durations[:half])
+	newer := average(durations[len(durations)-half:])
+	if older == 0 {
+		if newer == 0 {
+			return 0, "stable"
+		}
+		return 0, "unknown"
+	}
+
+	change := (newer - older) / older
+	switch {
+	case change > durationTrendThreshold:
+		return change, "slower"
+	case change < -durationTrendThreshold:
+		return change, "faster"
+	default:
+		return change, "stable"
+	}
+}
+
+func average(values []float64) float64 {
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// StoreCommit records the commit the tests ran against in the output directory, so that the output directory can later
+// be used as part of the history passed to LoadRunHistory.
+func StoreCommit(logger *logrus.Logger, outputDir string, commit string) {
+	ensureDirectoryExists(logger, outputDir)
+
+	filename := filepath.Join(outputDir, CommitFileName)
+	if err := os.WriteFile(filename, []byte(commit+"\n"), 0644); err != nil {
+		logger.Errorf("Error writing commit to %s: %s", filename, err)
+	}
+}
+
+// StoreFlakinessReport stores the given report as flakiness.json and flakiness.md in the output directory.
+func StoreFlakinessReport(logger *logrus.Logger, outputDir string, report FlakinessReport) {
+	ensureDirectoryExists(logger, outputDir)
+
+	jsonFilename := filepath.Join(outputDir, "flakiness.json")
+	contents, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		logger.Errorf("Error formatting flakiness report: %s", err)
+		return
+	}
+	if err := os.WriteFile(jsonFilename, append(contents, '\n'), 0644); err != nil {
+		logger.Errorf("Error writing flakiness report to %s: %s", jsonFilename, err)
+	}
+
+	markdownFilename := filepath.Join(outputDir, "flakiness.md")
+	if err := os.WriteFile(markdownFilename, []byte(formatFlakinessMarkdown(report)), 0644); err != nil {
+		logger.Errorf("Error writing flakiness report to %s: %s", markdownFilename, err)
+	}
+}
+
+func formatFlakinessMarkdown(report FlakinessReport) string {
+	var sb strings.Builder
+	numFlaky := 0
+	for _, test := range report.Tests {
+		if test.Flaky {
+			numFlaky++
+		}
+	}
+
+	fmt.Fprintf(&sb, "# Test flakiness\n\n")
+	fmt.Fprintf(&sb, "%d tests across %d runs, %d flaky.\n\n", len(report.Tests), report.Runs, numFlaky)
+	fmt.Fprintf(&sb, "| Test | Flaky | Pass rate | Passed | Failed | Skipped | Duration trend |\n")
+	fmt.Fprintf(&sb, "| --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, test := range report.Tests {
+		flaky := "no"
+		if test.Flaky {
+			flaky = "**yes**"
+		}
+		trend := test.DurationTrend
+		if trend == "slower" || trend == "faster" {
+			trend = fmt.Sprintf("%s (%+.0f%%)", trend, test.DurationChange*100)
+		}
+		// A pipe ends the table cell even inside a code span, so escape it, e.g. for subtests named after table inputs
+		testName := strings.ReplaceAll(test.Test, "|", `\|`)
+		fmt.Fprintf(&sb, "| `%s` | %s | %.0f%% | %d | %d | %d | %s |\n", testName, flaky, test.PassRate*100, test.Passed, test.Failed, test.Skipped, trend)
+	}
+	return sb.String()
+}
@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const historyReportXML = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+	<testsuite tests="3" failures="1" time="3.000" name="example">
+		<properties>
+			<property name="go.version" value="go1.18"></property>
+		</properties>
+		<testcase classname="example" name="TestStable" time="1.000"></testcase>
+		<testcase classname="example" name="TestBroken" time="2.000">
+			<failure message="Failed" type="">boom</failure>
+		</testcase>
+		<testcase classname="example" name="TestSkipped" time="0.000">
+			<skipped message="skipped"></skipped>
+		</testcase>
+	</testsuite>
+</testsuites>
+`
+
+func TestLoadRunHistory(t *testing.T) {
+	t.Parallel()
+
+	historyDir := t.TempDir()
+	for _, run := range []string{"002", "001"} {
+		runDir := filepath.Join(historyDir, run)
+		require.NoError(t, os.MkdirAll(runDir, os.ModePerm))
+		require.NoError(t, os.WriteFile(filepath.Join(runDir, "report.xml"), []byte(historyReportXML), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(historyDir, "001", CommitFileName), []byte("abc123\n"), 0644))
+
+	runs, err := LoadRunHistory(historyDir)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+
+	assert.Equal(t, "001", runs[0].Name)
+	assert.Equal(t, "abc123", runs[0].Commit)
+	assert.Equal(t, "002", runs[1].Name)
+	assert.Equal(t, "", runs[1].Commit)
+
+	assert.Equal(t, []RunResult{{Result: "PASS", DurationSeconds: 1}}, runs[0].Results["example.TestStable"])
+	assert.Equal(t, []RunResult{{Result: "FAIL", DurationSeconds: 2}}, runs[0].Results["example.TestBroken"])
+	assert.Equal(t, []RunResult{{Result: "SKIP", DurationSeconds: 0}}, runs[0].Results["example.TestSkipped"])
+}
+
+func TestAnalyzeFlakiness(t *testing.T) {
+	t.Parallel()
+
+	runs := []Run{
+		{Commit: "a", Results: map[string][]RunResult{
+			"pkg.TestFlaky":   {{Result: "PASS", DurationSeconds: 1}},
+			"pkg.TestSlower":  {{Result: "PASS", DurationSeconds: 1}},
+			"pkg.TestChanged": {{Result: "PASS", DurationSeconds: 1}},
+		}},
+		{Commit: "a", Results: map[string][]RunResult{
+			"pkg.TestFlaky":   {{Result: "FAIL", DurationSeconds: 1}},
+			"pkg.TestSlower":  {{Result: "PASS", DurationSeconds: 1}},
+			"pkg.TestChanged": {{Result: "PASS", DurationSeconds: 1}},
+		}},
+		{Commit: "b", Results: map[string][]RunResult{
+			"pkg.TestFlaky":   {{Result: "PASS", DurationSeconds: 1}},
+			"pkg.TestSlower":  {{Result: "PASS", DurationSeconds: 3}},
+			"pkg.TestChanged": {{Result: "FAIL", DurationSeconds: 1}},
+		}},
+		// No commit, but retried within the same run
+		{Results: map[string][]RunResult{
+			"pkg.TestRetried": {{Result: "FAIL", DurationSeconds: 1}, {Result: "PASS", DurationSeconds: 1}},
+			"pkg.TestSlower":  {{Result: "PASS", DurationSeconds: 3}},
+		}},
+	}
+
+	report := AnalyzeFlakiness(runs)
+	assert.Equal(t, 4, report.Runs)
+	require.Len(t, report.Tests, 4)
+
+	byName := map[string]TestFlakiness{}
+	for _, test := range report.Tests {
+		byName[test.Test] = test
+	}
+
+	flaky := byName["pkg.TestFlaky"]
+	assert.True(t, flaky.Flaky)
+	assert.Equal(t, []string{"a"}, flaky.FlakyCommits)
+	assert.Equal(t, 3, flaky.Runs)
+	assert.InDelta(t, 2.0/3.0, flaky.PassRate, 0.001)
+
+	// Results of the same run count as a single run
+	retried := byName["pkg.TestRetried"]
+	assert.Equal(t, 1, retried.Runs)
+	assert.True(t, retried.Flaky)
+	assert.Empty(t, retried.FlakyCommits)
+
+	// Failing only on a different commit is a regression, not flakiness
+	changed := byName["pkg.TestChanged"]
+	assert.False(t, changed.Flaky)
+	assert.InDelta(t, 2.0/3.0, changed.PassRate, 0.001)
+
+	slower := byName["pkg.TestSlower"]
+	assert.False(t, slower.Flaky)
+	assert.Equal(t, 1.0, slower.PassRate)
+	assert.Equal(t, "slower", slower.DurationTrend)
+	assert.InDelta(t, 2.0, slower.DurationChange, 0.001)
+
+	// Flaky tests come first, then by ascending pass rate
+	assert.True(t, report.Tests[0].Flaky)
+	assert.True(t, report.Tests[1].Flaky)
+	assert.Equal(t, "pkg.TestChanged", report.Tests[2].Test)
+	assert.Equal(t, "pkg.TestSlower", report.Tests[3].Test)
+}
+
+func TestDurationTrend(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		durations     []float64
+		expectedTrend string
+	}{
+		{"no data", []float64{}, "unknown"},
+		{"single run", []float64{1}, "unknown"},
+		{"stable", []float64{10, 10.5, 10}, "stable"},
+		{"slower", []float64{10, 10, 20, 20}, "slower"},
+		{"faster", []float64{20, 20, 10, 10}, "faster"},
+		{"always zero", []float64{0, 0}, "stable"},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			_, trend := durationTrend(testCase.durations)
+			assert.Equal(t, testCase.expectedTrend, trend)
+		})
+	}
+}
+
+func TestFormatFlakinessMarkdownEscapesPipes(t *testing.T) {
+	t.Parallel()
+
+	report := FlakinessReport{Runs: 1, Tests: []TestFlakiness{{Test: "TestFoo/a|b", Runs: 1, Passed: 1, PassRate: 1, DurationTrend: "stable"}}}
+	assert.Contains(t, formatFlakinessMarkdown(report), "| `TestFoo/a\\|b` | no | 100% | 1 | 0 | 0 | stable |\n")
+}
+
+func TestStoreFlakinessReport(t *testing.T) {
+	t.Parallel()
+
+	outputDir := t.TempDir()
+	report := FlakinessReport{
+		Runs: 2,
+		Tests: []TestFlakiness{
+			{Test: "pkg.TestFlaky", Runs: 2, Passed: 1, Failed: 1, PassRate: 0.5, Flaky: true, FlakyCommits: []string{"a"}, DurationTrend: "slower", DurationChange: 0.5},
+		},
+	}
+	StoreFlakinessReport(NewTestLogger(t), outputDir, report)
+
+	markdown, err := os.ReadFile(filepath.Join(outputDir, "flakiness.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(markdown), "1 tests across 2 runs, 1 flaky.")
+	assert.Contains(t, string(markdown), "| `pkg.TestFlaky` | **yes** | 50% | 1 | 1 | 0 | slower (+50%) |")
+
+	_, err = os.Stat(filepath.Join(outputDir, "flakiness.json"))
+	assert.NoError(t, err)
+}
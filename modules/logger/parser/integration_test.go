@@ -70,3 +70,13 @@ func TestIntegrationNewGoExample(t *testing.T) {
 	t.Parallel()
 	testExample(t, "new_go_failing")
 }
+
+func TestIntegrationJSONExample(t *testing.T) {
+	t.Parallel()
+
+	logger := NewTestLogger(t)
+	dir := t.TempDir()
+	file := openFile(t, "./fixtures/json_example.log")
+	ParseJSONOutput(logger, file, dir)
+	assert.True(t, DirectoryEqual(t, dir, "./fixtures/json_example_expected"))
+}
@@ -0,0 +1,266 @@
+// Package logger/parser contains methods to parse and restructure log output from go testing and terratest
+package parser
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	junitparser "github.com/jstemmer/go-junit-report/parser"
+	"github.com/sirupsen/logrus"
+)
+
+// TestEvent is a single event emitted by `go test -json` (see `go doc test2json`).
+type TestEvent struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64 // seconds
+	Output  string
+}
+
+// ParseJSONOutput takes the event stream produced by `go test -json`, possibly for multiple packages, and produces the
+// same outputs as SpawnParsers: a log file per test, summary.log, report.xml and the derived reports. Unlike the text
+// parser, this does not rely on heuristics such as test names starting with `Test` or the indentation of result lines,
+// as every event says which package and test it belongs to.
+//
+// Lines that are not valid JSON (e.g. build errors that `go test` prints outside the event stream) are written to
+// summary.log.
+func ParseJSONOutput(logger *logrus.Logger, reader io.Reader, outputDir string) {
+	ParseJSONOutputWithClassifier(logger, reader, outputDir, DefaultFailureClassifier())
+}
+
+// ParseJSONOutputWithClassifier is like ParseJSONOutput, but classifies the cause of each failed test with the given
+// classifier. Pass nil to skip classification.
+func ParseJSONOutputWithClassifier(logger *logrus.Logger, reader io.Reader, outputDir string, classifier *FailureClassifier) {
+	logWriter := LogWriter{
+		lookup:    make(map[string]*os.File),
+		outputDir: outputDir,
+	}
+	defer logWriter.closeFiles(logger)
+
+	collector := newJSONReportCollector()
+
+	bufReader := bufio.NewReader(reader)
+	for {
+		data, err := bufReader.ReadString('\n')
+		if len(data) == 0 && err == io.EOF {
+			break
+		}
+		data = strings.TrimSuffix(data, "\n")
+
+		var event TestEvent
+		if jsonErr := json.Unmarshal([]byte(data), &event); jsonErr != nil || event.Action == "" {
+			logWriter.writeLog(logger, "summary", data)
+		} else {
+			collector.handleEvent(logger, logWriter, event)
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			logger.Fatalf("Error reading from Reader: %s", err)
+		}
+	}
+
+	report := collector.report()
+	summary := summarizeReport(report)
+	for i, test := range summary.Tests {
+		summary.Tests[i].LogFile = collector.getTest(collector.getPackage(test.Package), test.Name).logName + ".log"
+	}
+	storeReports(logger, outputDir, report, summary, classifier)
+}
+
+// jsonTestState tracks a single test while processing the event stream.
+type jsonTestState struct {
+	test     *junitparser.Test
+	logName  string
+	finished bool
+	panicked bool
+}
+
+// jsonPackageState tracks a single package while processing the event stream.
+type jsonPackageState struct {
+	pkg   junitparser.Package
+	tests map[string]*jsonTestState
+}
+
+// jsonReportCollector accumulates the junit report from `go test -json` events, and decides which log file each event
+// goes to.
+type jsonReportCollector struct {
+	packageOrder []string
+	packages     map[string]*jsonPackageState
+
+	// Maps a test name to the package that first used it, so that tests with the same name in different packages get
+	// separate log files.
+	testNameOwners map[string]string
+	// Maps a package to the prefix of the log files of its tests whose names are used by another package, and a prefix
+	// to its package, so that packages with the same last path element, e.g. modules/aws and test/aws, get different
+	// prefixes.
+	packagePrefixes map[string]string
+	prefixOwners    map[string]string
+}
+
+func newJSONReportCollector() *jsonReportCollector {
+	return &jsonReportCollector{
+		packages:        map[string]*jsonPackageState{},
+		testNameOwners:  map[string]string{},
+		packagePrefixes: map[string]string{},
+		prefixOwners:    map[string]string{},
+	}
+}
+
+func (collector *jsonReportCollector) getPackage(name string) *jsonPackageState {
+	pkg, hasKey := collector.packages[name]
+	if !hasKey {
+		pkg = &jsonPackageState{
+			pkg:   junitparser.Package{Name: name},
+			tests: map[string]*jsonTestState{},
+		}
+		collector.packages[name] = pkg
+		collector.packageOrder = append(collector.packageOrder, name)
+	}
+	return pkg
+}
+
+func (collector *jsonReportCollector) getTest(pkg *jsonPackageState, testName string) *jsonTestState {
+	state, hasKey := pkg.tests[testName]
+	if !hasKey {
+		state = &jsonTestState{
+			test:    &junitparser.Test{Name: testName, Result: junitparser.PASS, Output: []string{}},
+			logName: collector.logNameForTest(pkg.pkg.Name, testName),
+		}
+		pkg.tests[testName] = state
+		pkg.pkg.Tests = append(pkg.pkg.Tests, state.test)
+	}
+	return state
+}
+
+// logNameForTest returns the name of the log file (without extension) for the given test. This is the test name,
+// unless a test with the same name was already seen in another package, in which case the package gets a prefix: the
+// last element of the package path, or as many of the last elements as needed to be unique, joined with underscores.
+func (collector *jsonReportCollector) logNameForTest(packageName string, testName string) string {
+	rootTestName := strings.SplitN(testName, "/", 2)[0]
+	owner, hasOwner := collector.testNameOwners[rootTestName]
+	if !hasOwner {
+		collector.testNameOwners[rootTestName] = packageName
+		return testName
+	}
+	if owner == packageName {
+		return testName
+	}
+	return collector.packagePrefix(packageName) + "." + testName
+}
+
+// packagePrefix returns the unique prefix of the log files of the given package, e.g. "aws" for
+// github.com/foo/modules/aws, or "test_aws" for github.com/foo/test/aws if the former already uses "aws".
+func (collector *jsonReportCollector) packagePrefix(packageName string) string {
+	if prefix, hasPrefix := collector.packagePrefixes[packageName]; hasPrefix {
+		return prefix
+	}
+
+	elements := strings.Split(packageName, "/")
+	prefix := strings.ReplaceAll(packageName, "/", "_")
+	for i := len(elements) - 1; i >= 0; i-- {
+		candidate := strings.Join(elements[i:], "_")
+		if _, isUsed := collector.prefixOwners[candidate]; !isUsed {
+			prefix = candidate
+			break
+		}
+	}
+	collector.packagePrefixes[packageName] = prefix
+	collector.prefixOwners[prefix] = packageName
+	return prefix
+}
+
+func (collector *jsonReportCollector) handleEvent(logger *logrus.Logger, logWriter LogWriter, event TestEvent) {
+	pkg := collector.getPackage(event.Package)
+
+	if event.Test == "" {
+		collector.handlePackageEvent(logger, logWriter, pkg, event)
+		return
+	}
+
+	state := collector.getTest(pkg, event.Test)
+	switch event.Action {
+	case "output":
+		line := strings.TrimSuffix(event.Output, "\n")
+		logWriter.writeLog(logger, state.logName, line)
+
+		switch {
+		case isResultLine(line):
+			logWriter.writeLog(logger, "summary", line)
+		case isPanicLine(strings.TrimSpace(line)):
+			// Like the text parser, roll panics (and everything the test prints after them) up to the summary.
+			state.panicked = true
+			logWriter.writeLog(logger, "summary", line)
+		case state.panicked:
+			logWriter.writeLog(logger, "summary", line)
+		}
+
+		if !isStatusLine(line) && !isResultLine(line) && strings.TrimSpace(line) != "" {
+			state.test.Output = append(state.test.Output, strings.TrimSpace(line))
+		}
+
+	case "pass", "fail", "skip":
+		state.finished = true
+		state.test.Duration = elapsedToDuration(event.Elapsed)
+		state.test.Time = int(state.test.Duration / time.Millisecond)
+		state.test.Result = actionToResult(event.Action)
+	}
+}
+
+func (collector *jsonReportCollector) handlePackageEvent(logger *logrus.Logger, logWriter LogWriter, pkg *jsonPackageState, event TestEvent) {
+	switch event.Action {
+	case "output", "build-output":
+		logWriter.writeLog(logger, "summary", strings.TrimSuffix(event.Output, "\n"))
+
+	case "pass", "fail", "skip":
+		pkg.pkg.Duration = elapsedToDuration(event.Elapsed)
+		pkg.pkg.Time = int(pkg.pkg.Duration / time.Millisecond)
+
+		if event.Action == "fail" {
+			// Tests that never reported a result when their package failed (e.g. because another test panicked or the
+			// test binary timed out) did not pass.
+			for _, state := range pkg.tests {
+				if !state.finished {
+					state.test.Result = junitparser.FAIL
+				}
+			}
+		}
+	}
+}
+
+// report returns the junit report for all the events seen so far.
+func (collector *jsonReportCollector) report() *junitparser.Report {
+	report := &junitparser.Report{Packages: []junitparser.Package{}}
+	for _, name := range collector.packageOrder {
+		pkg := collector.packages[name]
+		// Packages without tests (e.g. "no test files") don't belong in the report
+		if len(pkg.pkg.Tests) == 0 {
+			continue
+		}
+		report.Packages = append(report.Packages, pkg.pkg)
+	}
+	return report
+}
+
+func actionToResult(action string) junitparser.Result {
+	switch action {
+	case "fail":
+		return junitparser.FAIL
+	case "skip":
+		return junitparser.SKIP
+	default:
+		return junitparser.PASS
+	}
+}
+
+func elapsedToDuration(elapsed float64) time.Duration {
+	return time.Duration(elapsed * float64(time.Second)).Round(time.Millisecond)
+}
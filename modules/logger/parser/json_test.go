@@ -0,0 +1,19 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogNameForTestPrefixesPackagesWithSameName(t *testing.T) {
+	t.Parallel()
+
+	collector := newJSONReportCollector()
+	assert.Equal(t, "TestFoo", collector.logNameForTest("github.com/foo/aws", "TestFoo"))
+	assert.Equal(t, "aws.TestFoo", collector.logNameForTest("github.com/foo/modules/aws", "TestFoo"))
+	assert.Equal(t, "test_aws.TestFoo", collector.logNameForTest("github.com/foo/test/aws", "TestFoo"))
+	assert.Equal(t, "aws.TestFoo/sub", collector.logNameForTest("github.com/foo/modules/aws", "TestFoo/sub"))
+	assert.Equal(t, "test_aws.TestFoo/sub", collector.logNameForTest("github.com/foo/test/aws", "TestFoo/sub"))
+	assert.Equal(t, "TestBar", collector.logNameForTest("github.com/foo/test/aws", "TestBar"))
+}
@@ -13,10 +13,19 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// SpawnParsers will spawn the log parser and junit report parsers off of a single reader.
+// SpawnParsers will spawn the log parser and junit report parsers off of a single reader. Once both are done, the
+// junit report is also used to produce an HTML report, a JSON summary and a listing of the slowest tests. The cause of
+// each failed test is classified with the DefaultFailureClassifier.
 func SpawnParsers(logger *logrus.Logger, reader io.Reader, outputDir string) {
+	SpawnParsersWithClassifier(logger, reader, outputDir, DefaultFailureClassifier())
+}
+
+// SpawnParsersWithClassifier is like SpawnParsers, but classifies the cause of each failed test with the given
+// classifier. Pass nil to skip classification.
+func SpawnParsersWithClassifier(logger *logrus.Logger, reader io.Reader, outputDir string, classifier *FailureClassifier) {
 	forkedReader, forkedWriter := io.Pipe()
 	teedReader := io.TeeReader(reader, forkedWriter)
+	var report *junitparser.Report
 	var waitForParsers sync.WaitGroup
 	waitForParsers.Add(2)
 	go func() {
@@ -27,14 +36,19 @@ func SpawnParsers(logger *logrus.Logger, reader io.Reader, outputDir string) {
 	}()
 	go func() {
 		defer waitForParsers.Done()
-		report, err := junitparser.Parse(forkedReader, "")
-		if err == nil {
-			storeJunitReport(logger, outputDir, report)
-		} else {
+		var err error
+		report, err = junitparser.Parse(forkedReader, "")
+		if err != nil {
 			logger.Errorf("Error parsing test output into junit report: %s", err)
 		}
 	}()
 	waitForParsers.Wait()
+
+	if report != nil {
+		// Failure classification and the HTML report read the per-test logs, so this can only run after
+		// parseAndStoreTestOutput is done.
+		storeReports(logger, outputDir, report, summarizeReport(report), classifier)
+	}
 }
 
 // RegEx for parsing test status lines. Pulled from jstemmer/go-junit-report
@@ -0,0 +1,321 @@
+// Package logger/parser contains methods to parse and restructure log output from go testing and terratest
+package parser
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tnn-gruntwork-io/go-commons/errors"
+	junitparser "github.com/jstemmer/go-junit-report/parser"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Number of tests listed in slowest.log
+	numSlowestTests = 10
+	// Maximum number of lines of a failed test's output to include as the failure excerpt
+	maxFailureExcerptLines = 20
+	// Maximum number of lines of each test's log to embed in the HTML report. Longer logs are truncated from the top,
+	// as the end of the log is usually the most interesting part.
+	maxEmbeddedLogLines = 1000
+)
+
+// TestSummary is the outcome of a single test, as recorded in summary.json and report.html.
+type TestSummary struct {
+	Package         string        `json:"package"`
+	Name            string        `json:"name"`
+	Result          string        `json:"result"`
+	DurationSeconds float64       `json:"durationSeconds"`
+	FailureExcerpt  []string      `json:"failureExcerpt,omitempty"`
+	FailureCause    *FailureCause `json:"failureCause,omitempty"`
+	LogFile         string        `json:"logFile"`
+}
+
+// ReportSummary is the outcome of a whole test run, as recorded in summary.json and report.html.
+type ReportSummary struct {
+	Total           int           `json:"total"`
+	Passed          int           `json:"passed"`
+	Failed          int           `json:"failed"`
+	Skipped         int           `json:"skipped"`
+	DurationSeconds float64       `json:"durationSeconds"`
+	Tests           []TestSummary `json:"tests"`
+	Slowest         []TestSummary `json:"slowest"`
+	// Number of failed tests for each failure cause found by the FailureClassifier
+	FailureCauses map[string]int `json:"failureCauses,omitempty"`
+}
+
+// summarizeReport converts a parsed junit report into a ReportSummary.
+func summarizeReport(report *junitparser.Report) ReportSummary {
+	summary := ReportSummary{Tests: []TestSummary{}}
+
+	for _, pkg := range report.Packages {
+		summary.DurationSeconds += pkg.Duration.Seconds()
+		for _, test := range pkg.Tests {
+			testSummary := TestSummary{
+				Package:         pkg.Name,
+				Name:            test.Name,
+				Result:          resultString(test.Result),
+				DurationSeconds: test.Duration.Seconds(),
+				LogFile:         test.Name + ".log",
+			}
+
+			summary.Total++
+			switch test.Result {
+			case junitparser.PASS:
+				summary.Passed++
+			case junitparser.FAIL:
+				summary.Failed++
+				testSummary.FailureExcerpt = failureExcerpt(test.Output)
+			case junitparser.SKIP:
+				summary.Skipped++
+			}
+
+			summary.Tests = append(summary.Tests, testSummary)
+		}
+	}
+
+	summary.Slowest = slowestTests(summary.Tests, numSlowestTests)
+	return summary
+}
+
+// slowestTests returns up to n of the given tests, ordered from slowest to fastest. Tests with the same duration keep
+// their original order.
+func slowestTests(tests []TestSummary, n int) []TestSummary {
+	sorted := make([]TestSummary, len(tests))
+	copy(sorted, tests)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].DurationSeconds > sorted[j].DurationSeconds
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// failureExcerpt returns the output go test collected for a failed test (e.g., the testify assertion message), trimmed
+// to a readable length.
+func failureExcerpt(output []string) []string {
+	excerpt := []string{}
+	for _, line := range output {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			continue
+		}
+		excerpt = append(excerpt, line)
+	}
+	if len(excerpt) > maxFailureExcerptLines {
+		excerpt = append(excerpt[:maxFailureExcerptLines], fmt.Sprintf("... (%d more lines)", len(excerpt)-maxFailureExcerptLines))
+	}
+	return excerpt
+}
+
+func resultString(result junitparser.Result) string {
+	switch result {
+	case junitparser.PASS:
+		return "PASS"
+	case junitparser.FAIL:
+		return "FAIL"
+	case junitparser.SKIP:
+		return "SKIP"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// storeReports classifies the failed tests in the report, then stores report.xml and all the reports derived from it.
+// This must be called after all the per-test logs have been written.
+func storeReports(logger *logrus.Logger, outputDir string, report *junitparser.Report, summary ReportSummary, classifier *FailureClassifier) {
+	classifyFailures(logger, outputDir, classifier, report, &summary)
+	summary.Slowest = slowestTests(summary.Tests, numSlowestTests)
+	storeJunitReport(logger, outputDir, report)
+	storeDerivedReports(logger, outputDir, summary)
+}
+
+// storeDerivedReports stores all the reports derived from the junit report: summary.json, slowest.log and report.html.
+// The HTML report embeds the per-test logs, so this must be called after they have all been written.
+func storeDerivedReports(logger *logrus.Logger, outputDir string, summary ReportSummary) {
+	storeSummaryJSON(logger, outputDir, summary)
+	storeSlowestTests(logger, outputDir, summary)
+	storeHTMLReport(logger, outputDir, summary)
+}
+
+// storeSummaryJSON stores the summary as summary.json in the output directory.
+func storeSummaryJSON(logger *logrus.Logger, outputDir string, summary ReportSummary) {
+	filename := filepath.Join(outputDir, "summary.json")
+	contents, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		logger.Errorf("Error formatting json summary: %s", err)
+		return
+	}
+	if err := os.WriteFile(filename, append(contents, '\n'), 0644); err != nil {
+		logger.Errorf("Error writing json summary to %s: %s", filename, err)
+	}
+}
+
+// storeSlowestTests stores the slowest tests of the run, one per line, as slowest.log in the output directory.
+func storeSlowestTests(logger *logrus.Logger, outputDir string, summary ReportSummary) {
+	filename := filepath.Join(outputDir, "slowest.log")
+	var sb strings.Builder
+	for _, test := range summary.Slowest {
+		fmt.Fprintf(&sb, "%10.2fs  %s  %s\n", test.DurationSeconds, test.Result, test.Name)
+	}
+	if err := os.WriteFile(filename, []byte(sb.String()), 0644); err != nil {
+		logger.Errorf("Error writing slowest tests to %s: %s", filename, err)
+	}
+}
+
+// htmlTestEntry is the data for a single row of the HTML report.
+type htmlTestEntry struct {
+	TestSummary
+	Log []string
+}
+
+// storeHTMLReport stores a self-contained HTML report as report.html in the output directory. The report embeds the
+// per-test logs written by parseAndStoreTestOutput, so this must be called after that has finished.
+func storeHTMLReport(logger *logrus.Logger, outputDir string, summary ReportSummary) {
+	entries := []htmlTestEntry{}
+	for _, test := range summary.Tests {
+		log, err := readLogTail(filepath.Join(outputDir, test.LogFile), maxEmbeddedLogLines)
+		if err != nil {
+			logger.Warnf("Could not read log for test %s to embed in html report: %s", test.Name, err)
+		}
+		entries = append(entries, htmlTestEntry{TestSummary: test, Log: log})
+	}
+
+	filename := filepath.Join(outputDir, "report.html")
+	f, err := os.Create(filename)
+	if err != nil {
+		logger.Errorf("Error making file %s for html report", filename)
+		return
+	}
+	defer f.Close()
+
+	data := struct {
+		Summary ReportSummary
+		Entries []htmlTestEntry
+	}{summary, entries}
+	if err := htmlReportTemplate.Execute(f, data); err != nil {
+		logger.Errorf("Error formatting html report: %s", err)
+	}
+}
+
+// readLogTail returns up to the last maxLines lines of the file at the given path.
+func readLogTail(path string, maxLines int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	defer f.Close()
+
+	lines := []string{}
+	omitted := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > maxLines {
+			lines = lines[1:]
+			omitted++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	if omitted > 0 {
+		lines = append([]string{fmt.Sprintf("... (%d earlier lines omitted, see %s)", omitted, filepath.Base(path))}, lines...)
+	}
+	return lines, nil
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"lower":     strings.ToLower,
+	"joinLines": func(lines []string) string { return strings.Join(lines, "\n") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Test report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #eee; cursor: pointer; user-select: none; }
+td.duration { text-align: right; white-space: nowrap; }
+.pass { color: #1a7f37; }
+.fail { color: #cf222e; font-weight: bold; }
+.skip { color: #9a6700; }
+pre { margin: 4px 0; white-space: pre-wrap; font-size: 12px; }
+pre.excerpt { background: #fff0f0; }
+p.cause { margin: 4px 0; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Test report</h1>
+<p>{{.Summary.Total}} tests: <span class="pass">{{.Summary.Passed}} passed</span>, <span class="fail">{{.Summary.Failed}} failed</span>, <span class="skip">{{.Summary.Skipped}} skipped</span> in {{printf "%.2f" .Summary.DurationSeconds}}s</p>
+<h2>Slowest tests</h2>
+<ol>
+{{- range .Summary.Slowest}}
+<li>{{.Name}} ({{printf "%.2f" .DurationSeconds}}s, <span class="{{lower .Result}}">{{.Result}}</span>)</li>
+{{- end}}
+</ol>
+<h2>All tests</h2>
+<table id="tests">
+<thead>
+<tr><th data-type="text">Package</th><th data-type="text">Test</th><th data-type="text">Result</th><th data-type="number">Duration (s)</th><th>Details</th></tr>
+</thead>
+<tbody>
+{{- range .Entries}}
+<tr>
+<td>{{.Package}}</td>
+<td>{{.Name}}</td>
+<td class="{{lower .Result}}">{{.Result}}</td>
+<td class="duration">{{printf "%.3f" .DurationSeconds}}</td>
+<td>
+{{- if .FailureCause}}
+<p class="cause">Cause: {{.FailureCause.Cause}}{{if .FailureCause.Description}} ({{.FailureCause.Description}}){{end}}</p>
+{{- end}}
+{{- if .FailureExcerpt}}
+<pre class="excerpt">{{joinLines .FailureExcerpt}}</pre>
+{{- end}}
+{{- if .Log}}
+<details><summary>Log ({{.LogFile}})</summary><pre>{{joinLines .Log}}</pre></details>
+{{- end}}
+</td>
+</tr>
+{{- end}}
+</tbody>
+</table>
+<script>
+(function () {
+  var table = document.getElementById("tests");
+  var headers = table.tHead.rows[0].cells;
+  for (var i = 0; i < headers.length; i++) {
+    (function (column, header) {
+      if (!header.dataset.type) {
+        return;
+      }
+      var ascending = true;
+      header.addEventListener("click", function () {
+        var body = table.tBodies[0];
+        var rows = Array.prototype.slice.call(body.rows);
+        rows.sort(function (a, b) {
+          var x = a.cells[column].textContent, y = b.cells[column].textContent;
+          var cmp = header.dataset.type === "number" ? parseFloat(x) - parseFloat(y) : x.localeCompare(y);
+          return ascending ? cmp : -cmp;
+        });
+        ascending = !ascending;
+        rows.forEach(function (row) { body.appendChild(row); });
+      });
+    })(i, headers[i]);
+  }
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	junitparser "github.com/jstemmer/go-junit-report/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeReport(t *testing.T) {
+	t.Parallel()
+
+	report := &junitparser.Report{
+		Packages: []junitparser.Package{
+			{
+				Name:     "example",
+				Duration: 3 * time.Second,
+				Tests: []*junitparser.Test{
+					{Name: "TestFast", Duration: 1 * time.Second, Result: junitparser.PASS},
+					{Name: "TestSlow", Duration: 2 * time.Second, Result: junitparser.FAIL, Output: []string{"foo_test.go:10:", "Error: boom", ""}},
+					{Name: "TestSkipped", Result: junitparser.SKIP},
+				},
+			},
+		},
+	}
+
+	summary := summarizeReport(report)
+	assert.Equal(t, 3, summary.Total)
+	assert.Equal(t, 1, summary.Passed)
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, 1, summary.Skipped)
+	assert.Equal(t, 3.0, summary.DurationSeconds)
+	assert.Equal(t, []string{"foo_test.go:10:", "Error: boom"}, summary.Tests[1].FailureExcerpt)
+	assert.Empty(t, summary.Tests[0].FailureExcerpt)
+
+	require.Len(t, summary.Slowest, 3)
+	assert.Equal(t, "TestSlow", summary.Slowest[0].Name)
+	assert.Equal(t, "TestFast", summary.Slowest[1].Name)
+	assert.Equal(t, "TestSkipped", summary.Slowest[2].Name)
+}
+
+func TestSlowestTestsLimitsCount(t *testing.T) {
+	t.Parallel()
+
+	tests := []TestSummary{}
+	for i := 0; i < 20; i++ {
+		tests = append(tests, TestSummary{Name: fmt.Sprintf("Test%d", i), DurationSeconds: float64(i)})
+	}
+
+	slowest := slowestTests(tests, 3)
+	require.Len(t, slowest, 3)
+	assert.Equal(t, "Test19", slowest[0].Name)
+	assert.Equal(t, "Test17", slowest[2].Name)
+	assert.Equal(t, "Test0", tests[0].Name, "input should not be reordered")
+}
+
+func TestReadLogTailTruncatesFromTheTop(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "TestFoo.log")
+	lines := []string{}
+	for i := 0; i < 10; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+
+	tail, err := readLogTail(path, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"... (7 earlier lines omitted, see TestFoo.log)", "line 7", "line 8", "line 9"}, tail)
+}
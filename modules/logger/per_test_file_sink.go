@@ -0,0 +1,201 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// CombinedLogFileName is the name of the file, in the output directory of a PerTestFileSink, that contains the logs of
+// all tests, interleaved in the order they were written.
+const CombinedLogFileName = "combined.log"
+
+// PerTestFileSink writes the log entries of each test to its own file as they happen, which is the same breakdown the
+// terratest_log_parser command produces after the fact, but without having to parse interleaved stdout:
+//
+//	outputDir
+//	  |-> combined.log
+//	  |-> TestFoo.log
+//	  |-> TestFoo/
+//	        |-> subtest.log
+//
+// Each test is keyed by t.Name(), so subtests get their own file in a folder named after the parent test, and the logs
+// of tests running in parallel with t.Parallel() never end up in each other's files. Characters that are not valid in
+// file names are replaced, and if two tests still map to the same file name, e.g. TestFoo/a:b and TestFoo/a_b, the
+// second one gets a numbered file, e.g. a_b-2.log: use LogFilePath to find the file of a test. The file of a test is
+// closed when the test ends, and reopened if anything is logged for it later. Every entry is written as a single line
+// in the TextSink format. Since the shell package logs each line of command output through the command's Logger,
+// the output of commands such as terraform ends up in the log file of the test that ran them.
+//
+// To send all Terratest logs to per-test files while still logging to stdout, set the Default logger in TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		sink, err := logger.NewPerTestFileSink("/tmp/test-logs")
+//		if err != nil {
+//			panic(err)
+//		}
+//		logger.Default = logger.NewStructured(logger.NewTextSink(os.Stdout), sink)
+//		code := m.Run()
+//		sink.Close()
+//		os.Exit(code)
+//	}
+type PerTestFileSink struct {
+	outputDir string
+
+	// Protects files. Each file is written with a single Write call per entry, so the mutex also ensures lines from
+	// different goroutines are never interleaved.
+	mutex sync.Mutex
+	// The open log files, by test name
+	files map[string]*os.File
+	// The path of the log file of each test that logged anything, and the test of each of these paths
+	paths     map[string]string
+	pathTests map[string]string
+	combined  *os.File
+}
+
+// NewPerTestFileSink returns a PerTestFileSink that writes to the given output directory, creating it if necessary.
+// Call Close when all tests are done to close the log files.
+func NewPerTestFileSink(outputDir string) (*PerTestFileSink, error) {
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	combined, err := os.Create(filepath.Join(outputDir, CombinedLogFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	return &PerTestFileSink{
+		outputDir: outputDir,
+		files:     map[string]*os.File{},
+		paths:     map[string]string{},
+		pathTests: map[string]string{},
+		combined:  combined,
+	}, nil
+}
+
+// Write appends the given entry to the log file of its test and to the combined log file.
+func (sink *PerTestFileSink) Write(entry Entry) error {
+	line := formatTextEntry(entry)
+
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	if _, err := io.WriteString(sink.combined, line); err != nil {
+		return err
+	}
+
+	file, err := sink.getOrCreateFile(entry.Test)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(file, line)
+	return err
+}
+
+// LogFilePath returns the path of the log file for the test with the given name.
+func (sink *PerTestFileSink) LogFilePath(testName string) string {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	if path, hasPath := sink.paths[testName]; hasPath {
+		return path
+	}
+	return filepath.Join(sink.outputDir, testLogFileName(testName))
+}
+
+// TestEnded closes the log file of the test with the given name. The StructuredLogger calls it when the test ends.
+func (sink *PerTestFileSink) TestEnded(testName string) {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	if file, hasFile := sink.files[testName]; hasFile {
+		file.Close()
+		delete(sink.files, testName)
+	}
+}
+
+// Close closes all the log files. Entries written after Close will return an error.
+func (sink *PerTestFileSink) Close() error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	var firstErr error
+	for _, file := range append([]*os.File{sink.combined}, filesOf(sink.files)...) {
+		if err := file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	sink.files = map[string]*os.File{}
+	return firstErr
+}
+
+// getOrCreateFile returns the open log file for the given test, creating it (and any parent folders for subtests) the
+// first time the test logs, and reopening it for appending if it was closed when the test ended. The caller must hold
+// the mutex.
+func (sink *PerTestFileSink) getOrCreateFile(testName string) (*os.File, error) {
+	if file, hasKey := sink.files[testName]; hasKey {
+		return file, nil
+	}
+
+	if path, hasPath := sink.paths[testName]; hasPath {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		sink.files[testName] = file
+		return file, nil
+	}
+
+	path := sink.uniqueLogFilePath(testName)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	sink.files[testName] = file
+	sink.paths[testName] = path
+	sink.pathTests[path] = testName
+	return file, nil
+}
+
+// uniqueLogFilePath returns the path of the log file for the given test, numbered if another test already uses the
+// same path, e.g. because their names only differ by characters that are replaced. The caller must hold the mutex.
+func (sink *PerTestFileSink) uniqueLogFilePath(testName string) string {
+	basePath := strings.TrimSuffix(filepath.Join(sink.outputDir, testLogFileName(testName)), ".log")
+	path := basePath + ".log"
+	for i := 2; ; i++ {
+		if _, isUsed := sink.pathTests[path]; !isUsed {
+			return path
+		}
+		path = fmt.Sprintf("%s-%d.log", basePath, i)
+	}
+}
+
+// testLogFileName converts a test name, such as TestFoo/subtest, into a relative file path, such as TestFoo/subtest.log.
+// Path components that could escape the output directory or that are not valid file names on some operating systems are
+// replaced.
+func testLogFileName(testName string) string {
+	components := strings.Split(testName, "/")
+	for i, component := range components {
+		component = invalidFileNameChars.Replace(component)
+		if component == "" || component == "." || component == ".." {
+			component = "_"
+		}
+		components[i] = component
+	}
+	return filepath.Join(components...) + ".log"
+}
+
+var invalidFileNameChars = strings.NewReplacer(`\`, "_", ":", "_", "*", "_", "?", "_", `"`, "_", "<", "_", ">", "_", "|", "_")
+
+func filesOf(files map[string]*os.File) []*os.File {
+	out := make([]*os.File, 0, len(files))
+	for _, file := range files {
+		out = append(out, file)
+	}
+	return out
+}
@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPerTestFileSinkSeparatesParallelSubtests(t *testing.T) {
+	t.Parallel()
+
+	outputDir := t.TempDir()
+	sink, err := NewPerTestFileSink(outputDir)
+	require.NoError(t, err)
+	l := NewStructured(sink)
+
+	l.Logf(t, "parent log")
+	t.Run("group", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			i := i // capture range variable for each subtest
+			t.Run(fmt.Sprintf("sub%d", i), func(t *testing.T) {
+				t.Parallel()
+				for j := 0; j < 50; j++ {
+					l.Logf(t, "sub%d line %d", i, j)
+				}
+			})
+		}
+	})
+	require.NoError(t, sink.Close())
+
+	parentLog := readLines(t, sink.LogFilePath(t.Name()))
+	require.Len(t, parentLog, 1)
+	assert.Contains(t, parentLog[0], "parent log")
+
+	for i := 0; i < 3; i++ {
+		subtestName := fmt.Sprintf("%s/group/sub%d", t.Name(), i)
+		subtestLog := readLines(t, filepath.Join(outputDir, t.Name(), "group", fmt.Sprintf("sub%d.log", i)))
+		require.Len(t, subtestLog, 50)
+		for j, line := range subtestLog {
+			assert.True(t, strings.HasPrefix(line, subtestName+" "), "line %q should be logged by %s", line, subtestName)
+			assert.True(t, strings.HasSuffix(line, fmt.Sprintf("sub%d line %d", i, j)), "line %q is out of order", line)
+		}
+	}
+
+	assert.Len(t, readLines(t, filepath.Join(outputDir, CombinedLogFileName)), 151)
+}
+
+func TestPerTestFileSinkNumbersCollidingFileNames(t *testing.T) {
+	t.Parallel()
+
+	sink, err := NewPerTestFileSink(t.TempDir())
+	require.NoError(t, err)
+	l := NewStructured(sink)
+
+	var colonName, underscoreName string
+	t.Run("a:b", func(t *testing.T) {
+		colonName = t.Name()
+		l.Logf(t, "colon")
+	})
+	t.Run("a_b", func(t *testing.T) {
+		underscoreName = t.Name()
+		l.Logf(t, "underscore")
+	})
+	require.NoError(t, sink.Close())
+
+	assert.NotEqual(t, sink.LogFilePath(colonName), sink.LogFilePath(underscoreName))
+	assert.True(t, strings.HasSuffix(sink.LogFilePath(underscoreName), "a_b-2.log"))
+	assert.Contains(t, readLines(t, sink.LogFilePath(colonName))[0], "colon")
+	assert.Contains(t, readLines(t, sink.LogFilePath(underscoreName))[0], "underscore")
+}
+
+func TestPerTestFileSinkClosesFileWhenTestEnds(t *testing.T) {
+	t.Parallel()
+
+	sink, err := NewPerTestFileSink(t.TempDir())
+	require.NoError(t, err)
+	defer sink.Close()
+	l := NewStructured(sink)
+
+	var subtestName string
+	var subtest *testing.T
+	t.Run("sub", func(t *testing.T) {
+		subtestName = t.Name()
+		subtest = t
+		l.Logf(t, "first line")
+		sink.mutex.Lock()
+		assert.Contains(t, sink.files, subtestName)
+		sink.mutex.Unlock()
+	})
+
+	sink.mutex.Lock()
+	assert.NotContains(t, sink.files, subtestName)
+	sink.mutex.Unlock()
+
+	// A late entry, e.g. from a cleanup function, is appended to the same file
+	l.Logf(subtest, "late line")
+	lines := readLines(t, sink.LogFilePath(subtestName))
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "first line")
+	assert.Contains(t, lines[1], "late line")
+}
+
+func TestTestLogFileName(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		testName string
+		expected string
+	}{
+		{"TestFoo", "TestFoo.log"},
+		{"TestFoo/sub", filepath.Join("TestFoo", "sub.log")},
+		{"TestFoo/../../etc", filepath.Join("TestFoo", "_", "_", "etc.log")},
+		{"TestFoo/a:b", filepath.Join("TestFoo", "a_b.log")},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, testLogFileName(testCase.testName))
+	}
+}
+
+func readLines(t *testing.T, path string) []string {
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(contents)), "\n")
+}
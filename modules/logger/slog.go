@@ -0,0 +1,35 @@
+//go:build go1.21
+// +build go1.21
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogSink forwards entries to a log/slog Handler, so Terratest logs can flow into whatever slog-based logging setup
+// you already have. Each entry becomes an Info record with test and caller attributes plus one attribute per field.
+type SlogSink struct {
+	handler slog.Handler
+}
+
+// NewSlogSink returns a SlogSink that writes to the given handler.
+func NewSlogSink(handler slog.Handler) *SlogSink {
+	return &SlogSink{handler: handler}
+}
+
+// Write converts the given entry into a slog.Record and passes it to the handler.
+func (sink *SlogSink) Write(entry Entry) error {
+	ctx := context.Background()
+	if !sink.handler.Enabled(ctx, slog.LevelInfo) {
+		return nil
+	}
+
+	record := slog.NewRecord(entry.Time, slog.LevelInfo, entry.Message, 0)
+	record.AddAttrs(slog.String(FieldTest, entry.Test), slog.String("caller", entry.Caller))
+	for _, key := range sortedKeys(entry.Fields) {
+		record.AddAttrs(slog.Any(key, entry.Fields[key]))
+	}
+	return sink.handler.Handle(ctx, record)
+}
@@ -0,0 +1,29 @@
+//go:build go1.21
+// +build go1.21
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogSink(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	l := NewStructured(NewSlogSink(slog.NewJSONHandler(&buffer, nil)))
+	l.WithFields(Fields{FieldModule: "vpc"}).Logf(t, "via slog")
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &record))
+	assert.Equal(t, "via slog", record["msg"])
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, t.Name(), record[FieldTest])
+	assert.Equal(t, "vpc", record[FieldModule])
+}
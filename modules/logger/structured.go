@@ -0,0 +1,239 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// Well-known keys for structured log fields. Terratest modules use these when attaching fields to their loggers, and
+// you are encouraged to use them in your own tests so that log consumers can rely on them.
+const (
+	FieldTest     = "test"     // The name of the test doing the logging. The StructuredLogger always records this.
+	FieldModule   = "module"   // The Terraform module, Packer template, Helm chart, etc. being tested
+	FieldCommand  = "command"  // The external command (e.g., terraform, kubectl) being run
+	FieldResource = "resource" // The cloud or Kubernetes resource being operated on
+	FieldDuration = "duration" // How long an operation took, as a time.Duration
+)
+
+// Fields are structured key/value pairs attached to a log message.
+type Fields map[string]interface{}
+
+// FieldLogger is a TestLogger that can also record structured fields. If the TestLogger passed to New implements this
+// interface, Logger calls LogfWithFields instead of Logf, passing along any fields added with Logger.WithFields.
+type FieldLogger interface {
+	TestLogger
+	LogfWithFields(t testing.TestingT, fields Fields, format string, args ...interface{})
+}
+
+// Entry is a single structured log message.
+type Entry struct {
+	Time    time.Time
+	Test    string
+	Caller  string // The file and line number that logged the message, as returned by CallerPrefix
+	Message string
+	Fields  Fields
+}
+
+// Sink receives structured log entries and writes them somewhere. Sinks must be safe for concurrent use, as parallel
+// tests log at the same time.
+type Sink interface {
+	Write(entry Entry) error
+}
+
+// TestEndedSink is a Sink that wants to know when a test ends, e.g. to close the resources it holds for that test. The
+// StructuredLogger calls TestEnded from a t.Cleanup function registered on the first entry of each test, if the
+// TestingT supports Cleanup. A sink may still receive entries for a test after TestEnded, e.g. from cleanup functions
+// of the test that run later.
+type TestEndedSink interface {
+	Sink
+	TestEnded(testName string)
+}
+
+// NewStructured returns a Logger that sends every message, along with its structured fields, to each of the given
+// sinks. For example, to keep the usual text output on stdout while also writing JSON lines to a file:
+//
+//	jsonSink, err := logger.NewJSONFileSink("/tmp/terratest.jsonl")
+//	require.NoError(t, err)
+//	defer jsonSink.Close()
+//	logger.Default = logger.NewStructured(logger.NewTextSink(os.Stdout), jsonSink)
+func NewStructured(sinks ...Sink) *Logger {
+	return New(&StructuredLogger{Sinks: sinks})
+}
+
+// StructuredLogger is a FieldLogger that turns every message into an Entry and writes it to each of its Sinks.
+type StructuredLogger struct {
+	Sinks []Sink
+
+	mutex sync.Mutex
+	// The tests for which a t.Cleanup function calls TestEnded on the sinks
+	trackedTests map[string]bool
+}
+
+// Logf logs the given format and arguments without any structured fields.
+func (sl *StructuredLogger) Logf(t testing.TestingT, format string, args ...interface{}) {
+	sl.log(t, 3, nil, fmt.Sprintf(format, args...))
+}
+
+// LogfWithFields logs the given format and arguments along with the given structured fields.
+func (sl *StructuredLogger) LogfWithFields(t testing.TestingT, fields Fields, format string, args ...interface{}) {
+	sl.log(t, 3, fields, fmt.Sprintf(format, args...))
+}
+
+func (sl *StructuredLogger) log(t testing.TestingT, callDepth int, fields Fields, message string) {
+	entry := Entry{
+		Time:    time.Now(),
+		Test:    t.Name(),
+		Caller:  CallerPrefix(callDepth + 1),
+		Message: message,
+		Fields:  fields,
+	}
+
+	sl.trackTestEnd(t)
+	for _, sink := range sl.Sinks {
+		if err := sink.Write(entry); err != nil {
+			// There is nowhere else to report a broken sink, and failing the test because of logging would be worse.
+			fmt.Fprintf(os.Stderr, "terratest: failed to write log entry: %v\n", err)
+		}
+	}
+}
+
+// trackTestEnd registers a t.Cleanup function that calls TestEnded on the sinks that implement TestEndedSink when the
+// given test ends, unless it was registered already.
+func (sl *StructuredLogger) trackTestEnd(t testing.TestingT) {
+	cleanupT, hasCleanup := t.(interface{ Cleanup(func()) })
+	if !hasCleanup {
+		return
+	}
+	sinks := []TestEndedSink{}
+	for _, sink := range sl.Sinks {
+		if testEndedSink, isTestEndedSink := sink.(TestEndedSink); isTestEndedSink {
+			sinks = append(sinks, testEndedSink)
+		}
+	}
+	if len(sinks) == 0 {
+		return
+	}
+
+	testName := t.Name()
+	sl.mutex.Lock()
+	if sl.trackedTests == nil {
+		sl.trackedTests = map[string]bool{}
+	}
+	tracked := sl.trackedTests[testName]
+	sl.trackedTests[testName] = true
+	sl.mutex.Unlock()
+	if tracked {
+		return
+	}
+
+	cleanupT.Cleanup(func() {
+		for _, sink := range sinks {
+			sink.TestEnded(testName)
+		}
+	})
+}
+
+// TextSink writes entries in the same text format as DoLog, followed by any fields as sorted key=value pairs.
+type TextSink struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+// NewTextSink returns a TextSink that writes to the given writer.
+func NewTextSink(writer io.Writer) *TextSink {
+	return &TextSink{writer: writer}
+}
+
+// Write writes the given entry as a single line of text.
+func (sink *TextSink) Write(entry Entry) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	_, err := io.WriteString(sink.writer, formatTextEntry(entry))
+	return err
+}
+
+// formatTextEntry renders the given entry as a newline-terminated line in the TextSink format.
+func formatTextEntry(entry Entry) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s %s: %s", entry.Test, entry.Time.Format(time.RFC3339), entry.Caller, entry.Message)
+	for _, key := range sortedKeys(entry.Fields) {
+		fmt.Fprintf(&sb, " %s=%v", key, entry.Fields[key])
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// JSONSink writes entries as JSON lines: one JSON object per entry, with the keys time, test, caller and msg, plus one
+// key per structured field. Fields cannot override the four fixed keys.
+type JSONSink struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	closer io.Closer
+}
+
+// NewJSONSink returns a JSONSink that writes to the given writer.
+func NewJSONSink(writer io.Writer) *JSONSink {
+	return &JSONSink{writer: writer}
+}
+
+// NewJSONFileSink returns a JSONSink that appends to the file at the given path, creating it if necessary. Call Close
+// when you're done logging to close the file.
+func NewJSONFileSink(path string) (*JSONSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONSink{writer: file, closer: file}, nil
+}
+
+// Write writes the given entry as a single JSON line.
+func (sink *JSONSink) Write(entry Entry) error {
+	object := map[string]interface{}{}
+	for key, value := range entry.Fields {
+		if err, isErr := value.(error); isErr {
+			// Most error types have no exported fields, so they would otherwise marshal to {}
+			value = err.Error()
+		}
+		object[key] = value
+	}
+	object["time"] = entry.Time.Format(time.RFC3339Nano)
+	object["test"] = entry.Test
+	object["caller"] = entry.Caller
+	object["msg"] = entry.Message
+
+	line, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	_, err = sink.writer.Write(line)
+	return err
+}
+
+// Close closes the underlying file, if this sink was created with NewJSONFileSink.
+func (sink *JSONSink) Close() error {
+	if sink.closer == nil {
+		return nil
+	}
+	return sink.closer.Close()
+}
+
+func sortedKeys(fields Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	mutex   sync.Mutex
+	entries []Entry
+}
+
+func (sink *recordingSink) Write(entry Entry) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	sink.entries = append(sink.entries, entry)
+	return nil
+}
+
+func TestStructuredLoggerRecordsFields(t *testing.T) {
+	t.Parallel()
+
+	sink := &recordingSink{}
+	l := NewStructured(sink)
+
+	l.Logf(t, "no fields")
+	l.WithFields(Fields{FieldModule: "vpc"}).WithFields(Fields{FieldCommand: "terraform"}).Logf(t, "hello %s", "world")
+
+	require.Len(t, sink.entries, 2)
+	assert.Equal(t, "no fields", sink.entries[0].Message)
+	assert.Empty(t, sink.entries[0].Fields)
+
+	entry := sink.entries[1]
+	assert.Equal(t, "hello world", entry.Message)
+	assert.Equal(t, t.Name(), entry.Test)
+	assert.Regexp(t, `^structured_test\.go:[0-9]+$`, entry.Caller)
+	assert.Equal(t, Fields{FieldModule: "vpc", FieldCommand: "terraform"}, entry.Fields)
+}
+
+func TestWithFieldsDoesNotModifyParent(t *testing.T) {
+	t.Parallel()
+
+	parent := NewStructured().WithFields(Fields{FieldModule: "vpc"})
+	child := parent.WithFields(Fields{FieldModule: "eks"})
+
+	assert.Equal(t, Fields{FieldModule: "vpc"}, parent.fields)
+	assert.Equal(t, Fields{FieldModule: "eks"}, child.fields)
+}
+
+func TestWithFieldsIgnoredByPlainLoggers(t *testing.T) {
+	t.Parallel()
+
+	c := &customLogger{}
+	New(c).WithFields(Fields{FieldModule: "vpc"}).Logf(t, "plain")
+
+	assert.Equal(t, []string{"plain"}, c.logs)
+}
+
+func TestTextSink(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+	NewStructured(NewTextSink(&buffer)).WithFields(Fields{FieldResource: "i-123", FieldCommand: "aws"}).Logf(t, "text")
+
+	assert.Regexp(t, fmt.Sprintf("^%s .+? structured_test.go:[0-9]+: text command=aws resource=i-123$", t.Name()), strings.TrimSpace(buffer.String()))
+}
+
+func TestJSONFileSink(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "log.jsonl")
+	sink, err := NewJSONFileSink(path)
+	require.NoError(t, err)
+
+	l := NewStructured(sink)
+	l.WithFields(Fields{FieldModule: "vpc", "error": fmt.Errorf("boom"), "test": "cannot override"}).Logf(t, "first")
+	l.Logf(t, "second")
+	require.NoError(t, sink.Close())
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	require.Len(t, lines, 2)
+
+	var first map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "first", first["msg"])
+	assert.Equal(t, t.Name(), first["test"])
+	assert.Equal(t, "vpc", first[FieldModule])
+	assert.Equal(t, "boom", first["error"])
+	assert.NotEmpty(t, first["time"])
+	assert.NotEmpty(t, first["caller"])
+}
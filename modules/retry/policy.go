@@ -0,0 +1,285 @@
+package retry
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/logger"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// Policy describes how an action should be retried: how many times to try, how long to sleep between attempts, and
+// which errors are worth retrying at all. The zero value runs the action exactly once.
+//
+// A Policy with a Multiplier greater than 1 backs off exponentially: the sleep before retry n is
+// InitialDelay * Multiplier^(n-1), capped at MaxDelay. With Jitter enabled, the actual sleep is chosen uniformly at
+// random between 0 and that value ("full jitter"), which spreads out retries from many parallel tests that all hit the
+// same API throttle at the same time.
+type Policy struct {
+	MaxRetries     int           // Maximum number of retries after the first attempt. If 0, retries are only bounded by MaxElapsedTime.
+	InitialDelay   time.Duration // How long to sleep before the first retry
+	MaxDelay       time.Duration // Upper bound on the sleep between retries. If 0, the sleep is not capped.
+	Multiplier     float64       // Factor by which the sleep grows after each retry. Values <= 1 mean a fixed sleep of InitialDelay.
+	MaxElapsedTime time.Duration // Stop retrying once this much time has passed since the first attempt. If 0, there is no time limit.
+	Jitter         bool          // Randomize each sleep between 0 and the computed delay
+
+	// Errors for which errors.Is reports a match are never retried.
+	FatalErrors []error
+	// If non-empty, only errors for which errors.Is matches one of these are retried; every other error is fatal.
+	RetryableErrors []error
+	// If set, decides whether an error (that is not a FatalError and does not match FatalErrors) should be retried.
+	// This takes precedence over RetryableErrors. See IsErrorType for a classifier built on errors.As.
+	IsRetryable func(err error) bool
+
+	// If set, called after every failed attempt that will be retried, before sleeping.
+	OnRetry func(attempt Attempt)
+
+	// If true, MaxRetriesExceeded and TimeoutExceeded errors keep the one-line message of DoWithRetry instead of listing
+	// the failed attempts. Set by legacyFixedPolicy, so that DoWithRetry and the functions built on it keep their
+	// messages.
+	singleLineErrors bool
+}
+
+// The maximum number of failed attempts kept in MaxRetriesExceeded and TimeoutExceeded errors: the first and last
+// maxAttemptsKept/2 attempts are kept, and the others are dropped.
+const maxAttemptsKept = 10
+
+// Attempt describes a single failed attempt at running an action under a Policy.
+type Attempt struct {
+	Number  int           // The attempt number, starting at 1
+	Err     error         // The error returned by the action
+	Elapsed time.Duration // Time since the first attempt started
+	Delay   time.Duration // How long we will sleep before the next attempt
+}
+
+// FixedPolicy returns a Policy that retries every non-fatal error up to maxRetries times, sleeping for
+// sleepBetweenRetries between attempts. This is the behavior of DoWithRetry, except that errors list the failed
+// attempts.
+func FixedPolicy(maxRetries int, sleepBetweenRetries time.Duration) Policy {
+	return Policy{MaxRetries: maxRetries, InitialDelay: sleepBetweenRetries}
+}
+
+// legacyFixedPolicy returns a FixedPolicy whose errors keep the one-line messages of DoWithRetry, for the functions
+// that predate Policy.
+func legacyFixedPolicy(maxRetries int, sleepBetweenRetries time.Duration) Policy {
+	policy := FixedPolicy(maxRetries, sleepBetweenRetries)
+	policy.singleLineErrors = true
+	return policy
+}
+
+// ExponentialPolicy returns a Policy that retries every non-fatal error up to maxRetries times, starting with a sleep
+// of initialDelay and doubling it on each retry up to maxDelay, with full jitter.
+func ExponentialPolicy(maxRetries int, initialDelay time.Duration, maxDelay time.Duration) Policy {
+	return Policy{
+		MaxRetries:   maxRetries,
+		InitialDelay: initialDelay,
+		MaxDelay:     maxDelay,
+		Multiplier:   2,
+		Jitter:       true,
+	}
+}
+
+// PolicyOrFixed returns policy if it is not nil, or a FixedPolicy with the given maxRetries and sleepBetweenRetries
+// otherwise. This is handy for functions that accept explicit retry settings but also allow an options struct to
+// override them with a Policy.
+func PolicyOrFixed(policy *Policy, maxRetries int, sleepBetweenRetries time.Duration) Policy {
+	if policy != nil {
+		return *policy
+	}
+	return FixedPolicy(maxRetries, sleepBetweenRetries)
+}
+
+// IsErrorType returns a function, suitable for use as Policy.IsRetryable, that reports whether any error in the chain
+// of the given error has the same type as example, as determined by errors.As. Only the type of example matters, so a
+// typed nil works: IsErrorType((*net.OpError)(nil)) retries only network errors.
+func IsErrorType(example error) func(err error) bool {
+	exampleType := reflect.TypeOf(example)
+	if exampleType == nil {
+		panic("retry: example passed to IsErrorType must not be a nil interface")
+	}
+
+	return func(err error) bool {
+		return errors.As(err, reflect.New(exampleType).Interface())
+	}
+}
+
+// DoWithPolicy runs the specified action, retrying it according to the given Policy. If it returns a string, return
+// that string. If retries are exhausted or the error is not retryable, fail the test.
+func DoWithPolicy(t testing.TestingT, actionDescription string, policy Policy, action func() (string, error)) string {
+	out, err := DoWithPolicyE(t, actionDescription, policy, action)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// DoWithPolicyE runs the specified action, retrying it according to the given Policy. If it returns a string, return
+// that string. If it returns a FatalError or an error the Policy classifies as not retryable, return that error
+// immediately. If MaxRetries is exceeded, return a MaxRetriesExceeded error. If MaxElapsedTime is exceeded, return a
+// TimeoutExceeded error.
+func DoWithPolicyE(t testing.TestingT, actionDescription string, policy Policy, action func() (string, error)) (string, error) {
+	out, err := DoWithPolicyInterfaceE(t, actionDescription, policy, func() (interface{}, error) { return action() })
+	return out.(string), err
+}
+
+// DoWithPolicyInterfaceE runs the specified action, retrying it according to the given Policy. If it returns a value,
+// return that value. If it returns a FatalError or an error the Policy classifies as not retryable, return that error
+// immediately. If MaxRetries is exceeded, return a MaxRetriesExceeded error. If MaxElapsedTime is exceeded, return a
+// TimeoutExceeded error. Both of these include the last error and the first and last failed attempts.
+func DoWithPolicyInterfaceE(t testing.TestingT, actionDescription string, policy Policy, action func() (interface{}, error)) (interface{}, error) {
+	var output interface{}
+	var err error
+	var attempts []Attempt
+
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		logger.Log(t, actionDescription)
+
+		output, err = action()
+		if err == nil {
+			return output, nil
+		}
+
+		if !policy.isRetryable(err) {
+			logger.Logf(t, "Returning due to fatal error: %v", err)
+			return output, err
+		}
+
+		elapsed := time.Since(start)
+
+		retriesSoFar := attempt - 1
+		if policy.MaxRetries > 0 || policy.MaxElapsedTime <= 0 {
+			if retriesSoFar >= policy.MaxRetries {
+				attempts = recordAttempt(attempts, Attempt{Number: attempt, Err: err, Elapsed: elapsed})
+				return output, MaxRetriesExceeded{Description: actionDescription, MaxRetries: policy.MaxRetries, LastError: err, Attempts: attempts, listAttempts: !policy.singleLineErrors}
+			}
+		}
+
+		delay := policy.delayForRetry(attempt)
+		if policy.MaxElapsedTime > 0 && elapsed+delay > policy.MaxElapsedTime {
+			logger.Logf(t, "%s returned an error: %s. Not retrying as the next attempt would exceed the maximum elapsed time of %s.", actionDescription, err.Error(), policy.MaxElapsedTime)
+			attempts = recordAttempt(attempts, Attempt{Number: attempt, Err: err, Elapsed: elapsed})
+			return output, TimeoutExceeded{Description: actionDescription, Timeout: policy.MaxElapsedTime, LastError: err, Attempts: attempts, listAttempts: !policy.singleLineErrors}
+		}
+
+		currentAttempt := Attempt{Number: attempt, Err: err, Elapsed: elapsed, Delay: delay}
+		attempts = recordAttempt(attempts, currentAttempt)
+		if policy.OnRetry != nil {
+			policy.OnRetry(currentAttempt)
+		}
+
+		logger.Logf(t, "%s returned an error: %s. Sleeping for %s and will try again.", actionDescription, err.Error(), delay)
+		time.Sleep(delay)
+	}
+}
+
+// DoWithRetryableErrorsAndPolicyE runs the specified action. If it returns a value, return that value. If it returns
+// an error, check if error message or the string output from the action matches any of the regular expressions in the
+// specified retryableErrors map. If there is a match, retry the action according to the given Policy. If there is no
+// match, return that error immediately, wrapped in a FatalError.
+func DoWithRetryableErrorsAndPolicyE(t testing.TestingT, actionDescription string, retryableErrors map[string]string, policy Policy, action func() (string, error)) (string, error) {
+	retryableErrorsRegexp := map[*regexp.Regexp]string{}
+	for errorStr, errorMessage := range retryableErrors {
+		errorRegex, err := regexp.Compile(errorStr)
+		if err != nil {
+			return "", FatalError{Underlying: err}
+		}
+		retryableErrorsRegexp[errorRegex] = errorMessage
+	}
+
+	return DoWithPolicyE(t, actionDescription, policy, func() (string, error) {
+		output, err := action()
+		if err == nil {
+			return output, nil
+		}
+
+		for errorRegexp, errorMessage := range retryableErrorsRegexp {
+			if errorRegexp.MatchString(output) || errorRegexp.MatchString(err.Error()) {
+				logger.Logf(t, "'%s' failed with the error '%s' but this error was expected and warrants a retry. Further details: %s\n", actionDescription, err.Error(), errorMessage)
+				return output, err
+			}
+		}
+
+		return output, FatalError{Underlying: err}
+	})
+}
+
+// recordAttempt appends the given attempt to the given attempts, dropping the oldest attempt after the first
+// maxAttemptsKept/2 if there are too many, so that an action retried hundreds of times doesn't produce a huge error.
+func recordAttempt(attempts []Attempt, attempt Attempt) []Attempt {
+	if len(attempts) == maxAttemptsKept {
+		attempts = append(attempts[:maxAttemptsKept/2], attempts[maxAttemptsKept/2+1:]...)
+	}
+	return append(attempts, attempt)
+}
+
+// isRetryable returns true if the given error should be retried under this policy.
+func (policy Policy) isRetryable(err error) bool {
+	var fatalErr FatalError
+	if errors.As(err, &fatalErr) {
+		return false
+	}
+
+	for _, fatal := range policy.FatalErrors {
+		if errors.Is(err, fatal) {
+			return false
+		}
+	}
+
+	if policy.IsRetryable != nil {
+		return policy.IsRetryable(err)
+	}
+
+	if len(policy.RetryableErrors) == 0 {
+		return true
+	}
+
+	for _, retryable := range policy.RetryableErrors {
+		if errors.Is(err, retryable) {
+			return true
+		}
+	}
+	return false
+}
+
+// delayForRetry returns how long to sleep after the given (1-based) failed attempt.
+func (policy Policy) delayForRetry(attempt int) time.Duration {
+	delay := float64(policy.InitialDelay)
+	if policy.Multiplier > 1 {
+		delay *= math.Pow(policy.Multiplier, float64(attempt-1))
+	}
+	if policy.MaxDelay > 0 && delay > float64(policy.MaxDelay) {
+		delay = float64(policy.MaxDelay)
+	}
+	// Guard against overflow for large attempt counts with no MaxDelay
+	if delay > math.MaxInt64 {
+		delay = math.MaxInt64
+	}
+
+	if policy.Jitter && delay > 0 {
+		delay = jitterSource.float64() * delay
+	}
+
+	return time.Duration(delay)
+}
+
+// lockedRand is a math/rand source that is safe for concurrent use. We don't use the global source as, depending on the
+// Go version, it may not be seeded, in which case parallel test processes would all pick the same "random" delays.
+type lockedRand struct {
+	mutex sync.Mutex
+	rand  *rand.Rand
+}
+
+func (r *lockedRand) float64() float64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.rand.Float64()
+}
+
+var jitterSource = &lockedRand{rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
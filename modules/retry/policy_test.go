@@ -0,0 +1,112 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoWithPolicyExponentialBackoff(t *testing.T) {
+	t.Parallel()
+
+	expectedError := fmt.Errorf("expected error")
+	policy := Policy{
+		MaxRetries:   5,
+		InitialDelay: 1 * time.Millisecond,
+		MaxDelay:     8 * time.Millisecond,
+		Multiplier:   2,
+	}
+
+	var delays []time.Duration
+	policy.OnRetry = func(attempt Attempt) {
+		assert.Equal(t, len(delays)+1, attempt.Number)
+		assert.Equal(t, expectedError, attempt.Err)
+		delays = append(delays, attempt.Delay)
+	}
+
+	_, err := DoWithPolicyE(t, t.Name(), policy, func() (string, error) { return "", expectedError })
+	assertRetryError(t, MaxRetriesExceeded{Description: t.Name(), MaxRetries: 5}, err)
+	assert.Equal(t, []time.Duration{1 * time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 8 * time.Millisecond, 8 * time.Millisecond}, delays)
+}
+
+func TestDoWithPolicyJitterStaysWithinBounds(t *testing.T) {
+	t.Parallel()
+
+	policy := ExponentialPolicy(10, 1*time.Millisecond, 4*time.Millisecond)
+	for attempt := 1; attempt <= 10; attempt++ {
+		delay := policy.delayForRetry(attempt)
+		assert.True(t, delay >= 0, "delay %s should not be negative", delay)
+		assert.True(t, delay <= 4*time.Millisecond, "delay %s should not exceed max delay", delay)
+	}
+}
+
+func TestDoWithPolicyMaxElapsedTime(t *testing.T) {
+	t.Parallel()
+
+	policy := Policy{InitialDelay: 10 * time.Millisecond, MaxElapsedTime: 55 * time.Millisecond}
+
+	count := 0
+	_, err := DoWithPolicyE(t, t.Name(), policy, func() (string, error) {
+		count++
+		return "", fmt.Errorf("expected error")
+	})
+	timeoutErr, isTimeoutErr := err.(TimeoutExceeded)
+	require.True(t, isTimeoutErr, "expected a TimeoutExceeded error, but got %v", err)
+	assert.Equal(t, 55*time.Millisecond, timeoutErr.Timeout)
+	assert.Len(t, timeoutErr.Attempts, count)
+	assert.True(t, count > 1, "action should have been retried, but ran %d times", count)
+}
+
+func TestDoWithPolicyErrorClassification(t *testing.T) {
+	t.Parallel()
+
+	errRetryable := errors.New("retryable")
+	errFatal := errors.New("fatal")
+	errOther := errors.New("other")
+
+	testCases := []struct {
+		description   string
+		policy        Policy
+		err           error
+		expectedCalls int
+	}{
+		{"Retry any error by default", Policy{MaxRetries: 2}, errOther, 3},
+		{"FatalError is never retried", Policy{MaxRetries: 2}, FatalError{Underlying: errOther}, 1},
+		{"Wrapped FatalError is never retried", Policy{MaxRetries: 2}, fmt.Errorf("wrapped: %w", FatalError{Underlying: errOther}), 1},
+		{"Retry wrapped errors listed in RetryableErrors", Policy{MaxRetries: 2, RetryableErrors: []error{errRetryable}}, fmt.Errorf("wrapped: %w", errRetryable), 3},
+		{"Do not retry errors not listed in RetryableErrors", Policy{MaxRetries: 2, RetryableErrors: []error{errRetryable}}, errOther, 1},
+		{"Do not retry errors listed in FatalErrors", Policy{MaxRetries: 2, FatalErrors: []error{errFatal}}, fmt.Errorf("wrapped: %w", errFatal), 1},
+		{"Retry errors matching IsErrorType", Policy{MaxRetries: 2, IsRetryable: IsErrorType((*os.PathError)(nil))}, fmt.Errorf("wrapped: %w", &os.PathError{Op: "open", Err: errOther}), 3},
+		{"Do not retry errors not matching IsErrorType", Policy{MaxRetries: 2, IsRetryable: IsErrorType((*os.PathError)(nil))}, errOther, 1},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase // capture range variable for each test case
+
+		t.Run(testCase.description, func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+			_, err := DoWithPolicyE(t, testCase.description, testCase.policy, func() (string, error) {
+				calls++
+				return "", testCase.err
+			})
+			require.Error(t, err)
+			assert.Equal(t, testCase.expectedCalls, calls)
+		})
+	}
+}
+
+func TestPolicyOrFixed(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, FixedPolicy(3, time.Second), PolicyOrFixed(nil, 3, time.Second))
+
+	policy := ExponentialPolicy(5, time.Second, time.Minute)
+	assert.Equal(t, policy.MaxDelay, PolicyOrFixed(&policy, 3, time.Second).MaxDelay)
+}
@@ -3,7 +3,7 @@ package retry
 
 import (
 	"fmt"
-	"regexp"
+	"strings"
 	"time"
 
 	"github.com/stretchr/testify/require"
@@ -84,27 +84,7 @@ func DoWithRetryInterface(t testing.TestingT, actionDescription string, maxRetri
 // immediately. If it returns any other type of error, sleep for sleepBetweenRetries and try again, up to a maximum of
 // maxRetries retries. If maxRetries is exceeded, return a MaxRetriesExceeded error.
 func DoWithRetryInterfaceE(t testing.TestingT, actionDescription string, maxRetries int, sleepBetweenRetries time.Duration, action func() (interface{}, error)) (interface{}, error) {
-	var output interface{}
-	var err error
-
-	for i := 0; i <= maxRetries; i++ {
-		logger.Log(t, actionDescription)
-
-		output, err = action()
-		if err == nil {
-			return output, nil
-		}
-
-		if _, isFatalErr := err.(FatalError); isFatalErr {
-			logger.Logf(t, "Returning due to fatal error: %v", err)
-			return output, err
-		}
-
-		logger.Logf(t, "%s returned an error: %s. Sleeping for %s and will try again.", actionDescription, err.Error(), sleepBetweenRetries)
-		time.Sleep(sleepBetweenRetries)
-	}
-
-	return output, MaxRetriesExceeded{Description: actionDescription, MaxRetries: maxRetries}
+	return DoWithPolicyInterfaceE(t, actionDescription, legacyFixedPolicy(maxRetries, sleepBetweenRetries), action)
 }
 
 // DoWithRetryableErrors runs the specified action. If it returns a value, return that value. If it returns an error,
@@ -124,30 +104,7 @@ func DoWithRetryableErrors(t testing.TestingT, actionDescription string, retryab
 // sleepBetweenRetries, and retry the specified action, up to a maximum of maxRetries retries. If there is no match,
 // return that error immediately, wrapped in a FatalError. If maxRetries is exceeded, return a MaxRetriesExceeded error.
 func DoWithRetryableErrorsE(t testing.TestingT, actionDescription string, retryableErrors map[string]string, maxRetries int, sleepBetweenRetries time.Duration, action func() (string, error)) (string, error) {
-	retryableErrorsRegexp := map[*regexp.Regexp]string{}
-	for errorStr, errorMessage := range retryableErrors {
-		errorRegex, err := regexp.Compile(errorStr)
-		if err != nil {
-			return "", FatalError{Underlying: err}
-		}
-		retryableErrorsRegexp[errorRegex] = errorMessage
-	}
-
-	return DoWithRetryE(t, actionDescription, maxRetries, sleepBetweenRetries, func() (string, error) {
-		output, err := action()
-		if err == nil {
-			return output, nil
-		}
-
-		for errorRegexp, errorMessage := range retryableErrorsRegexp {
-			if errorRegexp.MatchString(output) || errorRegexp.MatchString(err.Error()) {
-				logger.Logf(t, "'%s' failed with the error '%s' but this error was expected and warrants a retry. Further details: %s\n", actionDescription, err.Error(), errorMessage)
-				return output, err
-			}
-		}
-
-		return output, FatalError{Underlying: err}
-	})
+	return DoWithRetryableErrorsAndPolicyE(t, actionDescription, retryableErrors, legacyFixedPolicy(maxRetries, sleepBetweenRetries), action)
 }
 
 // Done can be stopped.
@@ -192,20 +149,78 @@ func DoInBackgroundUntilStopped(t testing.TestingT, actionDescription string, sl
 type TimeoutExceeded struct {
 	Description string
 	Timeout     time.Duration
+	LastError   error     // The error returned by the last attempt, if the action was retried under a Policy
+	Attempts    []Attempt // The first and last failed attempts, if the action was retried under a Policy
+
+	// Whether Error lists the attempts, i.e. whether the action was not retried by DoWithRetry or a function built on it
+	listAttempts bool
 }
 
 func (err TimeoutExceeded) Error() string {
-	return fmt.Sprintf("'%s' did not complete before timeout of %s", err.Description, err.Timeout)
+	message := fmt.Sprintf("'%s' did not complete before timeout of %s", err.Description, err.Timeout)
+	if err.listAttempts {
+		message += formatAttempts(err.Attempts)
+	}
+	return message
+}
+
+// Unwrap returns the error returned by the last attempt, if any. Note that errors.Is and errors.As therefore match the
+// last error too, e.g. errors.Is(err, context.DeadlineExceeded) is true if the last attempt timed out.
+func (err TimeoutExceeded) Unwrap() error {
+	return err.LastError
 }
 
 // MaxRetriesExceeded is an error that occurs when the maximum amount of retries is exceeded.
 type MaxRetriesExceeded struct {
 	Description string
 	MaxRetries  int
+	LastError   error     // The error returned by the last attempt
+	Attempts    []Attempt // The first and last failed attempts, in order
+
+	// Whether Error lists the attempts, i.e. whether the action was not retried by DoWithRetry or a function built on it
+	listAttempts bool
 }
 
 func (err MaxRetriesExceeded) Error() string {
-	return fmt.Sprintf("'%s' unsuccessful after %d retries", err.Description, err.MaxRetries)
+	message := fmt.Sprintf("'%s' unsuccessful after %d retries", err.Description, err.MaxRetries)
+	if err.listAttempts {
+		message += formatAttempts(err.Attempts)
+	}
+	return message
+}
+
+// Unwrap returns the error returned by the last attempt, if any. Note that errors.Is and errors.As therefore match the
+// last error too, e.g. errors.As(err, &awsErr) succeeds if the last attempt failed with an AWS error.
+func (err MaxRetriesExceeded) Unwrap() error {
+	return err.LastError
+}
+
+// formatAttempts renders the history of failed attempts for inclusion in an error message. Consecutive attempts that
+// failed with the same error are collapsed into a single line, as polling loops tend to see the same error many times.
+func formatAttempts(attempts []Attempt) string {
+	if len(attempts) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(":")
+	for i := 0; i < len(attempts); {
+		first := attempts[i]
+		if i > 0 && first.Number > attempts[i-1].Number+1 {
+			fmt.Fprintf(&sb, "\n\t(%d attempts omitted)", first.Number-attempts[i-1].Number-1)
+		}
+		j := i + 1
+		for j < len(attempts) && attempts[j].Number == attempts[j-1].Number+1 && attempts[j].Err.Error() == first.Err.Error() {
+			j++
+		}
+		if j-i == 1 {
+			fmt.Fprintf(&sb, "\n\tattempt %d (after %s): %v", first.Number, first.Elapsed.Round(time.Millisecond), first.Err)
+		} else {
+			fmt.Fprintf(&sb, "\n\tattempts %d-%d (after %s): %v", first.Number, attempts[j-1].Number, first.Elapsed.Round(time.Millisecond), first.Err)
+		}
+		i = j
+	}
+	return sb.String()
 }
 
 // FatalError is a marker interface for errors that should not be retried.
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDoWithRetry(t *testing.T) {
@@ -49,7 +50,7 @@ func TestDoWithRetry(t *testing.T) {
 			actualOutput, err := DoWithRetryE(t, testCase.description, testCase.maxRetries, 1*time.Millisecond, testCase.action)
 			assert.Equal(t, expectedOutput, actualOutput)
 			if testCase.expectedError != nil {
-				assert.Equal(t, testCase.expectedError, err)
+				assertRetryError(t, testCase.expectedError, err)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, expectedOutput, actualOutput)
@@ -247,7 +248,7 @@ func TestDoWithRetryableErrors(t *testing.T) {
 			actualOutput, err := DoWithRetryableErrorsE(t, testCase.description, testCase.retryableErrors, testCase.maxRetries, 1*time.Millisecond, testCase.action)
 			assert.Equal(t, expectedOutput, actualOutput)
 			if testCase.expectedError != nil {
-				assert.Equal(t, testCase.expectedError, err)
+				assertRetryError(t, testCase.expectedError, err)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, expectedOutput, actualOutput)
@@ -256,6 +257,28 @@ func TestDoWithRetryableErrors(t *testing.T) {
 	}
 }
 
+// assertRetryError checks that actual matches expected. For MaxRetriesExceeded errors, the attempt history includes
+// timings that vary from run to run, so we only check that it has one entry per kept attempt and ends with the last
+// error.
+func assertRetryError(t *testing.T, expected error, actual error) {
+	expectedMaxRetriesErr, isMaxRetriesErr := expected.(MaxRetriesExceeded)
+	if !isMaxRetriesErr {
+		assert.Equal(t, expected, actual)
+		return
+	}
+
+	actualMaxRetriesErr, isMaxRetriesErr := actual.(MaxRetriesExceeded)
+	require.True(t, isMaxRetriesErr, "expected a MaxRetriesExceeded error, but got %v", actual)
+	assert.Equal(t, expectedMaxRetriesErr.Description, actualMaxRetriesErr.Description)
+	assert.Equal(t, expectedMaxRetriesErr.MaxRetries, actualMaxRetriesErr.MaxRetries)
+	expectedAttempts := expectedMaxRetriesErr.MaxRetries + 1
+	if expectedAttempts > maxAttemptsKept {
+		expectedAttempts = maxAttemptsKept
+	}
+	require.Len(t, actualMaxRetriesErr.Attempts, expectedAttempts)
+	assert.Equal(t, actualMaxRetriesErr.Attempts[len(actualMaxRetriesErr.Attempts)-1].Err, actualMaxRetriesErr.LastError)
+}
+
 type ErrorCounter int
 
 func (count ErrorCounter) Error() string {
@@ -0,0 +1,82 @@
+package retry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// Do runs the specified action, retrying it according to the given Policy, and returns its typed result. If retries
+// are exhausted or the error is not retryable, fail the test.
+func Do[T any](t testing.TestingT, actionDescription string, policy Policy, action func() (T, error)) T {
+	out, err := DoE(t, actionDescription, policy, action)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// DoE runs the specified action, retrying it according to the given Policy, and returns its typed result. If it
+// returns a FatalError or an error the Policy classifies as not retryable, return that error immediately. If the
+// Policy's limits are exceeded, return a MaxRetriesExceeded or TimeoutExceeded error that includes the last error and
+// the first and last failed attempts.
+func DoE[T any](t testing.TestingT, actionDescription string, policy Policy, action func() (T, error)) (T, error) {
+	out, err := DoWithPolicyInterfaceE(t, actionDescription, policy, func() (interface{}, error) { return action() })
+	// out is nil if T is an interface type and the action returned a nil value, in which case we return the zero value.
+	result, _ := out.(T)
+	return result, err
+}
+
+// Eventually runs the specified action every interval until it succeeds, and returns its typed result. If it has not
+// succeeded within timeout, fail the test.
+func Eventually[T any](t testing.TestingT, actionDescription string, timeout time.Duration, interval time.Duration, action func() (T, error)) T {
+	out, err := EventuallyE(t, actionDescription, timeout, interval, action)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// EventuallyE runs the specified action every interval until it succeeds, and returns its typed result. If it returns
+// a FatalError, return that error immediately. If it has not succeeded within timeout, return a TimeoutExceeded error
+// that includes the last error and the first and last failed attempts.
+func EventuallyE[T any](t testing.TestingT, actionDescription string, timeout time.Duration, interval time.Duration, action func() (T, error)) (T, error) {
+	return DoE(t, actionDescription, Policy{InitialDelay: interval, MaxElapsedTime: timeout}, action)
+}
+
+// Until polls the specified condition according to the given Policy until it returns true. If the condition is never
+// met or returns a non-retryable error, fail the test.
+func Until(t testing.TestingT, conditionDescription string, policy Policy, condition func() (bool, error)) {
+	err := UntilE(t, conditionDescription, policy, condition)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// UntilE polls the specified condition according to the given Policy until it returns true. Each time the condition
+// returns false, the attempt is recorded as a ConditionNotMet error. If the condition returns an error, it is
+// classified by the Policy like any other action error. If the Policy's limits are exceeded, return a
+// MaxRetriesExceeded or TimeoutExceeded error that includes the first and last failed attempts.
+func UntilE(t testing.TestingT, conditionDescription string, policy Policy, condition func() (bool, error)) error {
+	_, err := DoE(t, fmt.Sprintf("Waiting until %s", conditionDescription), policy, func() (bool, error) {
+		met, err := condition()
+		if err != nil {
+			return false, err
+		}
+		if !met {
+			return false, ConditionNotMet{Description: conditionDescription}
+		}
+		return true, nil
+	})
+	return err
+}
+
+// ConditionNotMet is an error that occurs when a condition polled with Until is not (yet) true.
+type ConditionNotMet struct {
+	Description string
+}
+
+func (err ConditionNotMet) Error() string {
+	return fmt.Sprintf("condition '%s' not met", err.Description)
+}
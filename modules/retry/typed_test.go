@@ -0,0 +1,130 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type typedResult struct {
+	Name  string
+	Count int
+}
+
+func TestDoReturnsTypedResult(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	result, err := DoE(t, t.Name(), FixedPolicy(5, 1*time.Millisecond), func() (typedResult, error) {
+		count++
+		if count < 3 {
+			return typedResult{}, fmt.Errorf("not yet")
+		}
+		return typedResult{Name: "done", Count: count}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, typedResult{Name: "done", Count: 3}, result)
+}
+
+func TestDoReturnsZeroValueForNilInterface(t *testing.T) {
+	t.Parallel()
+
+	result, err := DoE(t, t.Name(), FixedPolicy(0, 0), func() (error, error) { return nil, nil })
+	require.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestDoIncludesAttemptHistoryInError(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	_, err := DoE(t, t.Name(), Policy{MaxRetries: 3, InitialDelay: 1 * time.Millisecond}, func() (int, error) {
+		count++
+		if count == 1 {
+			return 0, fmt.Errorf("connection refused")
+		}
+		return 0, fmt.Errorf("status 503")
+	})
+
+	var maxRetriesErr MaxRetriesExceeded
+	require.True(t, errors.As(err, &maxRetriesErr))
+	require.Len(t, maxRetriesErr.Attempts, 4)
+	assert.EqualError(t, maxRetriesErr.LastError, "status 503")
+	assert.Contains(t, err.Error(), "attempt 1 ")
+	assert.Contains(t, err.Error(), "connection refused")
+	assert.Contains(t, err.Error(), "attempts 2-4 ")
+	assert.Contains(t, err.Error(), "status 503")
+}
+
+func TestDoKeepsFirstAndLastAttempts(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	_, err := DoE(t, t.Name(), Policy{MaxRetries: 29}, func() (int, error) {
+		count++
+		return 0, fmt.Errorf("error %d", count)
+	})
+
+	var maxRetriesErr MaxRetriesExceeded
+	require.True(t, errors.As(err, &maxRetriesErr))
+	require.Len(t, maxRetriesErr.Attempts, maxAttemptsKept)
+	assert.Equal(t, 5, maxRetriesErr.Attempts[4].Number)
+	assert.Equal(t, 26, maxRetriesErr.Attempts[5].Number)
+	assert.Equal(t, 30, maxRetriesErr.Attempts[9].Number)
+	assert.Contains(t, err.Error(), "(20 attempts omitted)")
+	assert.NotContains(t, err.Error(), "attempt 6 ")
+}
+
+func TestOnlyDoWithRetryKeepsOneLineError(t *testing.T) {
+	t.Parallel()
+
+	_, err := DoWithRetryE(t, t.Name(), 3, 1*time.Millisecond, func() (string, error) {
+		return "", fmt.Errorf("status 503")
+	})
+	assert.EqualError(t, err, fmt.Sprintf("'%s' unsuccessful after 3 retries", t.Name()))
+
+	_, err = DoE(t, t.Name(), FixedPolicy(3, 1*time.Millisecond), func() (string, error) {
+		return "", fmt.Errorf("status 503")
+	})
+	assert.Contains(t, err.Error(), fmt.Sprintf("'%s' unsuccessful after 3 retries", t.Name()))
+	assert.Contains(t, err.Error(), "status 503")
+
+	_, err = EventuallyE(t, t.Name(), 20*time.Millisecond, 5*time.Millisecond, func() (string, error) {
+		return "", fmt.Errorf("status 503")
+	})
+	assert.Contains(t, err.Error(), fmt.Sprintf("'%s' did not complete before timeout of 20ms", t.Name()))
+	assert.Regexp(t, `\n\tattempts? 1\b`, err.Error())
+	assert.Contains(t, err.Error(), "status 503")
+}
+
+func TestEventuallyTimesOut(t *testing.T) {
+	t.Parallel()
+
+	_, err := EventuallyE(t, t.Name(), 50*time.Millisecond, 10*time.Millisecond, func() (string, error) {
+		return "", fmt.Errorf("never succeeds")
+	})
+
+	var timeoutErr TimeoutExceeded
+	require.True(t, errors.As(err, &timeoutErr))
+	assert.NotEmpty(t, timeoutErr.Attempts)
+	assert.EqualError(t, errors.Unwrap(err), "never succeeds")
+}
+
+func TestUntil(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	err := UntilE(t, "count reaches 3", FixedPolicy(5, 1*time.Millisecond), func() (bool, error) {
+		count++
+		return count >= 3, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	err = UntilE(t, "never true", FixedPolicy(2, 1*time.Millisecond), func() (bool, error) { return false, nil })
+	assert.True(t, errors.Is(err, ConditionNotMet{Description: "never true"}))
+}
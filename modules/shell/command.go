@@ -10,6 +10,7 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/tnn-gruntwork-io/terratest/modules/logger"
 	"github.com/tnn-gruntwork-io/terratest/modules/testing"
@@ -97,6 +98,7 @@ func (e *ErrWithCmdOutput) Error() string {
 // stdout and stderr of that command will also be printed to the stdout and stderr of this Go program to make debugging
 // easier.
 func runCommand(t testing.TestingT, command Command) (*output, error) {
+	command.Logger = command.Logger.WithFields(logger.Fields{logger.FieldCommand: command.Command})
 	command.Logger.Logf(t, "Running command %s with args %s", command.Command, command.Args)
 
 	cmd := exec.Command(command.Command, command.Args...)
@@ -119,12 +121,16 @@ func runCommand(t testing.TestingT, command Command) (*output, error) {
 		return nil, err
 	}
 
+	start := time.Now()
 	output, err := readStdoutAndStderr(t, command.Logger, stdout, stderr)
 	if err != nil {
 		return output, err
 	}
 
-	return output, cmd.Wait()
+	err = cmd.Wait()
+	duration := time.Since(start)
+	command.Logger.WithFields(logger.Fields{logger.FieldDuration: duration}).Logf(t, "Command %s finished in %s", command.Command, duration.Round(time.Millisecond))
+	return output, err
 }
 
 // This function captures stdout and stderr into the given variables while still printing it to the stdout and stderr
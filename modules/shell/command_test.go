@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/tnn-gruntwork-io/terratest/modules/logger"
 	"github.com/tnn-gruntwork-io/terratest/modules/random"
@@ -183,3 +186,32 @@ func TestCommandOutputType(t *testing.T) {
 		assert.Len(t, o.Output.Combined(), len(stdout)+len(stderr)+1) // +1 for newline
 	}
 }
+
+type recordingSink struct {
+	mutex   sync.Mutex
+	entries []logger.Entry
+}
+
+func (sink *recordingSink) Write(entry logger.Entry) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	sink.entries = append(sink.entries, entry)
+	return nil
+}
+
+func TestRunCommandLogsCommandAndDuration(t *testing.T) {
+	t.Parallel()
+
+	sink := &recordingSink{}
+	RunCommand(t, Command{
+		Command: "echo",
+		Args:    []string{"hello"},
+		Logger:  logger.NewStructured(sink),
+	})
+
+	require.NotEmpty(t, sink.entries)
+	last := sink.entries[len(sink.entries)-1]
+	assert.Equal(t, "echo", last.Fields[logger.FieldCommand])
+	assert.IsType(t, time.Duration(0), last.Fields[logger.FieldDuration])
+	assert.True(t, strings.HasPrefix(last.Message, "Command echo finished in "), last.Message)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/tnn-gruntwork-io/terratest/modules/collections"
+	"github.com/tnn-gruntwork-io/terratest/modules/logger"
 	"github.com/tnn-gruntwork-io/terratest/modules/retry"
 	"github.com/tnn-gruntwork-io/terratest/modules/shell"
 	"github.com/tnn-gruntwork-io/terratest/modules/testing"
@@ -15,7 +16,7 @@ func generateCommand(options *Options, args ...string) shell.Command {
 		Args:       args,
 		WorkingDir: options.TerraformDir,
 		Env:        options.EnvVars,
-		Logger:     options.Logger,
+		Logger:     options.Logger.WithFields(logger.Fields{logger.FieldModule: options.TerraformDir}),
 	}
 	return cmd
 }
@@ -70,7 +71,7 @@ func RunTerraformCommandE(t testing.TestingT, additionalOptions *Options, additi
 
 	cmd := generateCommand(options, args...)
 	description := fmt.Sprintf("%s %v", options.TerraformBinary, args)
-	return retry.DoWithRetryableErrorsE(t, description, options.RetryableTerraformErrors, options.MaxRetries, options.TimeBetweenRetries, func() (string, error) {
+	return retry.DoWithRetryableErrorsAndPolicyE(t, description, options.RetryableTerraformErrors, retry.PolicyOrFixed(options.RetryPolicy, options.MaxRetries, options.TimeBetweenRetries), func() (string, error) {
 		return shell.RunCommandAndGetOutputE(t, cmd)
 	})
 }
@@ -82,7 +83,7 @@ func RunTerraformCommandAndGetStdoutE(t testing.TestingT, additionalOptions *Opt
 
 	cmd := generateCommand(options, args...)
 	description := fmt.Sprintf("%s %v", options.TerraformBinary, args)
-	return retry.DoWithRetryableErrorsE(t, description, options.RetryableTerraformErrors, options.MaxRetries, options.TimeBetweenRetries, func() (string, error) {
+	return retry.DoWithRetryableErrorsAndPolicyE(t, description, options.RetryableTerraformErrors, retry.PolicyOrFixed(options.RetryPolicy, options.MaxRetries, options.TimeBetweenRetries), func() (string, error) {
 		return shell.RunCommandAndGetStdOutE(t, cmd)
 	})
 }
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/tnn-gruntwork-io/terratest/modules/logger"
+	"github.com/tnn-gruntwork-io/terratest/modules/retry"
 	"github.com/tnn-gruntwork-io/terratest/modules/ssh"
 	"github.com/tnn-gruntwork-io/terratest/modules/testing"
 	"github.com/jinzhu/copier"
@@ -59,6 +60,7 @@ type Options struct {
 	RetryableTerraformErrors map[string]string      // If Terraform apply fails with one of these (transient) errors, retry. The keys are a regexp to match against the error and the message is what to display to a user if that error is matched.
 	MaxRetries               int                    // Maximum number of times to retry errors matching RetryableTerraformErrors
 	TimeBetweenRetries       time.Duration          // The amount of time to wait between retries
+	RetryPolicy              *retry.Policy          `json:"-"` // If set, overrides MaxRetries and TimeBetweenRetries, e.g. to use exponential backoff with jitter. RetryableTerraformErrors still decides which errors are retried. Not saved by test_structure.SaveTerraformOptions, as it may have functions.
 	Upgrade                  bool                   // Whether the -upgrade flag of the terraform init command should be set to true or not
 	Reconfigure              bool                   // Set the -reconfigure flag to the terraform init command
 	MigrateState             bool                   // Set the -migrate-state and -force-copy (suppress 'yes' answer prompt) flag to the terraform init command
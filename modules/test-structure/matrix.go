@@ -0,0 +1,354 @@
+package test_structure
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	go_test "testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/logger"
+	"github.com/tnn-gruntwork-io/terratest/modules/random"
+	"github.com/tnn-gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// SHARD_INDEX_ENV_VAR is the environment variable with the zero-based index of the shard of a TestMatrix to run,
+	// e.g. the index of the CI job.
+	SHARD_INDEX_ENV_VAR = "SHARD_INDEX"
+	// SHARD_COUNT_ENV_VAR is the environment variable with the number of shards the cases of a TestMatrix are split
+	// into, e.g. the number of CI jobs.
+	SHARD_COUNT_ENV_VAR = "SHARD_COUNT"
+)
+
+// MatrixValue is a value of a MatrixDimension.
+type MatrixValue struct {
+	// The name of the value, used in the names of the subtests
+	Name string
+	// Applies the value to the terraform.Options of a case, which are a copy of the base options
+	Apply func(options *terraform.Options)
+}
+
+// MatrixDimension is a dimension of a TestMatrix, such as the region or the Terraform version, with the values to test.
+type MatrixDimension struct {
+	Name   string
+	Values []MatrixValue
+}
+
+// RegionDimension returns a dimension that sets the given Terraform variable to each of the given regions.
+func RegionDimension(varName string, regions ...string) MatrixDimension {
+	return VarDimension("region", varName, regions...)
+}
+
+// VarDimension returns a dimension with the given name that sets the given Terraform variable to each of the given
+// values.
+func VarDimension(name string, varName string, values ...string) MatrixDimension {
+	dimension := MatrixDimension{Name: name}
+	for _, value := range values {
+		value := value
+		dimension.Values = append(dimension.Values, MatrixValue{
+			Name:  value,
+			Apply: func(options *terraform.Options) { options.Vars[varName] = value },
+		})
+	}
+	return dimension
+}
+
+// VarSetsDimension returns a dimension with the given name whose values are sets of Terraform variables, keyed by the
+// name of the value.
+func VarSetsDimension(name string, varSets map[string]map[string]interface{}) MatrixDimension {
+	names := []string{}
+	for varSetName := range varSets {
+		names = append(names, varSetName)
+	}
+	sort.Strings(names)
+
+	dimension := MatrixDimension{Name: name}
+	for _, varSetName := range names {
+		vars := varSets[varSetName]
+		dimension.Values = append(dimension.Values, MatrixValue{
+			Name: varSetName,
+			Apply: func(options *terraform.Options) {
+				for key, value := range vars {
+					options.Vars[key] = value
+				}
+			},
+		})
+	}
+	return dimension
+}
+
+// VarFilesDimension returns a dimension that adds each of the given var files to the VarFiles of the options. The
+// values are named after the file names.
+func VarFilesDimension(varFiles ...string) MatrixDimension {
+	dimension := MatrixDimension{Name: "var-file"}
+	for _, varFile := range varFiles {
+		varFile := varFile
+		dimension.Values = append(dimension.Values, MatrixValue{
+			Name: filepath.Base(varFile),
+			Apply: func(options *terraform.Options) {
+				// Copied, so that the cases don't share the underlying array of the base options
+				options.VarFiles = append(append([]string{}, options.VarFiles...), varFile)
+			},
+		})
+	}
+	return dimension
+}
+
+// TerraformBinaryDimension returns a dimension that runs each case with each of the given Terraform binaries, e.g.
+// "terraform-1.3" and "terraform-1.5" for different Terraform versions, or "terraform" and "tofu". The values are named
+// after the file names of the binaries.
+func TerraformBinaryDimension(binaries ...string) MatrixDimension {
+	dimension := MatrixDimension{Name: "binary"}
+	for _, binary := range binaries {
+		binary := binary
+		dimension.Values = append(dimension.Values, MatrixValue{
+			Name:  filepath.Base(binary),
+			Apply: func(options *terraform.Options) { options.TerraformBinary = binary },
+		})
+	}
+	return dimension
+}
+
+// TestMatrix is a set of dimensions, such as regions, variable sets and Terraform versions, to test every combination
+// of with the same base terraform.Options. See RunTestMatrix.
+type TestMatrix struct {
+	BaseOptions *terraform.Options
+	Dimensions  []MatrixDimension
+
+	// If set, each case runs in its own copy of this root folder, made with CopyTerraformFolderToTemp, in which case
+	// the TerraformDir of the BaseOptions must be relative to it. Without copies, parallel cases share the .terraform
+	// folder and state of the TerraformDir, so this should be set unless the cases use separate backends and workspaces.
+	CopyRootFolder string
+}
+
+// MatrixCase is a combination of the values of the dimensions of a TestMatrix.
+type MatrixCase struct {
+	// The name of the subtest of the case, e.g. "region=us-east-1,binary=terraform-1.5"
+	Name string
+	// The index of the case among all the cases of the matrix
+	Index int
+	// A unique ID for the case, to use in the names of the resources it creates
+	UniqueID string
+	// The name of the value of each dimension, keyed by the name of the dimension
+	Values map[string]string
+	// A copy of the base options, with the values of the case applied
+	Options *terraform.Options
+}
+
+// CasesE returns all the combinations of the values of the dimensions of the matrix, in order, with the first
+// dimension varying slowest.
+func (matrix TestMatrix) CasesE() ([]MatrixCase, error) {
+	if matrix.BaseOptions == nil {
+		return nil, fmt.Errorf("the BaseOptions of the test matrix must be set")
+	}
+	for _, dimension := range matrix.Dimensions {
+		if len(dimension.Values) == 0 {
+			return nil, EmptyMatrixDimension{Dimension: dimension.Name}
+		}
+	}
+
+	combinations := [][]MatrixValue{{}}
+	for _, dimension := range matrix.Dimensions {
+		next := [][]MatrixValue{}
+		for _, combination := range combinations {
+			for _, value := range dimension.Values {
+				next = append(next, append(append([]MatrixValue{}, combination...), value))
+			}
+		}
+		combinations = next
+	}
+
+	cases := []MatrixCase{}
+	for index, combination := range combinations {
+		options, err := matrix.BaseOptions.Clone()
+		if err != nil {
+			return nil, err
+		}
+
+		names := []string{}
+		values := map[string]string{}
+		for i, value := range combination {
+			dimensionName := matrix.Dimensions[i].Name
+			names = append(names, fmt.Sprintf("%s=%s", dimensionName, value.Name))
+			values[dimensionName] = value.Name
+			if value.Apply != nil {
+				value.Apply(options)
+			}
+		}
+		name := strings.Join(names, ",")
+		if name == "" {
+			name = "default"
+		}
+
+		cases = append(cases, MatrixCase{
+			Name:     name,
+			Index:    index,
+			UniqueID: random.UniqueId(),
+			Values:   values,
+			Options:  options,
+		})
+	}
+	return cases, nil
+}
+
+// RunTestMatrix runs the given test for every combination of the values of the dimensions of the matrix, each in a
+// parallel subtest with its own copy of the base options, so there is no need to write nested loops with t.Run and
+// t.Parallel:
+//
+//	matrix := test_structure.TestMatrix{
+//		BaseOptions:    &terraform.Options{TerraformDir: "examples/terraform-aws-example"},
+//		CopyRootFolder: "..",
+//		Dimensions: []test_structure.MatrixDimension{
+//			test_structure.RegionDimension("aws_region", "us-east-1", "eu-west-1"),
+//			test_structure.TerraformBinaryDimension("terraform-1.3", "terraform-1.5"),
+//		},
+//	}
+//	test_structure.RunTestMatrix(t, matrix, func(t *testing.T, matrixCase test_structure.MatrixCase) {
+//		matrixCase.Options.Vars["instance_name"] = "test-" + matrixCase.UniqueID
+//		defer terraform.Destroy(t, matrixCase.Options)
+//		terraform.InitAndApply(t, matrixCase.Options)
+//	})
+//
+// To split the cases across CI jobs, set the SHARD_COUNT environment variable to the number of jobs and SHARD_INDEX to
+// the zero-based index of each job. Once all the cases have completed, a table with the result of each is logged. As
+// parallel subtests only start once the test function returns, don't run any code that depends on the cases after
+// calling this function.
+func RunTestMatrix(t *go_test.T, matrix TestMatrix, test func(t *go_test.T, matrixCase MatrixCase)) {
+	cases, err := matrix.CasesE()
+	require.NoError(t, err)
+
+	shardIndex, shardCount, err := matrixShardE()
+	require.NoError(t, err)
+	if shardCount > 1 {
+		cases = shardMatrixCases(cases, shardIndex, shardCount)
+		logger.Logf(t, "Running %d cases of the test matrix in shard %d of %d", len(cases), shardIndex, shardCount)
+	}
+
+	results := &matrixResults{}
+	// Cleanup functions run once all the subtests, including the parallel ones, have completed
+	t.Cleanup(func() {
+		logger.Logf(t, "Test matrix results:\n%s", results.table())
+	})
+
+	for _, matrixCase := range cases {
+		matrixCase := matrixCase
+		t.Run(matrixCase.Name, func(t *go_test.T) {
+			t.Parallel()
+
+			start := time.Now()
+			// Deferred, so that the result is recorded even if the test fails with t.FailNow or is skipped
+			defer func() {
+				results.add(matrixCase, t.Failed(), t.Skipped(), time.Since(start))
+			}()
+
+			if matrix.CopyRootFolder != "" {
+				matrixCase.Options.TerraformDir = CopyTerraformFolderToTemp(t, matrix.CopyRootFolder, matrix.BaseOptions.TerraformDir)
+			}
+			test(t, matrixCase)
+		})
+	}
+}
+
+// matrixShardE returns the shard of the test matrix to run, as set in the SHARD_INDEX and SHARD_COUNT environment
+// variables. The shard count is 1 if they are not set.
+func matrixShardE() (int, int, error) {
+	countValue := os.Getenv(SHARD_COUNT_ENV_VAR)
+	indexValue := os.Getenv(SHARD_INDEX_ENV_VAR)
+	if countValue == "" && indexValue == "" {
+		return 0, 1, nil
+	}
+
+	count, err := strconv.Atoi(countValue)
+	if err != nil || count < 1 {
+		return 0, 0, InvalidShard{Index: indexValue, Count: countValue}
+	}
+	index, err := strconv.Atoi(indexValue)
+	if err != nil || index < 0 || index >= count {
+		return 0, 0, InvalidShard{Index: indexValue, Count: countValue}
+	}
+	return index, count, nil
+}
+
+// shardMatrixCases returns the cases in the given shard, spreading the cases evenly, round robin, across the shards.
+func shardMatrixCases(cases []MatrixCase, shardIndex int, shardCount int) []MatrixCase {
+	shard := []MatrixCase{}
+	for _, matrixCase := range cases {
+		if matrixCase.Index%shardCount == shardIndex {
+			shard = append(shard, matrixCase)
+		}
+	}
+	return shard
+}
+
+// matrixCaseResult is the result of a case of a test matrix.
+type matrixCaseResult struct {
+	matrixCase MatrixCase
+	failed     bool
+	skipped    bool
+	duration   time.Duration
+}
+
+// matrixResults collects the results of the cases of a test matrix, which run concurrently.
+type matrixResults struct {
+	mutex   sync.Mutex
+	results []matrixCaseResult
+}
+
+func (results *matrixResults) add(matrixCase MatrixCase, failed bool, skipped bool, duration time.Duration) {
+	results.mutex.Lock()
+	defer results.mutex.Unlock()
+	results.results = append(results.results, matrixCaseResult{matrixCase: matrixCase, failed: failed, skipped: skipped, duration: duration})
+}
+
+// table returns a table with the result of each case, in the order of the cases.
+func (results *matrixResults) table() string {
+	results.mutex.Lock()
+	defer results.mutex.Unlock()
+
+	sorted := append([]matrixCaseResult{}, results.results...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].matrixCase.Index < sorted[j].matrixCase.Index })
+
+	var buffer bytes.Buffer
+	writer := tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "CASE\tID\tRESULT\tDURATION")
+	for _, result := range sorted {
+		outcome := "PASS"
+		if result.failed {
+			outcome = "FAIL"
+		} else if result.skipped {
+			outcome = "SKIP"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", result.matrixCase.Name, result.matrixCase.UniqueID, outcome, result.duration.Round(time.Millisecond))
+	}
+	writer.Flush()
+	return buffer.String()
+}
+
+// EmptyMatrixDimension is an error that occurs when a dimension of a TestMatrix has no values, so there are no cases
+// to run.
+type EmptyMatrixDimension struct {
+	Dimension string
+}
+
+func (err EmptyMatrixDimension) Error() string {
+	return fmt.Sprintf("dimension %s of the test matrix has no values", err.Dimension)
+}
+
+// InvalidShard is an error that occurs when the SHARD_INDEX and SHARD_COUNT environment variables are not a valid
+// zero-based index and count.
+type InvalidShard struct {
+	Index string
+	Count string
+}
+
+func (err InvalidShard) Error() string {
+	return fmt.Sprintf("invalid test matrix shard: %s=%q must be an index from 0 to %s-1, with %s=%q", SHARD_INDEX_ENV_VAR, err.Index, SHARD_COUNT_ENV_VAR, SHARD_COUNT_ENV_VAR, err.Count)
+}
@@ -0,0 +1,153 @@
+package test_structure
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/files"
+	"github.com/tnn-gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestMatrixCases(t *testing.T) {
+	t.Parallel()
+
+	matrix := TestMatrix{
+		BaseOptions: &terraform.Options{
+			TerraformDir: "examples/foo",
+			Vars:         map[string]interface{}{"name": "foo"},
+			VarFiles:     []string{"common.tfvars"},
+		},
+		Dimensions: []MatrixDimension{
+			RegionDimension("aws_region", "us-east-1", "eu-west-1"),
+			VarFilesDimension("vars/small.tfvars", "vars/large.tfvars"),
+		},
+	}
+
+	cases, err := matrix.CasesE()
+	require.NoError(t, err)
+
+	names := []string{}
+	ids := map[string]bool{}
+	for i, matrixCase := range cases {
+		assert.Equal(t, i, matrixCase.Index)
+		names = append(names, matrixCase.Name)
+		ids[matrixCase.UniqueID] = true
+	}
+	assert.Equal(t, []string{
+		"region=us-east-1,var-file=small.tfvars",
+		"region=us-east-1,var-file=large.tfvars",
+		"region=eu-west-1,var-file=small.tfvars",
+		"region=eu-west-1,var-file=large.tfvars",
+	}, names)
+	assert.Len(t, ids, 4, "case IDs should be unique")
+
+	lastCase := cases[3]
+	assert.Equal(t, map[string]string{"region": "eu-west-1", "var-file": "large.tfvars"}, lastCase.Values)
+	assert.Equal(t, map[string]interface{}{"name": "foo", "aws_region": "eu-west-1"}, lastCase.Options.Vars)
+	assert.Equal(t, []string{"common.tfvars", "vars/large.tfvars"}, lastCase.Options.VarFiles)
+
+	// The base options are not modified
+	assert.Equal(t, map[string]interface{}{"name": "foo"}, matrix.BaseOptions.Vars)
+	assert.Equal(t, []string{"common.tfvars"}, matrix.BaseOptions.VarFiles)
+}
+
+func TestTestMatrixCasesWithEmptyDimension(t *testing.T) {
+	t.Parallel()
+
+	matrix := TestMatrix{
+		BaseOptions: &terraform.Options{},
+		Dimensions:  []MatrixDimension{TerraformBinaryDimension()},
+	}
+	_, err := matrix.CasesE()
+	assert.Equal(t, EmptyMatrixDimension{Dimension: "binary"}, err)
+}
+
+func TestShardMatrixCases(t *testing.T) {
+	t.Parallel()
+
+	cases := []MatrixCase{}
+	for i := 0; i < 5; i++ {
+		cases = append(cases, MatrixCase{Index: i})
+	}
+
+	indexes := func(cases []MatrixCase) []int {
+		result := []int{}
+		for _, matrixCase := range cases {
+			result = append(result, matrixCase.Index)
+		}
+		return result
+	}
+	assert.Equal(t, []int{0, 2, 4}, indexes(shardMatrixCases(cases, 0, 2)))
+	assert.Equal(t, []int{1, 3}, indexes(shardMatrixCases(cases, 1, 2)))
+}
+
+// Not parallel, as it sets environment variables
+func TestMatrixShardFromEnv(t *testing.T) {
+	testCases := []struct {
+		index         string
+		count         string
+		expectedIndex int
+		expectedCount int
+		expectErr     bool
+	}{
+		{"", "", 0, 1, false},
+		{"1", "3", 1, 3, false},
+		{"3", "3", 0, 0, true},
+		{"0", "", 0, 0, true},
+		{"foo", "2", 0, 0, true},
+	}
+
+	for _, testCase := range testCases {
+		t.Setenv(SHARD_INDEX_ENV_VAR, testCase.index)
+		t.Setenv(SHARD_COUNT_ENV_VAR, testCase.count)
+		index, count, err := matrixShardE()
+		if testCase.expectErr {
+			assert.IsType(t, InvalidShard{}, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, testCase.expectedIndex, index)
+		assert.Equal(t, testCase.expectedCount, count)
+	}
+}
+
+func TestRunTestMatrixCopiesRootFolderForEachCase(t *testing.T) {
+	t.Parallel()
+
+	matrix := TestMatrix{
+		BaseOptions:    &terraform.Options{TerraformDir: "terraform-null"},
+		CopyRootFolder: "../../test/fixtures",
+		Dimensions:     []MatrixDimension{VarDimension("name", "name", "foo", "bar")},
+	}
+
+	var mutex sync.Mutex
+	dirs := map[string]string{}
+	// The results table is logged once all the parallel subtests have completed, which is before t.Run returns
+	t.Run("matrix", func(t *testing.T) {
+		RunTestMatrix(t, matrix, func(t *testing.T, matrixCase MatrixCase) {
+			assert.True(t, files.FileExists(matrixCase.Options.TerraformDir))
+			mutex.Lock()
+			defer mutex.Unlock()
+			dirs[matrixCase.Options.Vars["name"].(string)] = matrixCase.Options.TerraformDir
+		})
+	})
+
+	require.Len(t, dirs, 2)
+	assert.NotEqual(t, dirs["foo"], dirs["bar"])
+}
+
+func TestMatrixResultsTable(t *testing.T) {
+	t.Parallel()
+
+	results := &matrixResults{}
+	results.add(MatrixCase{Name: "region=eu-west-1", Index: 1, UniqueID: "def456"}, true, false, 0)
+	results.add(MatrixCase{Name: "region=us-east-1", Index: 0, UniqueID: "abc123"}, false, false, 0)
+
+	expected := "" +
+		"CASE              ID      RESULT  DURATION\n" +
+		"region=us-east-1  abc123  PASS    0s\n" +
+		"region=eu-west-1  def456  FAIL    0s\n"
+	assert.Equal(t, expected, results.table())
+}
@@ -0,0 +1,105 @@
+package test_structure
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/logger"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// FORCE_STAGES_ENV_VAR is the environment variable that, when set to true, makes RunResumableTestStage (and resumable
+// Workflows) run every stage, even the ones that completed in an earlier run.
+const FORCE_STAGES_ENV_VAR = "FORCE_STAGES"
+
+// The value of the -force-stages flag, if registered with RegisterFlags
+var forceStagesFlag bool
+
+// StageProgress records the successful completion of a test stage.
+type StageProgress struct {
+	CompletedAt time.Time
+	TestName    string
+}
+
+// TestProgress records which stages of a test completed successfully for a test folder, keyed by stage name.
+type TestProgress struct {
+	Stages map[string]StageProgress
+}
+
+// RunResumableTestStage executes the given test stage like RunTestStage, but records in the test folder when the stage
+// completes successfully, and skips the stage if it has already completed in an earlier run. This way, when a long test
+// fails part way through, e.g. at validation after a 40 minute deploy, rerunning it resumes from the failed stage
+// without having to set the right combination of SKIP_<stage> environment variables:
+//
+//	test_structure.RunResumableTestStage(t, testFolder, "deploy", func() { ... })
+//	test_structure.RunResumableTestStage(t, testFolder, "validate", func() { ... })
+//	test_structure.RunResumableTestStage(t, testFolder, "teardown", func() {
+//		...
+//		test_structure.ClearTestProgress(t, testFolder)
+//	})
+//
+// The test folder must be the same between runs for this to work. Run the test with FORCE_STAGES=true, or the
+// -force-stages flag once registered with RegisterFlags, to run all stages regardless, and call ClearTestProgress (or
+// CleanupTestDataFolder) once the test has been torn down so that the next run starts from the beginning.
+func RunResumableTestStage(t testing.TestingT, testFolder string, stageName string, stage func()) {
+	if !forceStages() && IsTestStageCompleted(t, testFolder, stageName) {
+		logger.Logf(t, "Stage '%s' already completed in an earlier run for %s, so skipping it. Set %s=true to run it anyway.", stageName, testFolder, FORCE_STAGES_ENV_VAR)
+		return
+	}
+
+	RunTestStage(t, stageName, func() {
+		stage()
+		// A stage that fails with t.FailNow never gets here, but one that fails with t.Error does
+		if hasFailed(t) {
+			return
+		}
+		MarkTestStageCompleted(t, testFolder, stageName)
+	})
+}
+
+// IsTestStageCompleted returns true if the given stage has been marked as completed for the given test folder.
+func IsTestStageCompleted(t testing.TestingT, testFolder string, stageName string) bool {
+	_, completed := LoadTestProgress(t, testFolder).Stages[stageName]
+	return completed
+}
+
+// MarkTestStageCompleted records that the given stage completed successfully for the given test folder.
+func MarkTestStageCompleted(t testing.TestingT, testFolder string, stageName string) {
+	progress := LoadTestProgress(t, testFolder)
+	progress.Stages[stageName] = StageProgress{CompletedAt: time.Now().UTC(), TestName: t.Name()}
+	Save(t, formatTestProgressPath(testFolder), progress)
+}
+
+// LoadTestProgress loads the progress recorded for the given test folder, which is empty if no stage has completed.
+func LoadTestProgress(t testing.TestingT, testFolder string) TestProgress {
+	path := formatTestProgressPath(testFolder)
+	if !IsTestDataPresent(t, path) {
+		return TestProgress{Stages: map[string]StageProgress{}}
+	}
+	progress := Load[TestProgress](t, path)
+	if progress.Stages == nil {
+		progress.Stages = map[string]StageProgress{}
+	}
+	return progress
+}
+
+// ClearTestProgress deletes the progress recorded for the given test folder, so that the next run of the test runs all
+// of its stages.
+func ClearTestProgress(t testing.TestingT, testFolder string) {
+	CleanupTestData(t, formatTestProgressPath(testFolder))
+}
+
+// formatTestProgressPath formats a path to save the TestProgress in the given folder.
+func formatTestProgressPath(testFolder string) string {
+	return FormatTestDataPath(testFolder, "TestProgress.json")
+}
+
+// forceStages returns true if the -force-stages flag or the FORCE_STAGES environment variable is set.
+func forceStages() bool {
+	if forceStagesFlag {
+		return true
+	}
+	force, _ := strconv.ParseBool(os.Getenv(FORCE_STAGES_ENV_VAR))
+	return force
+}
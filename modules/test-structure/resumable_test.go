@@ -0,0 +1,92 @@
+package test_structure
+
+import (
+	"testing"
+
+	terratesting "github.com/tnn-gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunResumableTestStageSkipsCompletedStages(t *testing.T) {
+	t.Parallel()
+
+	testFolder := t.TempDir()
+	ran := []string{}
+	runStages := func(t terratesting.TestingT, failValidate bool) {
+		RunResumableTestStage(t, testFolder, "deploy", func() { ran = append(ran, "deploy") })
+		RunResumableTestStage(t, testFolder, "validate", func() {
+			ran = append(ran, "validate")
+			if failValidate {
+				t.Errorf("validation failed")
+			}
+		})
+	}
+
+	fake := &fakeT{}
+	runStages(fake, true)
+	assert.True(t, fake.Failed())
+	assert.Equal(t, []string{"deploy", "validate"}, ran)
+	assert.True(t, IsTestStageCompleted(t, testFolder, "deploy"))
+	assert.False(t, IsTestStageCompleted(t, testFolder, "validate"))
+
+	// The rerun resumes from the failed stage
+	ran = []string{}
+	runStages(t, false)
+	assert.Equal(t, []string{"validate"}, ran)
+	assert.True(t, IsTestStageCompleted(t, testFolder, "validate"))
+
+	ran = []string{}
+	runStages(t, false)
+	assert.Empty(t, ran)
+
+	ClearTestProgress(t, testFolder)
+	assert.Empty(t, LoadTestProgress(t, testFolder).Stages)
+	runStages(t, false)
+	assert.Equal(t, []string{"deploy", "validate"}, ran)
+}
+
+// Not parallel, as it sets environment variables
+func TestRunResumableTestStageWithForceStages(t *testing.T) {
+	testFolder := t.TempDir()
+	MarkTestStageCompleted(t, testFolder, "deploy")
+
+	ran := false
+	t.Setenv(FORCE_STAGES_ENV_VAR, "true")
+	RunResumableTestStage(t, testFolder, "deploy", func() { ran = true })
+	assert.True(t, ran)
+}
+
+// Not parallel, as it sets environment variables
+func TestResumableWorkflowResumesAfterFailure(t *testing.T) {
+	testFolder := t.TempDir()
+	ran := []string{}
+	failValidate := true
+
+	workflow := NewWorkflow(testFolder)
+	workflow.Resumable = true
+	workflow.AddStage("deploy", nil, func(t terratesting.TestingT) { ran = append(ran, "deploy") })
+	workflow.AddStage("validate", []string{"deploy"}, func(t terratesting.TestingT) {
+		ran = append(ran, "validate")
+		if failValidate {
+			t.Fatal("validation failed")
+		}
+	})
+	workflow.AddTeardownStage("destroy", func(t terratesting.TestingT) { ran = append(ran, "destroy") })
+
+	// Keep the deployment around to debug the failure, which also keeps the progress
+	t.Setenv("SKIP_destroy", "true")
+	fake := runWithFakeT(workflow)
+	assert.True(t, fake.Failed())
+	assert.Equal(t, []string{"deploy", "validate"}, ran)
+	assert.True(t, IsTestStageCompleted(t, testFolder, "deploy"))
+	assert.False(t, IsTestStageCompleted(t, testFolder, "validate"))
+
+	ran = []string{}
+	failValidate = false
+	t.Setenv("SKIP_destroy", "")
+	workflow.Run(t)
+	assert.Equal(t, []string{"validate", "destroy"}, ran)
+
+	// The progress is cleared once the teardown stages have run, so the next run starts from the beginning
+	assert.Empty(t, LoadTestProgress(t, testFolder).Stages)
+}
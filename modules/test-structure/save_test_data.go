@@ -2,13 +2,12 @@ package test_structure
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
+	"io/fs"
 	"path/filepath"
 
 	"github.com/tnn-gruntwork-io/terratest/modules/aws"
-	"github.com/tnn-gruntwork-io/terratest/modules/files"
 	"github.com/tnn-gruntwork-io/terratest/modules/k8s"
 	"github.com/tnn-gruntwork-io/terratest/modules/logger"
 	"github.com/tnn-gruntwork-io/terratest/modules/packer"
@@ -21,15 +20,13 @@ import (
 // SaveTerraformOptions serializes and saves TerraformOptions into the given folder. This allows you to create TerraformOptions during setup
 // and to reuse that TerraformOptions later during validation and teardown.
 func SaveTerraformOptions(t testing.TestingT, testFolder string, terraformOptions *terraform.Options) {
-	SaveTestData(t, formatTerraformOptionsPath(testFolder), terraformOptions)
+	Save(t, formatTerraformOptionsPath(testFolder), terraformOptions)
 }
 
 // LoadTerraformOptions loads and unserializes TerraformOptions from the given folder. This allows you to reuse a TerraformOptions that was
 // created during an earlier setup step in later validation and teardown steps.
 func LoadTerraformOptions(t testing.TestingT, testFolder string) *terraform.Options {
-	var terraformOptions terraform.Options
-	LoadTestData(t, formatTerraformOptionsPath(testFolder), &terraformOptions)
-	return &terraformOptions
+	return Load[*terraform.Options](t, formatTerraformOptionsPath(testFolder))
 }
 
 // formatTerraformOptionsPath formats a path to save TerraformOptions in the given folder.
@@ -40,15 +37,13 @@ func formatTerraformOptionsPath(testFolder string) string {
 // SavePackerOptions serializes and saves PackerOptions into the given folder. This allows you to create PackerOptions during setup
 // and to reuse that PackerOptions later during validation and teardown.
 func SavePackerOptions(t testing.TestingT, testFolder string, packerOptions *packer.Options) {
-	SaveTestData(t, formatPackerOptionsPath(testFolder), packerOptions)
+	Save(t, formatPackerOptionsPath(testFolder), packerOptions)
 }
 
 // LoadPackerOptions loads and unserializes PackerOptions from the given folder. This allows you to reuse a PackerOptions that was
 // created during an earlier setup step in later validation and teardown steps.
 func LoadPackerOptions(t testing.TestingT, testFolder string) *packer.Options {
-	var packerOptions packer.Options
-	LoadTestData(t, formatPackerOptionsPath(testFolder), &packerOptions)
-	return &packerOptions
+	return Load[*packer.Options](t, formatPackerOptionsPath(testFolder))
 }
 
 // formatPackerOptionsPath formats a path to save PackerOptions in the given folder.
@@ -57,17 +52,16 @@ func formatPackerOptionsPath(testFolder string) string {
 }
 
 // SaveEc2KeyPair serializes and saves an Ec2KeyPair into the given folder. This allows you to create an Ec2KeyPair during setup
-// and to reuse that Ec2KeyPair later during validation and teardown.
+// and to reuse that Ec2KeyPair later during validation and teardown. The key pair is encrypted if the
+// TERRATEST_TEST_DATA_ENCRYPTION_KEY environment variable is set (see SaveSensitive).
 func SaveEc2KeyPair(t testing.TestingT, testFolder string, keyPair *aws.Ec2Keypair) {
-	SaveTestData(t, formatEc2KeyPairPath(testFolder), keyPair)
+	SaveSensitive(t, formatEc2KeyPairPath(testFolder), keyPair)
 }
 
 // LoadEc2KeyPair loads and unserializes an Ec2KeyPair from the given folder. This allows you to reuse an Ec2KeyPair that was
 // created during an earlier setup step in later validation and teardown steps.
 func LoadEc2KeyPair(t testing.TestingT, testFolder string) *aws.Ec2Keypair {
-	var keyPair aws.Ec2Keypair
-	LoadTestData(t, formatEc2KeyPairPath(testFolder), &keyPair)
-	return &keyPair
+	return Load[*aws.Ec2Keypair](t, formatEc2KeyPairPath(testFolder))
 }
 
 // formatEc2KeyPairPath formats a path to save an Ec2KeyPair in the given folder.
@@ -76,17 +70,16 @@ func formatEc2KeyPairPath(testFolder string) string {
 }
 
 // SaveSshKeyPair serializes and saves an SshKeyPair into the given folder. This allows you to create an SshKeyPair during setup
-// and to reuse that SshKeyPair later during validation and teardown.
+// and to reuse that SshKeyPair later during validation and teardown. The key pair is encrypted if the
+// TERRATEST_TEST_DATA_ENCRYPTION_KEY environment variable is set (see SaveSensitive).
 func SaveSshKeyPair(t testing.TestingT, testFolder string, keyPair *ssh.KeyPair) {
-	SaveTestData(t, formatSshKeyPairPath(testFolder), keyPair)
+	SaveSensitive(t, formatSshKeyPairPath(testFolder), keyPair)
 }
 
 // LoadSshKeyPair loads and unserializes an SshKeyPair from the given folder. This allows you to reuse an SshKeyPair that was
 // created during an earlier setup step in later validation and teardown steps.
 func LoadSshKeyPair(t testing.TestingT, testFolder string) *ssh.KeyPair {
-	var keyPair ssh.KeyPair
-	LoadTestData(t, formatSshKeyPairPath(testFolder), &keyPair)
-	return &keyPair
+	return Load[*ssh.KeyPair](t, formatSshKeyPairPath(testFolder))
 }
 
 // formatSshKeyPairPath formats a path to save an SshKeyPair in the given folder.
@@ -97,15 +90,13 @@ func formatSshKeyPairPath(testFolder string) string {
 // SaveKubectlOptions serializes and saves KubectlOptions into the given folder. This allows you to create a KubectlOptions during setup
 // and reuse that KubectlOptions later during validation and teardown.
 func SaveKubectlOptions(t testing.TestingT, testFolder string, kubectlOptions *k8s.KubectlOptions) {
-	SaveTestData(t, formatKubectlOptionsPath(testFolder), kubectlOptions)
+	Save(t, formatKubectlOptionsPath(testFolder), kubectlOptions)
 }
 
 // LoadKubectlOptions loads and unserializes a KubectlOptions from the given folder. This allows you to reuse a KubectlOptions that was
 // created during an earlier setup step in later validation and teardown steps.
 func LoadKubectlOptions(t testing.TestingT, testFolder string) *k8s.KubectlOptions {
-	var kubectlOptions k8s.KubectlOptions
-	LoadTestData(t, formatKubectlOptionsPath(testFolder), &kubectlOptions)
-	return &kubectlOptions
+	return Load[*k8s.KubectlOptions](t, formatKubectlOptionsPath(testFolder))
 }
 
 // formatKubectlOptionsPath formats a path to save a KubectlOptions in the given folder.
@@ -116,31 +107,25 @@ func formatKubectlOptionsPath(testFolder string) string {
 // SaveString serializes and saves a uniquely named string value into the given folder. This allows you to create one or more string
 // values during one stage -- each with a unique name -- and to reuse those values during later stages.
 func SaveString(t testing.TestingT, testFolder string, name string, val string) {
-	path := formatNamedTestDataPath(testFolder, name)
-	SaveTestData(t, path, val)
+	Save(t, formatNamedTestDataPath(testFolder, name), val)
 }
 
 // LoadString loads and unserializes a uniquely named string value from the given folder. This allows you to reuse one or more string
 // values that were created during an earlier setup step in later steps.
 func LoadString(t testing.TestingT, testFolder string, name string) string {
-	var val string
-	LoadTestData(t, formatNamedTestDataPath(testFolder, name), &val)
-	return val
+	return Load[string](t, formatNamedTestDataPath(testFolder, name))
 }
 
 // SaveInt saves a uniquely named int value into the given folder. This allows you to create one or more int
 // values during one stage -- each with a unique name -- and to reuse those values during later stages.
 func SaveInt(t testing.TestingT, testFolder string, name string, val int) {
-	path := formatNamedTestDataPath(testFolder, name)
-	SaveTestData(t, path, val)
+	Save(t, formatNamedTestDataPath(testFolder, name), val)
 }
 
 // LoadInt loads a uniquely named int value from the given folder. This allows you to reuse one or more int
 // values that were created during an earlier setup step in later steps.
 func LoadInt(t testing.TestingT, testFolder string, name string) int {
-	var val int
-	LoadTestData(t, formatNamedTestDataPath(testFolder, name), &val)
-	return val
+	return Load[int](t, formatNamedTestDataPath(testFolder, name))
 }
 
 // SaveArtifactID serializes and saves an Artifact ID into the given folder. This allows you to build an Artifact during setup and to reuse that
@@ -183,7 +168,8 @@ func FormatTestDataPath(testFolder string, filename string) string {
 }
 
 // SaveTestData serializes and saves a value used at test time to the given path. This allows you to create some sort of test data
-// (e.g., TerraformOptions) during setup and to reuse this data later during validation and teardown.
+// (e.g., TerraformOptions) during setup and to reuse this data later during validation and teardown. The data is stored in the
+// TestDataStore returned by GetTestDataStore.
 func SaveTestData(t testing.TestingT, path string, value interface{}) {
 	logger.Logf(t, "Storing test data in %s so it can be reused later", path)
 
@@ -198,23 +184,24 @@ func SaveTestData(t testing.TestingT, path string, value interface{}) {
 
 	logger.Logf(t, "Marshalled JSON: %s", string(bytes))
 
-	parentDir := filepath.Dir(path)
-	if err := os.MkdirAll(parentDir, 0777); err != nil {
-		t.Fatalf("Failed to create folder %s: %v", parentDir, err)
-	}
-
-	if err := ioutil.WriteFile(path, bytes, 0644); err != nil {
+	if err := getTestDataStore(t).WriteTestDataE(path, bytes, false); err != nil {
 		t.Fatalf("Failed to save value %s: %v", path, err)
 	}
 }
 
 // LoadTestData loads and unserializes a value stored at the given path. The value should be a pointer to a struct into which the
 // value will be deserialized. This allows you to reuse some sort of test data (e.g., TerraformOptions) from earlier
-// setup steps in later validation and teardown steps.
+// setup steps in later validation and teardown steps. Values saved with Save, SaveEncrypted or SaveSensitive are unwrapped
+// from their TestDataEnvelope.
 func LoadTestData(t testing.TestingT, path string, value interface{}) {
 	logger.Logf(t, "Loading test data from %s", path)
 
-	bytes, err := ioutil.ReadFile(path)
+	bytes, err := getTestDataStore(t).ReadTestDataE(path)
+	if err != nil {
+		t.Fatalf("Failed to load value from %s: %v", path, err)
+	}
+
+	bytes, err = unwrapTestDataE(bytes)
 	if err != nil {
 		t.Fatalf("Failed to load value from %s: %v", path, err)
 	}
@@ -224,22 +211,34 @@ func LoadTestData(t testing.TestingT, path string, value interface{}) {
 	}
 }
 
-// IsTestDataPresent returns true if a file exists at $path and the test data there is non-empty.
+// IsTestDataPresent returns true if test data exists at $path and the test data there is non-empty.
 func IsTestDataPresent(t testing.TestingT, path string) bool {
-	exists, err := files.FileExistsE(path)
+	store := getTestDataStore(t)
+	exists, err := store.ExistsE(path)
 	if err != nil {
-		t.Fatalf("Failed to load test data from %s due to unexpected error: %v", path, err)
+		t.Fatalf("Failed to check for test data at %s due to unexpected error: %v", path, err)
 	}
 	if !exists {
 		return false
 	}
 
-	bytes, err := ioutil.ReadFile(path)
-
+	// The contents are needed to tell whether the test data is empty
+	bytes, err := store.ReadTestDataE(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
 	if err != nil {
 		t.Fatalf("Failed to load test data from %s due to unexpected error: %v", path, err)
 	}
 
+	// Only look at the value of data saved in a TestDataEnvelope. Encrypted values are never empty.
+	if envelope, isEnvelope := parseEnvelope(bytes); isEnvelope {
+		if envelope.Encrypted {
+			return true
+		}
+		bytes = envelope.Value
+	}
+
 	if isEmptyJSON(t, bytes) {
 		return false
 	}
@@ -247,6 +246,15 @@ func IsTestDataPresent(t testing.TestingT, path string) bool {
 	return true
 }
 
+// getTestDataStore returns the store from GetTestDataStore, failing the test if it can't be created.
+func getTestDataStore(t testing.TestingT) TestDataStore {
+	store, err := GetTestDataStore()
+	if err != nil {
+		t.Fatalf("Failed to create test data store: %v", err)
+	}
+	return store
+}
+
 // isEmptyJSON returns true if the given bytes are empty, or in a valid JSON format that can reasonably be considered empty.
 // The types used are based on the type possibilities listed at https://golang.org/src/encoding/json/decode.go?s=4062:4110#L51
 func isEmptyJSON(t testing.TestingT, bytes []byte) bool {
@@ -294,13 +302,19 @@ func isEmptyJSON(t testing.TestingT, bytes []byte) bool {
 
 // CleanupTestData cleans up the test data at the given path.
 func CleanupTestData(t testing.TestingT, path string) {
-	if files.FileExists(path) {
-		logger.Logf(t, "Cleaning up test data from %s", path)
-		if err := os.Remove(path); err != nil {
-			t.Fatalf("Failed to clean up file at %s: %v", path, err)
-		}
-	} else {
+	store := getTestDataStore(t)
+	exists, err := store.ExistsE(path)
+	if err != nil {
+		t.Fatalf("Failed to check for test data at %s: %v", path, err)
+	}
+	if !exists {
 		logger.Logf(t, "%s does not exist. Nothing to cleanup.", path)
+		return
+	}
+
+	logger.Logf(t, "Cleaning up test data from %s", path)
+	if err := store.DeleteTestDataE(path); err != nil {
+		t.Fatalf("Failed to clean up file at %s: %v", path, err)
 	}
 }
 
@@ -314,17 +328,13 @@ func CleanupTestDataFolder(t testing.TestingT, path string) {
 // CleanupTestDataFolderE cleans up the .test-data folder inside the given folder.
 func CleanupTestDataFolderE(t testing.TestingT, path string) error {
 	path = filepath.Join(path, ".test-data")
-	exists, err := files.FileExistsE(path)
+	store, err := GetTestDataStore()
 	if err != nil {
 		logger.Logf(t, "Failed to clean up test data folder at %s: %v", path, err)
 		return err
 	}
 
-	if !exists {
-		logger.Logf(t, "%s does not exist. Nothing to cleanup.", path)
-		return nil
-	}
-	if err := os.RemoveAll(path); err != nil {
+	if err := store.DeleteTestDataE(path); err != nil {
 		logger.Logf(t, "Failed to clean up test data folder at %s: %v", path, err)
 		return err
 	}
@@ -3,9 +3,11 @@ package test_structure
 import (
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/tnn-gruntwork-io/terratest/modules/files"
 	"github.com/tnn-gruntwork-io/terratest/modules/k8s"
+	"github.com/tnn-gruntwork-io/terratest/modules/retry"
 	"github.com/tnn-gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
 )
@@ -107,6 +109,26 @@ func TestSaveAndLoadTerraformOptions(t *testing.T) {
 	assert.Equal(t, expectedData, actualData)
 }
 
+func TestSaveAndLoadTerraformOptionsWithRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	tmpFolder := t.TempDir()
+
+	policy := retry.ExponentialPolicy(5, time.Second, time.Minute)
+	policy.IsRetryable = func(err error) bool { return true }
+	expectedData := &terraform.Options{
+		TerraformDir: "/abc/def/ghi",
+		Vars:         map[string]interface{}{},
+		RetryPolicy:  &policy,
+	}
+	SaveTerraformOptions(t, tmpFolder, expectedData)
+
+	// The retry policy has functions, so it is not saved
+	actualData := LoadTerraformOptions(t, tmpFolder)
+	expectedData.RetryPolicy = nil
+	assert.Equal(t, expectedData, actualData)
+}
+
 func TestSaveAndLoadAmiId(t *testing.T) {
 	t.Parallel()
 
@@ -220,3 +242,20 @@ func TestSaveAndLoadKubectlOptions(t *testing.T) {
 	actualData := LoadKubectlOptions(t, tmpFolder)
 	assert.Equal(t, expectedData, actualData)
 }
+
+func TestSaveAndLoadKubectlOptionsWithRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	tmpFolder := t.TempDir()
+
+	policy := retry.FixedPolicy(3, time.Second)
+	policy.OnRetry = func(attempt retry.Attempt) {}
+	expectedData := k8s.NewKubectlOptions("terratest-context", "~/.kube/config", "default")
+	expectedData.RetryPolicy = &policy
+	SaveKubectlOptions(t, tmpFolder, expectedData)
+
+	// The retry policy has functions, so it is not saved
+	actualData := LoadKubectlOptions(t, tmpFolder)
+	expectedData.RetryPolicy = nil
+	assert.Equal(t, expectedData, actualData)
+}
@@ -0,0 +1,322 @@
+package test_structure
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	terratest_aws "github.com/tnn-gruntwork-io/terratest/modules/aws"
+)
+
+const (
+	// TEST_DATA_DIR_ENV_VAR is the environment variable with the path of a directory, shared between the runners of
+	// the test stages (e.g. a network share or a CI cache), in which to store test data.
+	TEST_DATA_DIR_ENV_VAR = "TERRATEST_TEST_DATA_DIR"
+	// TEST_DATA_S3_BUCKET_ENV_VAR is the environment variable with the name of an S3 (or S3-compatible) bucket in which
+	// to store test data.
+	TEST_DATA_S3_BUCKET_ENV_VAR = "TERRATEST_TEST_DATA_S3_BUCKET"
+	// TEST_DATA_S3_PREFIX_ENV_VAR is the environment variable with the prefix of the keys of the test data stored in
+	// the TEST_DATA_S3_BUCKET_ENV_VAR bucket, e.g. the CI pipeline ID.
+	TEST_DATA_S3_PREFIX_ENV_VAR = "TERRATEST_TEST_DATA_S3_PREFIX"
+	// TEST_DATA_S3_REGION_ENV_VAR is the environment variable with the region of the TEST_DATA_S3_BUCKET_ENV_VAR
+	// bucket. Defaults to us-east-1.
+	TEST_DATA_S3_REGION_ENV_VAR = "TERRATEST_TEST_DATA_S3_REGION"
+	// TEST_DATA_S3_ENDPOINT_ENV_VAR is the environment variable with the endpoint of an S3-compatible service, such as
+	// MinIO, that hosts the TEST_DATA_S3_BUCKET_ENV_VAR bucket.
+	TEST_DATA_S3_ENDPOINT_ENV_VAR = "TERRATEST_TEST_DATA_S3_ENDPOINT"
+
+	defaultTestDataS3Region = "us-east-1"
+)
+
+// TestDataStore stores the test data saved by SaveTestData, Save and the functions built on them, such as
+// SaveTerraformOptions. Test data is identified by its path, as returned by FormatTestDataPath.
+type TestDataStore interface {
+	// ReadTestDataE returns the test data at the given path, or a TestDataNotFound error if there is none.
+	ReadTestDataE(path string) ([]byte, error)
+	// WriteTestDataE stores the given test data at the given path, overwriting any existing data. If sensitive is true,
+	// the data is kept private to the current user where the store supports it.
+	WriteTestDataE(path string, data []byte, sensitive bool) error
+	// ExistsE returns true if there is test data at the given path, without reading it.
+	ExistsE(path string) (bool, error)
+	// DeleteTestDataE deletes the test data at the given path, or if the path is a folder, such as a .test-data folder,
+	// all the test data in it. Deleting test data that does not exist is not an error.
+	DeleteTestDataE(path string) error
+}
+
+var (
+	testDataStoreMutex sync.Mutex
+	testDataStore      TestDataStore
+
+	// The S3 store configured by environment variables is created once, as it creates an authenticated session
+	s3TestDataStoreOnce sync.Once
+	s3TestDataStore     *S3TestDataStore
+	s3TestDataStoreErr  error
+)
+
+// SetTestDataStore sets the store used by all the functions that save and load test data. Pass nil to go back to
+// choosing the store with GetTestDataStore's defaults.
+func SetTestDataStore(store TestDataStore) {
+	testDataStoreMutex.Lock()
+	defer testDataStoreMutex.Unlock()
+	testDataStore = store
+}
+
+// GetTestDataStore returns the store used by all the functions that save and load test data. This is the store set
+// with SetTestDataStore, if any. Otherwise, it is chosen by environment variables, so that a setup stage on one CI
+// runner can hand off to a validate stage on another without any changes to the test code:
+//
+//   - If TERRATEST_TEST_DATA_DIR is set, a SharedDirTestDataStore in that directory.
+//   - If TERRATEST_TEST_DATA_S3_BUCKET is set, an S3TestDataStore for that bucket, configured with
+//     TERRATEST_TEST_DATA_S3_PREFIX, TERRATEST_TEST_DATA_S3_REGION and TERRATEST_TEST_DATA_S3_ENDPOINT.
+//   - Otherwise, a FileSystemTestDataStore, which stores test data in the .test-data folder of the test folder.
+//
+// Note that the shared stores identify test data by the path of the test folder, so the stages must use the same test
+// folder path on every runner. This is the case when the test folder is created with CopyTerraformFolderToTemp and
+// stages are selected with SKIP_<stage>, RUN_FROM_STAGE or STOP_AFTER_STAGE, as the original folder is then used.
+func GetTestDataStore() (TestDataStore, error) {
+	testDataStoreMutex.Lock()
+	store := testDataStore
+	testDataStoreMutex.Unlock()
+	if store != nil {
+		return store, nil
+	}
+
+	if dir := os.Getenv(TEST_DATA_DIR_ENV_VAR); dir != "" {
+		return SharedDirTestDataStore{Dir: dir}, nil
+	}
+	if bucket := os.Getenv(TEST_DATA_S3_BUCKET_ENV_VAR); bucket != "" {
+		s3TestDataStoreOnce.Do(func() {
+			region := os.Getenv(TEST_DATA_S3_REGION_ENV_VAR)
+			if region == "" {
+				region = defaultTestDataS3Region
+			}
+			s3TestDataStore, s3TestDataStoreErr = NewS3TestDataStoreE(region, os.Getenv(TEST_DATA_S3_ENDPOINT_ENV_VAR), bucket, os.Getenv(TEST_DATA_S3_PREFIX_ENV_VAR))
+		})
+		if s3TestDataStoreErr != nil {
+			return nil, s3TestDataStoreErr
+		}
+		return s3TestDataStore, nil
+	}
+	return FileSystemTestDataStore{}, nil
+}
+
+// FileSystemTestDataStore stores test data as files at their paths on the local file system. This is the default
+// store.
+type FileSystemTestDataStore struct{}
+
+// ReadTestDataE returns the contents of the file at the given path.
+func (store FileSystemTestDataStore) ReadTestDataE(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, TestDataNotFound{Path: path}
+	}
+	return data, err
+}
+
+// WriteTestDataE writes the given data to the file at the given path, creating its folder if necessary. Files with
+// sensitive data are only readable by the current user.
+func (store FileSystemTestDataStore) WriteTestDataE(path string, data []byte, sensitive bool) error {
+	parentDir := filepath.Dir(path)
+	if err := os.MkdirAll(parentDir, 0777); err != nil {
+		return fmt.Errorf("failed to create folder %s: %w", parentDir, err)
+	}
+	if !sensitive {
+		return os.WriteFile(path, data, 0644)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(path, 0600)
+}
+
+// ExistsE returns true if there is a file or folder at the given path.
+func (store FileSystemTestDataStore) ExistsE(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteTestDataE deletes the file or folder at the given path.
+func (store FileSystemTestDataStore) DeleteTestDataE(path string) error {
+	return os.RemoveAll(path)
+}
+
+// SharedDirTestDataStore stores test data as files in a directory shared between the runners of the test stages, such
+// as a network share, a volume mounted into every container, or a folder cached between CI jobs.
+type SharedDirTestDataStore struct {
+	Dir string
+}
+
+// ReadTestDataE returns the test data at the given path.
+func (store SharedDirTestDataStore) ReadTestDataE(path string) ([]byte, error) {
+	data, err := FileSystemTestDataStore{}.ReadTestDataE(store.filePath(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, TestDataNotFound{Path: path}
+	}
+	return data, err
+}
+
+// WriteTestDataE stores the given test data at the given path.
+func (store SharedDirTestDataStore) WriteTestDataE(path string, data []byte, sensitive bool) error {
+	return FileSystemTestDataStore{}.WriteTestDataE(store.filePath(path), data, sensitive)
+}
+
+// ExistsE returns true if there is test data at the given path.
+func (store SharedDirTestDataStore) ExistsE(path string) (bool, error) {
+	return FileSystemTestDataStore{}.ExistsE(store.filePath(path))
+}
+
+// DeleteTestDataE deletes the test data at, or under, the given path.
+func (store SharedDirTestDataStore) DeleteTestDataE(path string) error {
+	return FileSystemTestDataStore{}.DeleteTestDataE(store.filePath(path))
+}
+
+func (store SharedDirTestDataStore) filePath(path string) string {
+	return filepath.Join(store.Dir, filepath.FromSlash(testDataKey(path)))
+}
+
+// S3TestDataStore stores test data as objects in an S3 bucket, or a bucket of an S3-compatible service such as MinIO.
+type S3TestDataStore struct {
+	Client s3iface.S3API
+	Bucket string
+	Prefix string // Prefix for the keys of all test data, e.g. the CI pipeline ID, so that concurrent pipelines don't clash
+}
+
+// NewS3TestDataStoreE returns an S3TestDataStore for the given bucket. If endpoint is not empty, the bucket is accessed
+// through that endpoint with path-style addressing, as most S3-compatible services require.
+func NewS3TestDataStoreE(region string, endpoint string, bucket string, prefix string) (*S3TestDataStore, error) {
+	sess, err := terratest_aws.NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, err
+	}
+	config := aws.NewConfig()
+	if endpoint != "" {
+		config = config.WithEndpoint(endpoint).WithS3ForcePathStyle(true)
+	}
+	return &S3TestDataStore{Client: s3.New(sess, config), Bucket: bucket, Prefix: prefix}, nil
+}
+
+// ReadTestDataE returns the contents of the object for the given path.
+func (store *S3TestDataStore) ReadTestDataE(path string) ([]byte, error) {
+	output, err := store.Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(store.Bucket),
+		Key:    aws.String(store.objectKey(path)),
+	})
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, TestDataNotFound{Path: path}
+		}
+		return nil, err
+	}
+	defer output.Body.Close()
+	return io.ReadAll(output.Body)
+}
+
+// WriteTestDataE stores the given data as the object for the given path. Access to sensitive data is controlled by the
+// policies of the bucket, like the rest of the test data.
+func (store *S3TestDataStore) WriteTestDataE(path string, data []byte, sensitive bool) error {
+	_, err := store.Client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(store.Bucket),
+		Key:    aws.String(store.objectKey(path)),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+// ExistsE returns true if there is an object for the given path, without downloading it.
+func (store *S3TestDataStore) ExistsE(path string) (bool, error) {
+	_, err := store.Client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(store.Bucket),
+		Key:    aws.String(store.objectKey(path)),
+	})
+	if err != nil {
+		// HeadObject responses have no body, so a missing object is reported with the HTTP status, as NotFound
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && (awsErr.Code() == "NotFound" || awsErr.Code() == s3.ErrCodeNoSuchKey) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// DeleteTestDataE deletes the object for the given path, and all the objects under it.
+func (store *S3TestDataStore) DeleteTestDataE(path string) error {
+	key := store.objectKey(path)
+	keys := []string{key}
+
+	err := store.Client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(store.Bucket),
+		Prefix: aws.String(key + "/"),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, object := range page.Contents {
+			keys = append(keys, aws.StringValue(object.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		_, err := store.Client.DeleteObject(&s3.DeleteObjectInput{
+			Bucket: aws.String(store.Bucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store *S3TestDataStore) objectKey(testDataPath string) string {
+	return path.Join(store.Prefix, testDataKey(testDataPath))
+}
+
+// testDataKey converts a test data path into a relative, slash-separated key for the shared stores. E.g.
+// ../examples/foo/.test-data/TerraformOptions.json becomes __/examples/foo/.test-data/TerraformOptions.json.
+func testDataKey(testDataPath string) string {
+	components := strings.Split(filepath.ToSlash(filepath.Clean(testDataPath)), "/")
+	key := []string{}
+	for _, component := range components {
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			key = append(key, "__")
+		default:
+			key = append(key, component)
+		}
+	}
+	return strings.Join(key, "/")
+}
+
+// TestDataNotFound is an error that occurs when reading test data that was never saved, or has been deleted.
+type TestDataNotFound struct {
+	Path string
+}
+
+func (err TestDataNotFound) Error() string {
+	return fmt.Sprintf("no test data at %s", err.Path)
+}
+
+// Is makes errors.Is(err, fs.ErrNotExist) true for TestDataNotFound errors.
+func (err TestDataNotFound) Is(target error) bool {
+	return target == fs.ErrNotExist
+}
@@ -0,0 +1,176 @@
+package test_structure
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/tnn-gruntwork-io/terratest/modules/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestDataKey(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"../examples/foo/.test-data/TerraformOptions.json", "__/examples/foo/.test-data/TerraformOptions.json"},
+		{"/tmp/foo/.test-data/AMI.json", "tmp/foo/.test-data/AMI.json"},
+		{"./foo//.test-data/../.test-data/AMI.json", "foo/.test-data/AMI.json"},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, testDataKey(testCase.path))
+	}
+}
+
+// Not parallel, as it sets environment variables
+func TestSharedDirTestDataStoreFromEnv(t *testing.T) {
+	sharedDir := t.TempDir()
+	t.Setenv(TEST_DATA_DIR_ENV_VAR, sharedDir)
+
+	store, err := GetTestDataStore()
+	require.NoError(t, err)
+	assert.Equal(t, SharedDirTestDataStore{Dir: sharedDir}, store)
+
+	testFolder := "../examples/terraform-basic-example"
+	SaveString(t, testFolder, "Name", "foo")
+	assert.True(t, files.FileExists(filepath.Join(sharedDir, "__", "examples", "terraform-basic-example", ".test-data", "Name.json")))
+	assert.False(t, files.FileExists(FormatTestDataPath(testFolder, "Name.json")), "test data should not be stored in the test folder")
+	assert.Equal(t, "foo", LoadString(t, testFolder, "Name"))
+
+	CleanupTestDataFolder(t, testFolder)
+	assert.False(t, IsTestDataPresent(t, FormatTestDataPath(testFolder, "Name.json")))
+}
+
+func TestS3TestDataStore(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeS3Client{objects: map[string][]byte{"other-pipeline/foo/.test-data/AMI.json": []byte("other")}}
+	store := &S3TestDataStore{Client: client, Bucket: "test-data", Prefix: "pipeline-1"}
+
+	_, err := store.ReadTestDataE("foo/.test-data/AMI.json")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	exists, err := store.ExistsE("foo/.test-data/AMI.json")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, store.WriteTestDataE("foo/.test-data/AMI.json", []byte(`"ami-123"`), false))
+	require.NoError(t, store.WriteTestDataE("foo/.test-data/Name.json", []byte(`"name"`), true))
+	assert.Equal(t, []string{"other-pipeline/foo/.test-data/AMI.json", "pipeline-1/foo/.test-data/AMI.json", "pipeline-1/foo/.test-data/Name.json"}, client.keys())
+
+	data, err := store.ReadTestDataE("foo/.test-data/AMI.json")
+	require.NoError(t, err)
+	assert.Equal(t, `"ami-123"`, string(data))
+	gets := client.gets
+	exists, err = store.ExistsE("foo/.test-data/AMI.json")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, gets, client.gets, "checking whether test data exists should not download it")
+
+	require.NoError(t, store.DeleteTestDataE("foo/.test-data"))
+	assert.Equal(t, []string{"other-pipeline/foo/.test-data/AMI.json"}, client.keys())
+}
+
+// Not parallel, as it sets environment variables
+func TestS3TestDataStoreFromEnvIsCreatedOnce(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv(TEST_DATA_DIR_ENV_VAR, "")
+	t.Setenv(TEST_DATA_S3_BUCKET_ENV_VAR, "test-data")
+
+	store, err := GetTestDataStore()
+	require.NoError(t, err)
+	sameStore, err := GetTestDataStore()
+	require.NoError(t, err)
+	assert.Same(t, store, sameStore)
+}
+
+func TestFileSystemTestDataStoreKeepsSensitiveDataPrivate(t *testing.T) {
+	t.Parallel()
+
+	testFolder := t.TempDir()
+	path := FormatTestDataPath(testFolder, "Key.json")
+	SaveSensitive(t, path, "secret")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Overwriting test data with sensitive data makes the file private
+	path = FormatTestDataPath(testFolder, "Name.json")
+	SaveString(t, testFolder, "Name", "foo")
+	require.NoError(t, FileSystemTestDataStore{}.WriteTestDataE(path, []byte(`"secret"`), true))
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+// fakeS3Client is an in-memory implementation of the S3 API calls used by S3TestDataStore.
+type fakeS3Client struct {
+	s3iface.S3API
+	objects map[string][]byte
+	gets    int
+}
+
+func (client *fakeS3Client) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	client.gets++
+	data, exists := client.objects[aws.StringValue(input.Key)]
+	if !exists {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "The specified key does not exist.", nil)
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (client *fakeS3Client) HeadObject(input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	data, exists := client.objects[aws.StringValue(input.Key)]
+	if !exists {
+		return nil, awserr.New("NotFound", "Not Found", nil)
+	}
+	return &s3.HeadObjectOutput{ContentLength: aws.Int64(int64(len(data)))}, nil
+}
+
+func (client *fakeS3Client) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	client.objects[aws.StringValue(input.Key)] = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (client *fakeS3Client) ListObjectsV2Pages(input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool) error {
+	output := &s3.ListObjectsV2Output{}
+	for _, key := range client.keys() {
+		if strings.HasPrefix(key, aws.StringValue(input.Prefix)) {
+			output.Contents = append(output.Contents, &s3.Object{Key: aws.String(key)})
+		}
+	}
+	fn(output, true)
+	return nil
+}
+
+func (client *fakeS3Client) DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
+	delete(client.objects, aws.StringValue(input.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (client *fakeS3Client) keys() []string {
+	keys := []string{}
+	for key := range client.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	go_test "testing"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/tnn-gruntwork-io/terratest/modules/opa"
 	"github.com/tnn-gruntwork-io/terratest/modules/terraform"
 	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -32,8 +35,13 @@ func RunTestStage(t testing.TestingT, stageName string, stage func()) {
 }
 
 // SkipStageEnvVarSet returns true if an environment variable is set instructing Terratest to skip a test stage. This can be an easy way
-// to tell if the tests are running in a local dev environment vs a CI server.
+// to tell if the tests are running in a local dev environment vs a CI server. Selecting the stages of a Workflow to run with
+// RUN_FROM_STAGE or STOP_AFTER_STAGE (or the equivalent flags) also counts as skipping stages.
 func SkipStageEnvVarSet() bool {
+	if runFrom, stopAfter := selectedStageRange(); runFrom != "" || stopAfter != "" {
+		return true
+	}
+
 	for _, environmentVariable := range os.Environ() {
 		if strings.HasPrefix(environmentVariable, SKIP_STAGE_ENV_VAR_PREFIX) {
 			return true
@@ -106,6 +114,29 @@ func CopyTerraformFolderToTemp(t testing.TestingT, rootFolder string, terraformM
 // there are no other concurrent tests running and we want to be able to cache test data between test stages, so in that
 // case, we do NOT copy anything to a temp folder, and return the path to the original terraform module folder instead.
 func CopyTerraformFolderToDest(t testing.TestingT, rootFolder string, terraformModuleFolder string, destRootFolder string) string {
+	return CopyTerraformFolderToDestWithOptions(t, rootFolder, terraformModuleFolder, destRootFolder, files.CopyOptions{})
+}
+
+// CopyTerraformFolderToTempWithOptions is like CopyTerraformFolderToTemp, but with the given options for copying. E.g.,
+// to copy only the terraform module folder and the local modules it calls, rather than the entire root folder, and to
+// symlink the files rather than copy them:
+//
+//	tempTestFolder := test_structure.CopyTerraformFolderToTempWithOptions(t, rootFolder, terraformFolderRelativeToRoot, files.CopyOptions{
+//		Mode:               files.CopyModeSymlink,
+//		RespectIgnoreFiles: true,
+//	})
+//
+// If any option is set but ModuleFolders is empty, ModuleFolders defaults to the terraform module folder, so only it
+// and the local modules it calls are copied. Set ModuleFolders to copy other modules along with it. See
+// files.CopyOptions for all the options. As with CopyTerraformFolderToTemp, nothing is copied if any of the
+// SKIP_<stage> environment variables is set.
+func CopyTerraformFolderToTempWithOptions(t testing.TestingT, rootFolder string, terraformModuleFolder string, options files.CopyOptions) string {
+	return CopyTerraformFolderToDestWithOptions(t, rootFolder, terraformModuleFolder, os.TempDir(), options)
+}
+
+// CopyTerraformFolderToDestWithOptions is like CopyTerraformFolderToDest, but with the given options for copying. See
+// CopyTerraformFolderToTempWithOptions.
+func CopyTerraformFolderToDestWithOptions(t testing.TestingT, rootFolder string, terraformModuleFolder string, destRootFolder string, options files.CopyOptions) string {
 	if SkipStageEnvVarSet() {
 		logger.Logf(t, "A SKIP_XXX environment variable is set. Using original examples folder rather than a temp folder so we can cache data between stages for faster local testing.")
 		return filepath.Join(rootFolder, terraformModuleFolder)
@@ -119,7 +150,11 @@ func CopyTerraformFolderToDest(t testing.TestingT, rootFolder string, terraformM
 		t.Fatal(files.DirNotFoundError{Directory: fullTerraformModuleFolder})
 	}
 
-	tmpRootFolder, err := files.CopyTerraformFolderToDest(rootFolder, destRootFolder, cleanName(t.Name()))
+	if len(options.ModuleFolders) == 0 && (len(options.ExtraPaths) > 0 || options.Mode != files.CopyModeCopy || options.RespectIgnoreFiles) {
+		options.ModuleFolders = []string{terraformModuleFolder}
+	}
+
+	tmpRootFolder, err := files.CopyTerraformFolderToDestWithOptions(rootFolder, destRootFolder, cleanName(t.Name()), options)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -146,20 +181,31 @@ func cleanName(originalName string) string {
 // own testing package. We are using the native testing.T here because Terratest's testing.T struct does not implement Run
 // Note that we have opted to place the ValidateAllTerraformModules function here instead of in the terraform package
 // to avoid import cycling
+// Set ValidationOptions.Parallelism to validate up to that many modules concurrently, in parallel subtests. As parallel
+// subtests only start once the test function returns, don't run any code that depends on the validation after calling
+// this function. Set PluginCacheDir to share downloaded providers between modules (with Parallelism, the first module
+// is validated on its own to fill the cache), JSONReportPath and JUnitReportPath to write a ValidationSummary with the
+// result, duration and first diagnostic of each module, and AdditionalChecks to run checks such as tflint on each
+// module.
 func ValidateAllTerraformModules(t *go_test.T, opts *ValidationOptions) {
 	runValidateOnAllTerraformModules(
 		t,
 		opts,
-		func(t *go_test.T, fileType ValidateFileType, tfOpts *terraform.Options) {
+		func(t *go_test.T, fileType ValidateFileType, tfOpts *terraform.Options) error {
 			if fileType == TG {
 				tfOpts.TerraformBinary = "terragrunt"
 				// First call init and terraform validate
-				terraform.InitAndValidate(t, tfOpts)
+				if _, err := terraform.InitAndValidateE(t, tfOpts); err != nil {
+					return err
+				}
 				// Next, call terragrunt validate-inputs which will catch mis-aligned inputs provided via Terragrunt
-				terraform.ValidateInputs(t, tfOpts)
+				_, err := terraform.ValidateInputsE(t, tfOpts)
+				return err
 			} else if fileType == TF {
-				terraform.InitAndValidate(t, tfOpts)
+				_, err := terraform.InitAndValidateE(t, tfOpts)
+				return err
 			}
+			return nil
 		},
 	)
 }
@@ -179,8 +225,8 @@ func OPAEvalAllTerraformModules(
 	runValidateOnAllTerraformModules(
 		t,
 		opts,
-		func(t *go_test.T, _ ValidateFileType, tfOpts *terraform.Options) {
-			terraform.OPAEval(t, tfOpts, opaEvalOpts, resultQuery)
+		func(t *go_test.T, _ ValidateFileType, tfOpts *terraform.Options) error {
+			return terraform.OPAEvalE(t, tfOpts, opaEvalOpts, resultQuery)
 		},
 	)
 }
@@ -190,31 +236,97 @@ func OPAEvalAllTerraformModules(
 func runValidateOnAllTerraformModules(
 	t *go_test.T,
 	opts *ValidationOptions,
-	validationFunc func(t *go_test.T, fileType ValidateFileType, tfOps *terraform.Options),
+	validationFunc func(t *go_test.T, fileType ValidateFileType, tfOps *terraform.Options) error,
 ) {
 	dirsToValidate, readErr := FindTerraformModulePathsInRootE(opts)
 	require.NoError(t, readErr)
+	sort.Strings(dirsToValidate)
+
+	if opts.PluginCacheDir != "" {
+		require.NoError(t, os.MkdirAll(opts.PluginCacheDir, 0755))
+	}
+
+	results := &validationResults{}
+	start := time.Now()
+	// Cleanup functions run once all the subtests, including the parallel ones, have completed
+	t.Cleanup(func() {
+		summary := results.summary(time.Since(start))
+		logger.Logf(t, "Validated %d modules in %.1fs: %d passed, %d failed", len(summary.Modules), summary.DurationSeconds, summary.Passed, summary.Failed)
+		if opts.JSONReportPath != "" {
+			require.NoError(t, WriteValidationSummaryJSON(opts.JSONReportPath, summary))
+		}
+		if opts.JUnitReportPath != "" {
+			require.NoError(t, WriteValidationSummaryJUnit(opts.JUnitReportPath, summary))
+		}
+	})
 
-	for _, dir := range dirsToValidate {
+	var semaphore chan struct{}
+	if opts.Parallelism > 0 {
+		semaphore = make(chan struct{}, opts.Parallelism)
+	}
+
+	for i, dir := range dirsToValidate {
 		dir := dir
+		// Terraform does not support concurrent installs into the plugin cache, so the first module is validated on its
+		// own, which fills the cache with the providers that are typically shared by all the modules, before the others
+		// are validated in parallel
+		parallel := semaphore != nil && !(opts.PluginCacheDir != "" && i == 0)
 		t.Run(strings.TrimLeft(dir, "/"), func(t *go_test.T) {
-			// Determine the absolute path to the git repository root
-			cwd, cwdErr := os.Getwd()
-			require.NoError(t, cwdErr)
-			gitRoot, gitRootErr := filepath.Abs(filepath.Join(cwd, "../../"))
-			require.NoError(t, gitRootErr)
-
-			// Determine the relative path to the example, module, etc that is currently being considered
-			relativePath, pathErr := filepath.Rel(gitRoot, dir)
-			require.NoError(t, pathErr)
-			// Copy git root to tmp and supply the path to the current module to run init and validate on
-			testFolder := CopyTerraformFolderToTemp(t, gitRoot, relativePath)
-			require.NotNil(t, testFolder)
-
-			// Run Terraform init and terraform validate on the test folder that was copied to /tmp
-			// to avoid any potential conflicts with tests that may not use the same copy to /tmp behavior
-			tfOpts := &terraform.Options{TerraformDir: testFolder}
-			validationFunc(t, opts.FileType, tfOpts)
+			if parallel {
+				t.Parallel()
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+			}
+			validateTerraformModule(t, opts, dir, validationFunc, results)
 		})
 	}
 }
+
+// validateTerraformModule copies the module in the given dir to a temp folder, runs the validation function and the
+// additional checks on it, and records the result.
+func validateTerraformModule(
+	t *go_test.T,
+	opts *ValidationOptions,
+	dir string,
+	validationFunc func(t *go_test.T, fileType ValidateFileType, tfOps *terraform.Options) error,
+	results *validationResults,
+) {
+	start := time.Now()
+	var firstErr error
+	// Deferred, so that the result is recorded even if the validation fails the test with t.FailNow
+	defer func() {
+		duration := time.Since(start)
+		logger.Logf(t, "Validation of %s took %.1fs", dir, duration.Seconds())
+		results.add(opts.RootDir, dir, duration, t.Failed(), firstErr)
+	}()
+
+	// Determine the absolute path to the git repository root
+	cwd, cwdErr := os.Getwd()
+	require.NoError(t, cwdErr)
+	gitRoot, gitRootErr := filepath.Abs(filepath.Join(cwd, "../../"))
+	require.NoError(t, gitRootErr)
+
+	// Determine the relative path to the example, module, etc that is currently being considered
+	relativePath, pathErr := filepath.Rel(gitRoot, dir)
+	require.NoError(t, pathErr)
+	// Copy git root to tmp and supply the path to the current module to run init and validate on
+	testFolder := CopyTerraformFolderToTemp(t, gitRoot, relativePath)
+	require.NotNil(t, testFolder)
+
+	// Run Terraform init and terraform validate on the test folder that was copied to /tmp
+	// to avoid any potential conflicts with tests that may not use the same copy to /tmp behavior
+	tfOpts := &terraform.Options{TerraformDir: testFolder}
+	if opts.PluginCacheDir != "" {
+		tfOpts.EnvVars = map[string]string{"TF_PLUGIN_CACHE_DIR": opts.PluginCacheDir}
+	}
+
+	firstErr = validationFunc(t, opts.FileType, tfOpts)
+	assert.NoError(t, firstErr)
+	for _, check := range opts.AdditionalChecks {
+		err := check(t, opts, tfOpts)
+		assert.NoError(t, err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+}
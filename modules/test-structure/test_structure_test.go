@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/tnn-gruntwork-io/terratest/modules/collections"
+	"github.com/tnn-gruntwork-io/terratest/modules/files"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -144,3 +145,15 @@ func TestValidateTerragruntModulesOnTerratestByInclusion(t *testing.T) {
 
 	ValidateAllTerraformModules(t, opts)
 }
+
+func TestCopyModuleDependenciesToTempFolder(t *testing.T) {
+	t.Parallel()
+
+	tempFolder := CopyTerraformFolderToTempWithOptions(t, "../../", "examples/terraform-basic-example", files.CopyOptions{
+		Mode: files.CopyModeSymlink,
+	})
+	// Remove the copy of the root folder, which the module folder is in
+	defer os.RemoveAll(filepath.Join(tempFolder, "..", ".."))
+	assert.FileExists(t, filepath.Join(tempFolder, "main.tf"))
+	assert.NoDirExists(t, filepath.Join(tempFolder, "..", "terraform-aws-example"))
+}
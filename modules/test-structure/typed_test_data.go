@@ -0,0 +1,329 @@
+package test_structure
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/logger"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+// TEST_DATA_ENCRYPTION_KEY_ENV_VAR is the environment variable with the key used to encrypt test data saved with
+// SaveEncrypted and SaveSensitive. Any string can be used as the key; it is hashed into an AES-256 key.
+const TEST_DATA_ENCRYPTION_KEY_ENV_VAR = "TERRATEST_TEST_DATA_ENCRYPTION_KEY"
+
+// TestDataSchemaVersion is the version of the TestDataEnvelope format written by Save. Load fails on files written
+// with a newer version.
+const TestDataSchemaVersion = 1
+
+// TestDataEnvelope is the on-disk format of the test data written by Save, SaveEncrypted and SaveSensitive. It
+// records when and by which test the value was saved, so that stale data from an earlier run is easy to recognize.
+type TestDataEnvelope struct {
+	SchemaVersion int       `json:"terratestTestDataVersion"`
+	SavedAt       time.Time `json:"savedAt"`
+	TestName      string    `json:"testName"`
+	Encrypted     bool      `json:"encrypted,omitempty"`
+	// The value as JSON, or if Encrypted, the AES-GCM nonce followed by the encrypted JSON, as a JSON string in
+	// base64
+	Value json.RawMessage `json:"value"`
+}
+
+// Save serializes and saves a value used at test time to the given path, in a TestDataEnvelope. Use Load to load it
+// with the same type during a later stage.
+func Save[T any](t testing.TestingT, path string, value T) {
+	if IsTestDataPresent(t, path) {
+		logger.Logf(t, "[WARNING] The named test data at path %s is non-empty. Save operation will overwrite existing value with \"%v\".\n.", path, value)
+	}
+	if err := SaveE(t, path, value); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+}
+
+// SaveE serializes and saves a value used at test time to the given path, in a TestDataEnvelope.
+func SaveE[T any](t testing.TestingT, path string, value T) error {
+	return saveEnvelopeE(t, path, value, nil, false)
+}
+
+// SaveEncrypted serializes, encrypts and saves a sensitive value, such as a key pair, to the given path, so that it
+// does not leak if the .test-data folder is committed or uploaded by accident. The key is read from the
+// TERRATEST_TEST_DATA_ENCRYPTION_KEY environment variable, which must be set, and must also be set when the value is
+// loaded with Load.
+func SaveEncrypted[T any](t testing.TestingT, path string, value T) {
+	warnIfOverwritingSensitiveTestData(t, path)
+	if err := SaveEncryptedE(t, path, value); err != nil {
+		t.Fatalf("Failed to save encrypted test data: %v", err)
+	}
+}
+
+// SaveEncryptedE serializes, encrypts and saves a sensitive value to the given path. See SaveEncrypted.
+func SaveEncryptedE[T any](t testing.TestingT, path string, value T) error {
+	key, err := testDataEncryptionKeyE()
+	if err != nil {
+		return err
+	}
+	return saveEnvelopeE(t, path, value, key, true)
+}
+
+// SaveSensitive is like SaveEncrypted when the TERRATEST_TEST_DATA_ENCRYPTION_KEY environment variable is set, and
+// like Save, with a warning, when it is not. Either way, the file is only readable by the current user.
+func SaveSensitive[T any](t testing.TestingT, path string, value T) {
+	warnIfOverwritingSensitiveTestData(t, path)
+	if err := SaveSensitiveE(t, path, value); err != nil {
+		t.Fatalf("Failed to save sensitive test data: %v", err)
+	}
+}
+
+// SaveSensitiveE is like SaveEncryptedE when the TERRATEST_TEST_DATA_ENCRYPTION_KEY environment variable is set, and
+// like SaveE, with a warning, when it is not.
+func SaveSensitiveE[T any](t testing.TestingT, path string, value T) error {
+	key, err := testDataEncryptionKeyE()
+	if err != nil {
+		logger.Logf(t, "[WARNING] %s is not set, so the sensitive test data at path %s will be stored unencrypted.", TEST_DATA_ENCRYPTION_KEY_ENV_VAR, path)
+		return saveEnvelopeE(t, path, value, nil, true)
+	}
+	return saveEnvelopeE(t, path, value, key, true)
+}
+
+// Load loads and unserializes a value of type T stored at the given path by Save, SaveEncrypted, SaveSensitive or
+// SaveTestData. Encrypted values are decrypted with the key in the TERRATEST_TEST_DATA_ENCRYPTION_KEY environment
+// variable. If T is a pointer type, the test fails if the stored value is null.
+func Load[T any](t testing.TestingT, path string) T {
+	value, err := LoadE[T](t, path)
+	if err != nil {
+		t.Fatalf("Failed to load test data: %v", err)
+	}
+	return value
+}
+
+// LoadE loads and unserializes a value of type T stored at the given path. See Load. If T is a pointer type, this
+// returns a NullTestData error if the stored value is null, rather than a nil pointer.
+func LoadE[T any](t testing.TestingT, path string) (T, error) {
+	logger.Logf(t, "Loading test data from %s", path)
+
+	var value T
+	store, err := GetTestDataStore()
+	if err != nil {
+		return value, err
+	}
+	bytes, err := store.ReadTestDataE(path)
+	if err != nil {
+		return value, fmt.Errorf("failed to load value from %s: %w", path, err)
+	}
+	valueJSON, err := unwrapTestDataE(bytes)
+	if err != nil {
+		return value, fmt.Errorf("failed to load value from %s: %w", path, err)
+	}
+	if err := json.Unmarshal(valueJSON, &value); err != nil {
+		return value, fmt.Errorf("failed to parse JSON for value %s: %w", path, err)
+	}
+	if reflectValue := reflect.ValueOf(&value).Elem(); reflectValue.Kind() == reflect.Ptr && reflectValue.IsNil() {
+		return value, NullTestData{Path: path}
+	}
+	return value, nil
+}
+
+// LoadEnvelopeE loads the TestDataEnvelope stored at the given path, without decrypting or unserializing its value.
+func LoadEnvelopeE(path string) (*TestDataEnvelope, error) {
+	store, err := GetTestDataStore()
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := store.ReadTestDataE(path)
+	if err != nil {
+		return nil, err
+	}
+	envelope, isEnvelope := parseEnvelope(bytes)
+	if !isEnvelope {
+		return nil, NotATestDataEnvelope{Path: path}
+	}
+	return envelope, nil
+}
+
+// warnIfOverwritingSensitiveTestData logs the same warning as SaveTestData when the given path already has test data,
+// but without the value, which is sensitive.
+func warnIfOverwritingSensitiveTestData(t testing.TestingT, path string) {
+	if IsTestDataPresent(t, path) {
+		logger.Logf(t, "[WARNING] The named test data at path %s is non-empty. Save operation will overwrite existing value.", path)
+	}
+}
+
+// saveEnvelopeE saves the given value in a TestDataEnvelope, encrypted with the given key if it is not nil. Sensitive
+// values are kept private to the current user by the stores that support it.
+func saveEnvelopeE[T any](t testing.TestingT, path string, value T, key []byte, sensitive bool) error {
+	if key == nil {
+		logger.Logf(t, "Storing test data in %s so it can be reused later", path)
+	} else {
+		logger.Logf(t, "Storing encrypted test data in %s so it can be reused later", path)
+	}
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to convert value %s to JSON: %w", path, err)
+	}
+
+	envelope := TestDataEnvelope{
+		SchemaVersion: TestDataSchemaVersion,
+		SavedAt:       time.Now().UTC(),
+		TestName:      t.Name(),
+		Value:         valueJSON,
+	}
+	if key != nil {
+		ciphertext, err := encryptTestData(key, valueJSON)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt value %s: %w", path, err)
+		}
+		// A []byte is marshalled as a base64 string
+		envelope.Value, err = json.Marshal(ciphertext)
+		if err != nil {
+			return err
+		}
+		envelope.Encrypted = true
+	}
+
+	bytes, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to convert value %s to JSON: %w", path, err)
+	}
+
+	store, err := GetTestDataStore()
+	if err != nil {
+		return err
+	}
+	if err := store.WriteTestDataE(path, bytes, sensitive); err != nil {
+		return fmt.Errorf("failed to save value %s: %w", path, err)
+	}
+	return nil
+}
+
+// unwrapTestDataE returns the JSON of the value in the given test data, decrypting it if necessary. Test data that is
+// not in a TestDataEnvelope, as written by SaveTestData, is returned as is.
+func unwrapTestDataE(bytes []byte) ([]byte, error) {
+	envelope, isEnvelope := parseEnvelope(bytes)
+	if !isEnvelope {
+		return bytes, nil
+	}
+	if envelope.SchemaVersion > TestDataSchemaVersion {
+		return nil, UnsupportedTestDataVersion{Version: envelope.SchemaVersion}
+	}
+	if !envelope.Encrypted {
+		return envelope.Value, nil
+	}
+
+	key, err := testDataEncryptionKeyE()
+	if err != nil {
+		return nil, err
+	}
+	var ciphertext []byte
+	if err := json.Unmarshal(envelope.Value, &ciphertext); err != nil {
+		return nil, err
+	}
+	return decryptTestData(key, ciphertext)
+}
+
+// parseEnvelope parses the given test data as a TestDataEnvelope, and returns false if it is not one.
+func parseEnvelope(bytes []byte) (*TestDataEnvelope, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(bytes, &fields); err != nil {
+		return nil, false
+	}
+	if _, hasVersion := fields["terratestTestDataVersion"]; !hasVersion {
+		return nil, false
+	}
+	var envelope TestDataEnvelope
+	if err := json.Unmarshal(bytes, &envelope); err != nil {
+		return nil, false
+	}
+	return &envelope, true
+}
+
+// testDataEncryptionKeyE returns the AES-256 key derived from the TERRATEST_TEST_DATA_ENCRYPTION_KEY environment
+// variable.
+func testDataEncryptionKeyE() ([]byte, error) {
+	secret := os.Getenv(TEST_DATA_ENCRYPTION_KEY_ENV_VAR)
+	if secret == "" {
+		return nil, TestDataEncryptionKeyNotSet{}
+	}
+	key := sha256.Sum256([]byte(secret))
+	return key[:], nil
+}
+
+func encryptTestData(key []byte, plaintext []byte) ([]byte, error) {
+	gcm, err := newTestDataCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func decryptTestData(key []byte, ciphertext []byte) ([]byte, error) {
+	gcm, err := newTestDataCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("encrypted test data is too short")
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt test data, check %s: %w", TEST_DATA_ENCRYPTION_KEY_ENV_VAR, err)
+	}
+	return plaintext, nil
+}
+
+func newTestDataCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// TestDataEncryptionKeyNotSet is an error that occurs when saving or loading encrypted test data without the
+// TERRATEST_TEST_DATA_ENCRYPTION_KEY environment variable.
+type TestDataEncryptionKeyNotSet struct{}
+
+func (err TestDataEncryptionKeyNotSet) Error() string {
+	return fmt.Sprintf("the %s environment variable must be set to encrypt or decrypt test data", TEST_DATA_ENCRYPTION_KEY_ENV_VAR)
+}
+
+// UnsupportedTestDataVersion is an error that occurs when loading test data saved by a newer version of Terratest.
+type UnsupportedTestDataVersion struct {
+	Version int
+}
+
+func (err UnsupportedTestDataVersion) Error() string {
+	return fmt.Sprintf("test data has schema version %d, but this version of Terratest only supports up to version %d", err.Version, TestDataSchemaVersion)
+}
+
+// NotATestDataEnvelope is an error that occurs when test data was not saved in a TestDataEnvelope, e.g. because it was
+// saved with SaveTestData.
+type NotATestDataEnvelope struct {
+	Path string
+}
+
+func (err NotATestDataEnvelope) Error() string {
+	return fmt.Sprintf("test data at %s was not saved with test_structure.Save", err.Path)
+}
+
+// NullTestData is an error that occurs when loading test data into a pointer type, but the stored value is null.
+type NullTestData struct {
+	Path string
+}
+
+func (err NullTestData) Error() string {
+	return fmt.Sprintf("test data at %s is null", err.Path)
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"path"
 	"path/filepath"
+	go_test "testing"
 
 	go_commons_collections "github.com/tnn-gruntwork-io/go-commons/collections"
 	"github.com/tnn-gruntwork-io/terratest/modules/collections"
 	"github.com/tnn-gruntwork-io/terratest/modules/files"
+	"github.com/tnn-gruntwork-io/terratest/modules/terraform"
 	"github.com/mattn/go-zglob"
 )
 
@@ -40,8 +42,28 @@ type ValidationOptions struct {
 	// Note that while the struct requires full paths, you can pass relative paths to the NewValidationOptions function
 	// which will build the full paths based on the supplied RootDir
 	ExcludeDirs []string
+	// The maximum number of modules to validate concurrently. If 0, modules are validated one at a time. Note that the
+	// -parallel flag of go test also limits the number of modules validated concurrently.
+	Parallelism int
+	// If set, the Terraform provider plugin cache directory (TF_PLUGIN_CACHE_DIR) shared by all modules, so that each
+	// provider is downloaded only once. It is created if it doesn't exist. As Terraform does not support concurrent
+	// installs into the cache, with Parallelism the first module is validated before the others, to fill the cache. If
+	// the modules use providers, or provider versions, that the first module does not, set Parallelism to 0.
+	PluginCacheDir string
+	// If set, a ValidationSummary of all the modules is written to this path as JSON.
+	JSONReportPath string
+	// If set, a ValidationSummary of all the modules is written to this path as a JUnit XML report.
+	JUnitReportPath string
+	// Checks, such as running tflint, to run on each module after validating it.
+	AdditionalChecks []ValidationCheck
 }
 
+// ValidationCheck is an additional check to run on each module found by ValidateAllTerraformModules and
+// OPAEvalAllTerraformModules. The TerraformDir of the given terraform.Options is the copy of the module in a temp
+// folder. Return an error to fail the validation of the module; its first line with a diagnostic goes in the
+// ValidationSummary.
+type ValidationCheck func(t *go_test.T, opts *ValidationOptions, tfOpts *terraform.Options) error
+
 // configureBaseValidationOptions returns a pointer to a ValidationOptions struct configured with sane, override-able defaults
 // Note that the ValidationOptions's fields IncludeDirs and ExcludeDirs must be absolute paths, but this method will accept relative paths
 // and build the absolute paths when instantiating the ValidationOptions struct,  making it the preferred means of configuring
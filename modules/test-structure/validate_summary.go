@@ -0,0 +1,159 @@
+package test_structure
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jstemmer/go-junit-report/formatter"
+)
+
+// ModuleValidationResult is the result of validating a single module with ValidateAllTerraformModules or
+// OPAEvalAllTerraformModules.
+type ModuleValidationResult struct {
+	// The path of the module, relative to the RootDir of the ValidationOptions
+	Module          string  `json:"module"`
+	Passed          bool    `json:"passed"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	// The first diagnostic (e.g. "Error: Unsupported argument") of the failed validation or check
+	Diagnostic string `json:"diagnostic,omitempty"`
+}
+
+// ValidationSummary is the aggregated result of validating all the modules with ValidateAllTerraformModules or
+// OPAEvalAllTerraformModules.
+type ValidationSummary struct {
+	Passed          int                      `json:"passed"`
+	Failed          int                      `json:"failed"`
+	DurationSeconds float64                  `json:"durationSeconds"`
+	Modules         []ModuleValidationResult `json:"modules"`
+}
+
+// WriteValidationSummaryJSON writes the given summary to the given path as JSON.
+func WriteValidationSummaryJSON(path string, summary ValidationSummary) error {
+	bytes, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeValidationReport(path, bytes)
+}
+
+// WriteValidationSummaryJUnit writes the given summary to the given path as a JUnit XML report, with a test case for
+// each module.
+func WriteValidationSummaryJUnit(path string, summary ValidationSummary) error {
+	suite := formatter.JUnitTestSuite{
+		Tests:     len(summary.Modules),
+		Failures:  summary.Failed,
+		Time:      formatJUnitTime(summary.DurationSeconds),
+		Name:      "terraform-validation",
+		TestCases: []formatter.JUnitTestCase{},
+	}
+	for _, module := range summary.Modules {
+		testCase := formatter.JUnitTestCase{
+			Classname: "terraform-validation",
+			Name:      module.Module,
+			Time:      formatJUnitTime(module.DurationSeconds),
+		}
+		if !module.Passed {
+			testCase.Failure = &formatter.JUnitFailure{
+				Message:  "Failed",
+				Contents: module.Diagnostic,
+			}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	bytes, err := xml.MarshalIndent(formatter.JUnitTestSuites{Suites: []formatter.JUnitTestSuite{suite}}, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeValidationReport(path, append([]byte(xml.Header), bytes...))
+}
+
+func writeValidationReport(path string, contents []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, contents, 0644)
+}
+
+func formatJUnitTime(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
+
+// validationResults collects the results of the modules, which may be validated concurrently.
+type validationResults struct {
+	mutex   sync.Mutex
+	results []ModuleValidationResult
+}
+
+// add records the result of validating the module in the given dir.
+func (results *validationResults) add(rootDir string, dir string, duration time.Duration, failed bool, err error) {
+	module, relErr := filepath.Rel(rootDir, dir)
+	if relErr != nil {
+		module = dir
+	}
+	result := ModuleValidationResult{
+		Module:          filepath.ToSlash(module),
+		Passed:          !failed,
+		DurationSeconds: duration.Seconds(),
+	}
+	if failed {
+		result.Diagnostic = firstDiagnostic(err)
+	}
+
+	results.mutex.Lock()
+	defer results.mutex.Unlock()
+	results.results = append(results.results, result)
+}
+
+// summary returns the summary of the results recorded so far, with the modules sorted by path.
+func (results *validationResults) summary(duration time.Duration) ValidationSummary {
+	results.mutex.Lock()
+	defer results.mutex.Unlock()
+
+	summary := ValidationSummary{
+		DurationSeconds: duration.Seconds(),
+		Modules:         append([]ModuleValidationResult{}, results.results...),
+	}
+	sort.Slice(summary.Modules, func(i, j int) bool { return summary.Modules[i].Module < summary.Modules[j].Module })
+	for _, module := range summary.Modules {
+		if module.Passed {
+			summary.Passed++
+		} else {
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+// firstDiagnostic returns the first "Error: ..." line of the given error, which is typically the stderr of Terraform,
+// or if there is none, its first line.
+func firstDiagnostic(err error) string {
+	if err == nil {
+		return "Validation failed, see the test output for details"
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(err.Error(), "\n") {
+		// Terraform draws a box around diagnostics, e.g. "│ Error: Unsupported argument"
+		line = strings.TrimSpace(strings.TrimLeft(line, "│╷╵ \t"))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	for _, line := range lines {
+		if index := strings.Index(line, "Error: "); index >= 0 {
+			return line[index:]
+		}
+	}
+	if len(lines) > 0 {
+		return lines[0]
+	}
+	return err.Error()
+}
@@ -0,0 +1,110 @@
+package test_structure
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirstDiagnostic(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"nil", nil, "Validation failed, see the test output for details"},
+		{"terraform", errors.New("error while running command: exit status 1; ╷\n│ Error: Unsupported argument\n│ \n│   on main.tf line 2\n╵"), "Error: Unsupported argument"},
+		{"no diagnostic", errors.New("\nopa eval failed\nsecond line"), "opa eval failed"},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, firstDiagnostic(testCase.err), testCase.name)
+	}
+}
+
+func TestValidationSummaryReports(t *testing.T) {
+	t.Parallel()
+
+	results := &validationResults{}
+	results.add("/project", "/project/modules/vpc", 2*time.Second, true, errors.New("│ Error: Unsupported argument"))
+	results.add("/project", "/project/examples/vpc", time.Second, false, nil)
+	summary := results.summary(3 * time.Second)
+
+	assert.Equal(t, ValidationSummary{
+		Passed:          1,
+		Failed:          1,
+		DurationSeconds: 3,
+		Modules: []ModuleValidationResult{
+			{Module: "examples/vpc", Passed: true, DurationSeconds: 1},
+			{Module: "modules/vpc", Passed: false, DurationSeconds: 2, Diagnostic: "Error: Unsupported argument"},
+		},
+	}, summary)
+
+	junitPath := filepath.Join(t.TempDir(), "reports", "validation.xml")
+	require.NoError(t, WriteValidationSummaryJUnit(junitPath, summary))
+	junit, err := os.ReadFile(junitPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(junit), `<testsuite tests="2" failures="1" time="3.000" name="terraform-validation">`)
+	assert.Contains(t, string(junit), `<failure message="Failed" type="">Error: Unsupported argument</failure>`)
+}
+
+func TestRunValidateOnAllTerraformModulesInParallel(t *testing.T) {
+	t.Parallel()
+
+	opts, err := NewValidationOptions("../../test/fixtures", []string{"terraform-validation-valid", "terraform-null", "terraform-output"}, nil)
+	require.NoError(t, err)
+	opts.Parallelism = 2
+	opts.PluginCacheDir = filepath.Join(t.TempDir(), "plugin-cache")
+	opts.JSONReportPath = filepath.Join(t.TempDir(), "validation.json")
+
+	var running, maxRunning, checked int32
+	opts.AdditionalChecks = []ValidationCheck{
+		func(t *testing.T, opts *ValidationOptions, tfOpts *terraform.Options) error {
+			atomic.AddInt32(&checked, 1)
+			return nil
+		},
+	}
+	validate := func(t *testing.T, fileType ValidateFileType, tfOpts *terraform.Options) error {
+		assert.Equal(t, opts.PluginCacheDir, tfOpts.EnvVars["TF_PLUGIN_CACHE_DIR"])
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	}
+
+	// The report is written once all the parallel subtests have completed, which is before t.Run returns
+	t.Run("validate", func(t *testing.T) {
+		runValidateOnAllTerraformModules(t, opts, validate)
+	})
+
+	assert.DirExists(t, opts.PluginCacheDir)
+	assert.Equal(t, int32(3), checked)
+	assert.LessOrEqual(t, maxRunning, int32(2))
+
+	bytes, err := os.ReadFile(opts.JSONReportPath)
+	require.NoError(t, err)
+	var summary ValidationSummary
+	require.NoError(t, json.Unmarshal(bytes, &summary))
+	assert.Equal(t, 3, summary.Passed)
+	modules := []string{}
+	for _, module := range summary.Modules {
+		modules = append(modules, module.Module)
+	}
+	assert.Equal(t, []string{"terraform-null", "terraform-output", "terraform-validation-valid"}, modules)
+}
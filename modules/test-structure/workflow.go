@@ -0,0 +1,363 @@
+package test_structure
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/tnn-gruntwork-io/terratest/modules/logger"
+	"github.com/tnn-gruntwork-io/terratest/modules/testing"
+)
+
+const (
+	// RUN_FROM_STAGE_ENV_VAR is the environment variable that selects the first stage of a Workflow to run. The stages
+	// before it are skipped, and their data is loaded from the test folder instead.
+	RUN_FROM_STAGE_ENV_VAR = "RUN_FROM_STAGE"
+	// STOP_AFTER_STAGE_ENV_VAR is the environment variable that selects the last stage of a Workflow to run. The stages
+	// after it, including the teardown stages, are skipped.
+	STOP_AFTER_STAGE_ENV_VAR = "STOP_AFTER_STAGE"
+)
+
+// The values of the -run-from-stage and -stop-after-stage flags, if registered with RegisterFlags
+var (
+	runFromStageFlag   string
+	stopAfterStageFlag string
+)
+
+// RegisterFlags registers the -run-from-stage and -stop-after-stage flags on the given flag set, which take precedence
+// over the RUN_FROM_STAGE and STOP_AFTER_STAGE environment variables, and the -force-stages flag, which is equivalent
+// to setting FORCE_STAGES=true. The flags are not registered unless this is called, so that importing this package
+// doesn't add flags to every test binary, or clash with flags of the same name. To use them, register them on the
+// default flag set in the test package, before the flags are parsed:
+//
+//	func init() {
+//		test_structure.RegisterFlags(flag.CommandLine)
+//	}
+//
+// E.g.: go test -run TestFoo -args -run-from-stage=validate -force-stages
+func RegisterFlags(flagSet *flag.FlagSet) {
+	flagSet.StringVar(&runFromStageFlag, "run-from-stage", "", "Name of the first test_structure.Workflow stage to run. Overrides the "+RUN_FROM_STAGE_ENV_VAR+" environment variable.")
+	flagSet.StringVar(&stopAfterStageFlag, "stop-after-stage", "", "Name of the last test_structure.Workflow stage to run. Overrides the "+STOP_AFTER_STAGE_ENV_VAR+" environment variable.")
+	flagSet.BoolVar(&forceStagesFlag, "force-stages", false, "Run all resumable test stages, even the ones that completed in an earlier run. Equivalent to setting "+FORCE_STAGES_ENV_VAR+"=true.")
+}
+
+// workflowStage is a single stage registered in a Workflow.
+type workflowStage struct {
+	name      string
+	dependsOn []string
+	teardown  bool
+	run       func(t testing.TestingT)
+
+	// The type of the data this stage saves, or nil if it saves none
+	dataType reflect.Type
+}
+
+// Workflow runs a test as a sequence of named stages, such as deploy, validate and teardown, which is a more structured
+// alternative to calling RunTestStage for each stage:
+//
+//	workflow := test_structure.NewWorkflow(testFolder)
+//
+//	test_structure.AddDataStage(workflow, "deploy", nil, func(t testing.TestingT) *terraform.Options {
+//		terraformOptions := &terraform.Options{TerraformDir: testFolder}
+//		terraform.InitAndApply(t, terraformOptions)
+//		return terraformOptions
+//	})
+//	workflow.AddStage("validate", []string{"deploy"}, func(t testing.TestingT) {
+//		terraformOptions := test_structure.LoadStageData[*terraform.Options](t, workflow, "deploy")
+//		...
+//	})
+//	workflow.AddTeardownStage("teardown", func(t testing.TestingT) {
+//		terraform.Destroy(t, test_structure.LoadStageData[*terraform.Options](t, workflow, "deploy"))
+//	})
+//
+//	workflow.Run(t)
+//
+// Stages run in the order they were added, except that a stage always runs after the stages it depends on. Teardown
+// stages run after all the other stages, in reverse order of being added, and always run, even if an earlier stage
+// failed the test with t.FailNow or panicked.
+//
+// The data a stage returns is saved in the test folder with Save, so that when a later stage is run on its
+// own, it can load the data saved by an earlier run. To run only some of the stages, e.g. to iterate on validation
+// locally without redeploying:
+//
+//	STOP_AFTER_STAGE=deploy go test -run TestFoo  # deploy, and skip validation and teardown
+//	RUN_FROM_STAGE=validate STOP_AFTER_STAGE=validate go test -run TestFoo  # run only validation, as often as needed
+//	RUN_FROM_STAGE=teardown go test -run TestFoo  # clean up
+//
+// The -run-from-stage and -stop-after-stage test flags can be used instead of the environment variables, once
+// registered with RegisterFlags. As with RunTestStage, a stage is also skipped if the SKIP_<stage> environment variable
+// is set.
+//
+// Set Resumable to skip the regular stages that completed in an earlier run, as RunResumableTestStage does, so that a
+// rerun after a failure resumes from the failed stage. The recorded progress is cleared once all the teardown stages
+// have run successfully.
+type Workflow struct {
+	Resumable bool
+
+	testFolder string
+	stages     []*workflowStage
+	stageNames map[string]*workflowStage
+
+	// Data returned by the data stages that ran in this process, keyed by stage name
+	data map[string]interface{}
+}
+
+// NewWorkflow returns an empty Workflow that saves the data of its stages in the given test folder.
+func NewWorkflow(testFolder string) *Workflow {
+	return &Workflow{
+		testFolder: testFolder,
+		stageNames: map[string]*workflowStage{},
+		data:       map[string]interface{}{},
+	}
+}
+
+// AddStage adds a stage with the given name that runs after the stages it depends on.
+func (workflow *Workflow) AddStage(name string, dependsOn []string, run func(t testing.TestingT)) {
+	workflow.addStage(&workflowStage{name: name, dependsOn: dependsOn, run: run})
+}
+
+// AddTeardownStage adds a stage with the given name that always runs after all the other stages, even if the test has
+// failed. Teardown stages run in reverse order of being added.
+func (workflow *Workflow) AddTeardownStage(name string, run func(t testing.TestingT)) {
+	workflow.addStage(&workflowStage{name: name, teardown: true, run: run})
+}
+
+// AddDataStage adds a stage with the given name that runs after the stages it depends on, and returns data for later
+// stages. The data is saved in the test folder with Save, and can be loaded with LoadStageData.
+func AddDataStage[T any](workflow *Workflow, name string, dependsOn []string, run func(t testing.TestingT) T) {
+	workflow.addStage(&workflowStage{
+		name:      name,
+		dependsOn: dependsOn,
+		dataType:  reflect.TypeOf((*T)(nil)).Elem(),
+		run: func(t testing.TestingT) {
+			value := run(t)
+			workflow.data[name] = value
+			Save(t, workflow.formatStageDataPath(name), value)
+		},
+	})
+}
+
+// LoadStageData returns the data returned by the data stage with the given name. If that stage did not run in this
+// test run, e.g. because of RUN_FROM_STAGE, the data is loaded from the test folder, where it was saved by an earlier
+// run. Fail the test if the stage does not exist, if it returns a different type, or if it has never run.
+func LoadStageData[T any](t testing.TestingT, workflow *Workflow, stageName string) T {
+	var value T
+
+	stage, exists := workflow.stageNames[stageName]
+	if !exists {
+		t.Fatalf("Workflow has no stage named '%s'", stageName)
+		return value
+	}
+	expectedType := reflect.TypeOf((*T)(nil)).Elem()
+	if stage.dataType != expectedType {
+		t.Fatalf("Stage '%s' returns data of type %v, not %v", stageName, stage.dataType, expectedType)
+		return value
+	}
+
+	if data, ran := workflow.data[stageName]; ran {
+		return data.(T)
+	}
+
+	path := workflow.formatStageDataPath(stageName)
+	if !IsTestDataPresent(t, path) {
+		t.Fatalf("No data for stage '%s' at %s. Has the stage run?", stageName, path)
+		return value
+	}
+	return Load[T](t, path)
+}
+
+// Run runs the stages of the workflow, as selected by the -run-from-stage and -stop-after-stage flags or the
+// RUN_FROM_STAGE and STOP_AFTER_STAGE environment variables. Fail the test if the stages or their dependencies are
+// invalid.
+func (workflow *Workflow) Run(t testing.TestingT) {
+	stages, err := workflow.orderedStagesE()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	runFrom, stopAfter := selectedStageRange()
+	for _, name := range []string{runFrom, stopAfter} {
+		if _, exists := workflow.stageNames[name]; name != "" && !exists {
+			t.Fatalf("Workflow has no stage named '%s'", name)
+			return
+		}
+	}
+	firstIndex, lastIndex := 0, len(stages)-1
+	for i, stage := range stages {
+		if stage.name == runFrom {
+			firstIndex = i
+		}
+		if stage.name == stopAfter {
+			lastIndex = i
+		}
+	}
+
+	selected := []*workflowStage{}
+	for i, stage := range stages {
+		if i < firstIndex || i > lastIndex {
+			logger.Logf(t, "Skipping stage '%s', as it is outside of the selected stages", stage.name)
+			continue
+		}
+		selected = append(selected, stage)
+	}
+
+	regularStages := []*workflowStage{}
+	teardownStages := []*workflowStage{}
+	for _, stage := range selected {
+		if stage.teardown {
+			teardownStages = append(teardownStages, stage)
+		} else {
+			regularStages = append(regularStages, stage)
+		}
+	}
+
+	// Once everything has been torn down, the next run has to start from the beginning. Deferred before the teardown
+	// stages, so that it runs after them.
+	teardownStagesSucceeded := 0
+	if workflow.Resumable && len(teardownStages) > 0 {
+		defer func() {
+			if teardownStagesSucceeded == len(teardownStages) {
+				ClearTestProgress(t, workflow.testFolder)
+			}
+		}()
+	}
+
+	// Deferred, so that the teardown stages run even if a stage calls t.FailNow (which calls runtime.Goexit) or panics
+	defer runTeardownStages(t, teardownStages, func() { teardownStagesSucceeded++ })
+
+	for _, stage := range regularStages {
+		if hasFailed(t) {
+			logger.Logf(t, "Skipping stage '%s', as the test has already failed", stage.name)
+			continue
+		}
+		if workflow.Resumable {
+			RunResumableTestStage(t, workflow.testFolder, stage.name, func() { stage.run(t) })
+		} else {
+			RunTestStage(t, stage.name, func() { stage.run(t) })
+		}
+	}
+}
+
+// runTeardownStages runs the given stages in order, calling onSuccess after each stage that ran to completion (i.e., was
+// not skipped, and did not fail the test with t.FailNow or panic, or with t.Error if the test had not failed before).
+// Each stage runs in a deferred call of the one before it, so that a stage that fails the test with t.FailNow or panics
+// does not prevent the following stages from running.
+func runTeardownStages(t testing.TestingT, stages []*workflowStage, onSuccess func()) {
+	if len(stages) == 0 {
+		return
+	}
+	defer runTeardownStages(t, stages[1:], onSuccess)
+
+	failedBefore := hasFailed(t)
+	RunTestStage(t, stages[0].name, func() {
+		stages[0].run(t)
+		if failedBefore || !hasFailed(t) {
+			onSuccess()
+		}
+	})
+}
+
+// orderedStagesE returns the stages in the order they run: the regular stages in the order they were added, with each
+// stage moved after the stages it depends on, followed by the teardown stages in reverse order of being added.
+func (workflow *Workflow) orderedStagesE() ([]*workflowStage, error) {
+	ordered := []*workflowStage{}
+	visited := map[string]bool{}
+	visiting := map[string]bool{}
+
+	var visit func(stage *workflowStage) error
+	visit = func(stage *workflowStage) error {
+		if visited[stage.name] {
+			return nil
+		}
+		if visiting[stage.name] {
+			return WorkflowDependencyCycle{Stage: stage.name}
+		}
+		visiting[stage.name] = true
+		for _, dependencyName := range stage.dependsOn {
+			dependency, exists := workflow.stageNames[dependencyName]
+			if !exists {
+				return UnknownStageDependency{Stage: stage.name, Dependency: dependencyName}
+			}
+			if dependency.teardown {
+				return UnknownStageDependency{Stage: stage.name, Dependency: dependencyName}
+			}
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		visiting[stage.name] = false
+		visited[stage.name] = true
+		ordered = append(ordered, stage)
+		return nil
+	}
+
+	for _, stage := range workflow.stages {
+		if stage.teardown {
+			continue
+		}
+		if err := visit(stage); err != nil {
+			return nil, err
+		}
+	}
+	for i := len(workflow.stages) - 1; i >= 0; i-- {
+		if workflow.stages[i].teardown {
+			ordered = append(ordered, workflow.stages[i])
+		}
+	}
+	return ordered, nil
+}
+
+func (workflow *Workflow) addStage(stage *workflowStage) {
+	if _, exists := workflow.stageNames[stage.name]; exists {
+		panic(fmt.Sprintf("Workflow already has a stage named '%s'", stage.name))
+	}
+	workflow.stages = append(workflow.stages, stage)
+	workflow.stageNames[stage.name] = stage
+}
+
+// formatStageDataPath formats a path to save the data of the given stage in the test folder of the workflow.
+func (workflow *Workflow) formatStageDataPath(stageName string) string {
+	return formatNamedTestDataPath(workflow.testFolder, "Stage-"+stageName)
+}
+
+// selectedStageRange returns the names of the first and last stages to run, from the command line flags or the
+// environment variables. Either is empty if not set.
+func selectedStageRange() (string, string) {
+	runFrom := os.Getenv(RUN_FROM_STAGE_ENV_VAR)
+	if runFromStageFlag != "" {
+		runFrom = runFromStageFlag
+	}
+	stopAfter := os.Getenv(STOP_AFTER_STAGE_ENV_VAR)
+	if stopAfterStageFlag != "" {
+		stopAfter = stopAfterStageFlag
+	}
+	return runFrom, stopAfter
+}
+
+// hasFailed returns true if t supports reporting failures (like *testing.T) and the test has failed.
+func hasFailed(t testing.TestingT) bool {
+	failer, ok := t.(interface{ Failed() bool })
+	return ok && failer.Failed()
+}
+
+// WorkflowDependencyCycle is an error that occurs if the dependencies of Workflow stages form a cycle.
+type WorkflowDependencyCycle struct {
+	Stage string
+}
+
+func (err WorkflowDependencyCycle) Error() string {
+	return fmt.Sprintf("Workflow stage '%s' depends on itself through its dependencies", err.Stage)
+}
+
+// UnknownStageDependency is an error that occurs if a Workflow stage depends on a stage that does not exist, or on a
+// teardown stage.
+type UnknownStageDependency struct {
+	Stage      string
+	Dependency string
+}
+
+func (err UnknownStageDependency) Error() string {
+	return fmt.Sprintf("Workflow stage '%s' depends on '%s', which is not a regular stage of the workflow", err.Stage, err.Dependency)
+}
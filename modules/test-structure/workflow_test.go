@@ -0,0 +1,275 @@
+package test_structure
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"testing"
+
+	terratesting "github.com/tnn-gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeT records failures, and stops the calling goroutine on FailNow like *testing.T does.
+type fakeT struct {
+	failed bool
+	errors []string
+}
+
+func (t *fakeT) Fail()    { t.failed = true }
+func (t *fakeT) FailNow() { t.failed = true; runtime.Goexit() }
+func (t *fakeT) Fatal(args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprint(args...))
+	t.FailNow()
+}
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+	t.FailNow()
+}
+func (t *fakeT) Error(args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprint(args...))
+	t.Fail()
+}
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+	t.Fail()
+}
+func (t *fakeT) Name() string { return "fakeT" }
+func (t *fakeT) Failed() bool { return t.failed }
+
+// runWithFakeT runs the workflow with a fakeT in its own goroutine, so that FailNow does not stop the calling test.
+func runWithFakeT(workflow *Workflow) *fakeT {
+	t := &fakeT{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		workflow.Run(t)
+	}()
+	<-done
+	return t
+}
+
+// newRecordingWorkflow returns a workflow whose stages record their names in ran when they run.
+func newRecordingWorkflow(testFolder string, ran *[]string) *Workflow {
+	record := func(name string) func(t terratesting.TestingT) {
+		return func(t terratesting.TestingT) { *ran = append(*ran, name) }
+	}
+
+	workflow := NewWorkflow(testFolder)
+	workflow.AddTeardownStage("destroy_cluster", record("destroy_cluster"))
+	workflow.AddStage("validate", []string{"deploy_app"}, record("validate"))
+	workflow.AddStage("deploy_cluster", nil, record("deploy_cluster"))
+	workflow.AddStage("deploy_app", []string{"deploy_cluster"}, record("deploy_app"))
+	workflow.AddTeardownStage("destroy_app", record("destroy_app"))
+	return workflow
+}
+
+func TestWorkflowRunsStagesInDependencyOrder(t *testing.T) {
+	t.Parallel()
+
+	ran := []string{}
+	workflow := newRecordingWorkflow(t.TempDir(), &ran)
+	workflow.Run(t)
+
+	assert.Equal(t, []string{"deploy_cluster", "deploy_app", "validate", "destroy_app", "destroy_cluster"}, ran)
+}
+
+func TestWorkflowRunsTeardownAfterFailNow(t *testing.T) {
+	t.Parallel()
+
+	ran := []string{}
+	workflow := NewWorkflow(t.TempDir())
+	workflow.AddStage("deploy", nil, func(t terratesting.TestingT) {
+		ran = append(ran, "deploy")
+		t.Fatal("deploy failed")
+	})
+	workflow.AddStage("validate", []string{"deploy"}, func(t terratesting.TestingT) { ran = append(ran, "validate") })
+	workflow.AddTeardownStage("teardown_1", func(t terratesting.TestingT) { ran = append(ran, "teardown_1") })
+	workflow.AddTeardownStage("teardown_2", func(t terratesting.TestingT) {
+		ran = append(ran, "teardown_2")
+		panic("teardown failed")
+	})
+
+	fake := &fakeT{}
+	done := make(chan interface{})
+	go func() {
+		defer func() { done <- recover() }()
+		workflow.Run(fake)
+	}()
+	panicValue := <-done
+
+	assert.Equal(t, []string{"deploy", "teardown_2", "teardown_1"}, ran)
+	assert.Equal(t, "teardown failed", panicValue)
+	assert.True(t, fake.failed)
+}
+
+func TestWorkflowSkipsStagesAfterNonFatalFailure(t *testing.T) {
+	t.Parallel()
+
+	ran := []string{}
+	workflow := NewWorkflow(t.TempDir())
+	workflow.AddStage("deploy", nil, func(t terratesting.TestingT) {
+		ran = append(ran, "deploy")
+		t.Error("deploy failed")
+	})
+	workflow.AddStage("validate", []string{"deploy"}, func(t terratesting.TestingT) { ran = append(ran, "validate") })
+	workflow.AddTeardownStage("teardown", func(t terratesting.TestingT) { ran = append(ran, "teardown") })
+
+	fake := runWithFakeT(workflow)
+	assert.True(t, fake.failed)
+	assert.Equal(t, []string{"deploy", "teardown"}, ran)
+}
+
+func TestWorkflowRejectsInvalidDependencies(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		setup         func(workflow *Workflow)
+		expectedError error
+	}{
+		{
+			"unknown dependency",
+			func(workflow *Workflow) {
+				workflow.AddStage("validate", []string{"deploy"}, func(t terratesting.TestingT) {})
+			},
+			UnknownStageDependency{Stage: "validate", Dependency: "deploy"},
+		},
+		{
+			"teardown dependency",
+			func(workflow *Workflow) {
+				workflow.AddTeardownStage("teardown", func(t terratesting.TestingT) {})
+				workflow.AddStage("validate", []string{"teardown"}, func(t terratesting.TestingT) {})
+			},
+			UnknownStageDependency{Stage: "validate", Dependency: "teardown"},
+		},
+		{
+			"cycle",
+			func(workflow *Workflow) {
+				workflow.AddStage("a", []string{"b"}, func(t terratesting.TestingT) {})
+				workflow.AddStage("b", []string{"a"}, func(t terratesting.TestingT) {})
+			},
+			WorkflowDependencyCycle{Stage: "a"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			workflow := NewWorkflow(t.TempDir())
+			testCase.setup(workflow)
+			_, err := workflow.orderedStagesE()
+			assert.Equal(t, testCase.expectedError, err)
+		})
+	}
+}
+
+func TestWorkflowStageDataIsTyped(t *testing.T) {
+	t.Parallel()
+
+	type deployment struct {
+		Name     string
+		Replicas int
+	}
+
+	testFolder := t.TempDir()
+	var loaded deployment
+	workflow := NewWorkflow(testFolder)
+	AddDataStage(workflow, "deploy", nil, func(t terratesting.TestingT) deployment {
+		return deployment{Name: "app", Replicas: 3}
+	})
+	workflow.AddStage("validate", []string{"deploy"}, func(t terratesting.TestingT) {
+		loaded = LoadStageData[deployment](t, workflow, "deploy")
+	})
+	workflow.Run(t)
+
+	assert.Equal(t, deployment{Name: "app", Replicas: 3}, loaded)
+	assert.True(t, IsTestDataPresent(t, workflow.formatStageDataPath("deploy")))
+
+	// Loading with the wrong type fails the test
+	fake := &fakeT{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		LoadStageData[string](fake, workflow, "deploy")
+	}()
+	<-done
+	assert.True(t, fake.failed)
+	require.Len(t, fake.errors, 1)
+	assert.Contains(t, fake.errors[0], "returns data of type")
+}
+
+// Not parallel, as it sets environment variables
+func TestWorkflowRunsSelectedStages(t *testing.T) {
+	type deployment struct {
+		Name string
+	}
+
+	testFolder := t.TempDir()
+	newWorkflow := func(ran *[]string) *Workflow {
+		workflow := NewWorkflow(testFolder)
+		AddDataStage(workflow, "deploy", nil, func(t terratesting.TestingT) deployment {
+			*ran = append(*ran, "deploy")
+			return deployment{Name: "app"}
+		})
+		workflow.AddStage("validate", []string{"deploy"}, func(t terratesting.TestingT) {
+			*ran = append(*ran, "validate:"+LoadStageData[deployment](t, workflow, "deploy").Name)
+		})
+		workflow.AddTeardownStage("teardown", func(t terratesting.TestingT) { *ran = append(*ran, "teardown") })
+		return workflow
+	}
+
+	// Deploy only, which saves the data of the deploy stage
+	ran := []string{}
+	t.Setenv(STOP_AFTER_STAGE_ENV_VAR, "deploy")
+	newWorkflow(&ran).Run(t)
+	assert.Equal(t, []string{"deploy"}, ran)
+	assert.True(t, SkipStageEnvVarSet())
+
+	// Validate only, with the data loaded from the earlier run
+	ran = []string{}
+	t.Setenv(RUN_FROM_STAGE_ENV_VAR, "validate")
+	t.Setenv(STOP_AFTER_STAGE_ENV_VAR, "validate")
+	newWorkflow(&ran).Run(t)
+	assert.Equal(t, []string{"validate:app"}, ran)
+
+	// Teardown only
+	ran = []string{}
+	t.Setenv(RUN_FROM_STAGE_ENV_VAR, "teardown")
+	t.Setenv(STOP_AFTER_STAGE_ENV_VAR, "")
+	newWorkflow(&ran).Run(t)
+	assert.Equal(t, []string{"teardown"}, ran)
+
+	// Unknown stage names fail the test
+	ran = []string{}
+	t.Setenv(RUN_FROM_STAGE_ENV_VAR, "does_not_exist")
+	fake := runWithFakeT(newWorkflow(&ran))
+	assert.True(t, fake.failed)
+	assert.Empty(t, ran)
+}
+
+// Not parallel, as it sets the flags of the package
+func TestRegisterFlagsOverridesEnvVars(t *testing.T) {
+	defer func() {
+		runFromStageFlag = ""
+		stopAfterStageFlag = ""
+		forceStagesFlag = false
+	}()
+
+	t.Setenv(RUN_FROM_STAGE_ENV_VAR, "deploy")
+	t.Setenv(STOP_AFTER_STAGE_ENV_VAR, "teardown")
+	flagSet := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	RegisterFlags(flagSet)
+
+	require.NoError(t, flagSet.Parse([]string{"-run-from-stage=validate"}))
+	runFrom, stopAfter := selectedStageRange()
+	assert.Equal(t, "validate", runFrom)
+	assert.Equal(t, "teardown", stopAfter)
+	assert.False(t, forceStages())
+
+	require.NoError(t, flagSet.Parse([]string{"-force-stages"}))
+	assert.True(t, forceStages())
+}
@@ -79,6 +79,38 @@ func TestHelmBasicExampleTemplateRenderedDeployment(t *testing.T) {
 	require.Equal(t, deploymentContainers[0].Image, expectedContainerImage)
 }
 
+// An example of how to decode all the objects rendered by a Helm Chart, and look them up by kind and name.
+func TestHelmBasicExampleTemplateRenderedManifests(t *testing.T) {
+	t.Parallel()
+
+	// Path to the helm chart we will test
+	helmChartPath, err := filepath.Abs("../examples/helm-basic-example")
+	releaseName := "helm-basic"
+	require.NoError(t, err)
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"containerImageRepo": "nginx",
+			"containerImageTag":  "1.15.8",
+		},
+	}
+
+	// Render all the templates of the chart, and decode each rendered object into its client-go struct.
+	manifests := helm.RenderTemplateManifests(t, options, helmChartPath, releaseName, []string{})
+
+	// Look up the Deployment by kind and name, and verify its container image.
+	object, found := manifests.Find("Deployment", "helm-basic-helm-basic-example")
+	require.True(t, found)
+	deployment := object.(*appsv1.Deployment)
+	require.Equal(t, "nginx:1.15.8", deployment.Spec.Template.Spec.Containers[0].Image)
+	require.Len(t, manifests.FindAll("Service"), 1)
+
+	// The example chart does not set resource limits, which a query over all the containers reveals.
+	withoutLimits, err := manifests.ContainersWithoutResourceLimits()
+	require.NoError(t, err)
+	require.Len(t, withoutLimits, 1)
+}
+
 // An example of how to verify required values for a helm chart.
 func TestHelmBasicExampleTemplateRequiredTemplateArgs(t *testing.T) {
 	t.Parallel()